@@ -17,6 +17,7 @@ require (
 	go.uber.org/zap v1.26.0
 	golang.org/x/oauth2 v0.15.0
 	github.com/pquerna/otp v1.4.0
+	github.com/go-webauthn/webauthn v0.11.2
 	gorm.io/driver/postgres v1.5.4
 	gorm.io/gorm v1.25.5
 	github.com/casbin/casbin/v2 v2.81.0
@@ -26,6 +27,7 @@ require (
 	github.com/twilio/twilio-go v1.18.2
 	github.com/aws/aws-sdk-go v1.48.16
 	github.com/gorilla/websocket v1.5.1
+	github.com/alicebob/miniredis/v2 v2.31.1
 )
 
 require (
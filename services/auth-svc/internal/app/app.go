@@ -10,13 +10,25 @@ import (
 	"syscall"
 	"time"
 
+	"pagemagic/auth-svc/internal/captcha"
 	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/events"
 	"pagemagic/auth-svc/internal/handlers"
+	"pagemagic/auth-svc/internal/janitor"
+	"pagemagic/auth-svc/internal/mailer"
+	"pagemagic/auth-svc/internal/migrations"
+	"pagemagic/auth-svc/internal/oauth"
+	"pagemagic/auth-svc/internal/ratelimit"
 	"pagemagic/auth-svc/internal/repository"
 	"pagemagic/auth-svc/internal/services"
+	"pagemagic/auth-svc/internal/signing"
+	"pagemagic/auth-svc/internal/sms"
+	"pagemagic/auth-svc/pkg/authz"
+	"pagemagic/auth-svc/pkg/authz/ginauthz"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 )
 
 type App struct {
@@ -36,6 +48,13 @@ func (a *App) Run() error {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	if a.config.Database.MigrateOnBoot {
+		log.Println("Applying database migrations...")
+		if err := migrations.Up(context.Background(), a.config.DatabaseURL()); err != nil {
+			return err
+		}
+	}
+
 	// Inicializar repositório
 	repo, err := repository.New(a.config.DatabaseURL())
 	if err != nil {
@@ -43,14 +62,92 @@ func (a *App) Run() error {
 	}
 	defer repo.Close()
 
+	// Inicializar Redis
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     a.config.RedisAddr(),
+		Password: a.config.Redis.Password,
+		DB:       a.config.Redis.DB,
+	})
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	defer redisClient.Close()
+
+	// Publicar eventos da outbox no NATS
+	publisher, err := events.NewNATSPublisher(a.config.NATS)
+	if err != nil {
+		return fmt.Errorf("failed to initialize event publisher: %w", err)
+	}
+	defer publisher.Close()
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go events.NewRelay(repo, publisher).Run(workerCtx)
+
+	// Inicializar envio de emails
+	mail, err := mailer.New(a.config.Email)
+	if err != nil {
+		return fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+
+	// Inicializar chaves de assinatura dos access tokens
+	keys, err := a.loadSigningKeys()
+	if err != nil {
+		return err
+	}
+
 	// Inicializar serviços
-	authService := services.NewAuthService(repo, a.config)
+	authService := services.NewAuthService(repo, keys, services.NewRedisDenylist(redisClient), mail, a.config)
+	if err := authService.EnsureDefaultRoles(context.Background()); err != nil {
+		return err
+	}
+
+	providers, err := oauth.NewProviders(a.config.OAuth)
+	if err != nil {
+		return fmt.Errorf("failed to initialize oauth providers: %w", err)
+	}
+	oauthService := services.NewOAuthService(authService, repo, providers, redisClient)
+
+	organizationService := services.NewOrganizationService(authService, repo)
+	introspectionService := services.NewIntrospectionService(authService, a.config.Introspection)
+
+	passkeyService, err := services.NewPasskeyService(authService, repo, a.config.WebAuthn, redisClient)
+	if err != nil {
+		return fmt.Errorf("failed to initialize passkeys: %w", err)
+	}
+
+	smsSender, err := a.smsSender()
+	if err != nil {
+		return fmt.Errorf("failed to initialize sms provider: %w", err)
+	}
+	phoneService := services.NewPhoneService(authService, repo, smsSender)
+
+	// Exportação e exclusão de dados da conta (LGPD/GDPR)
+	privacyService := services.NewPrivacyService(authService, repo, a.config.Privacy)
+	go privacyService.Run(workerCtx)
+
+	// Limpeza de registros expirados, executada por uma réplica por vez
+	if a.config.Janitor.Enabled {
+		go janitor.New(repo, a.config.Janitor).Run(workerCtx)
+	}
+
+	// Limites de abuso dos endpoints públicos de magic link
+	abuseGuard := services.NewAbuseGuard(ratelimit.New(redisClient, "auth:rl:"), a.captchaVerifier())
 
 	// Inicializar handlers
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, abuseGuard)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+	passkeyHandler := handlers.NewPasskeyHandler(passkeyService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+	introspectionHandler := handlers.NewIntrospectionHandler(introspectionService)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+	phoneHandler := handlers.NewPhoneHandler(phoneService)
 
 	// Configurar rotas
-	router := a.setupRoutes(authHandler)
+	router, err := a.setupRoutes(keys, authHandler, oauthHandler, passkeyHandler, organizationHandler, introspectionHandler, privacyHandler, phoneHandler)
+	if err != nil {
+		return err
+	}
 
 	// Configurar servidor HTTP
 	a.server = &http.Server{
@@ -86,14 +183,71 @@ func (a *App) Run() error {
 	return nil
 }
 
-func (a *App) setupRoutes(authHandler *handlers.AuthHandler) *gin.Engine {
+// loadSigningKeys carrega as chaves do diretório configurado ou, fora de
+// produção, gera uma chave efêmera
+func (a *App) loadSigningKeys() (*signing.KeySet, error) {
+	if a.config.JWT.SigningKeysDir == "" {
+		log.Println("JWT_SIGNING_KEYS_DIR not set, using an ephemeral signing key")
+		return signing.NewEphemeral()
+	}
+
+	keys, err := signing.LoadDir(a.config.JWT.SigningKeysDir, a.config.JWT.ActiveKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// captchaVerifier retorna o provedor configurado ou, fora de produção, o
+// verificador fake. Em produção sem provedor apenas os limites rígidos valem.
+func (a *App) captchaVerifier() captcha.Verifier {
+	if a.config.Captcha.VerifyURL != "" {
+		return captcha.NewSiteVerify(a.config.Captcha.VerifyURL, a.config.Captcha.Secret)
+	}
+
+	if a.config.Server.Environment == "production" {
+		log.Println("CAPTCHA_VERIFY_URL not set, abuse challenges disabled")
+		return nil
+	}
+
+	log.Printf("CAPTCHA_VERIFY_URL not set, using fake verifier (token %q)", captcha.FakePassToken)
+	return captcha.Fake{}
+}
+
+// smsSender retorna o provedor configurado ou, fora de produção, o envio
+// fake que escreve as mensagens no log. Em produção sem provedor a
+// verificação de celular e o SMS como segundo fator ficam indisponíveis.
+func (a *App) smsSender() (sms.SMSSender, error) {
+	sender, err := sms.New(a.config.SMS)
+	if err != nil || sender != nil {
+		return sender, err
+	}
+
+	if a.config.Server.Environment == "production" {
+		log.Println("SMS_PROVIDER not set, sms verification disabled")
+		return nil, nil
+	}
+
+	log.Println("SMS_PROVIDER not set, writing sms messages to stdout")
+	return sms.NewFake(os.Stdout), nil
+}
+
+// newRouter cria o engine com os middlewares globais. O IP do cliente vem
+// do X-Forwarded-For apenas quando a conexão chega de um proxy confiável;
+// sem isso qualquer cliente escolheria o próprio IP e escaparia dos limites
+// por IP e sub-rede.
+func newRouter(server config.ServerConfig) (*gin.Engine, error) {
 	router := gin.Default()
+	if err := router.SetTrustedProxies(server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
 
 	// Middleware de CORS
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Device-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -103,11 +257,29 @@ func (a *App) setupRoutes(authHandler *handlers.AuthHandler) *gin.Engine {
 		c.Next()
 	})
 
+	// IP, user agent e dispositivo para o registro de sessões
+	router.Use(handlers.ClientInfoMiddleware())
+
+	return router, nil
+}
+
+func (a *App) setupRoutes(keys *signing.KeySet, authHandler *handlers.AuthHandler, oauthHandler *handlers.OAuthHandler, passkeyHandler *handlers.PasskeyHandler, organizationHandler *handlers.OrganizationHandler, introspectionHandler *handlers.IntrospectionHandler, privacyHandler *handlers.PrivacyHandler, phoneHandler *handlers.PhoneHandler) (*gin.Engine, error) {
+	router, err := newRouter(a.config.Server)
+	if err != nil {
+		return nil, err
+	}
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "service": "auth-svc"})
 	})
 
+	// Chaves públicas para validação dos access tokens
+	router.GET("/.well-known/jwks.json", handlers.JWKS(keys))
+
+	// Introspecção de tokens para serviços internos (RFC 7662)
+	router.POST("/oauth/introspect", introspectionHandler.Introspect)
+
 	// Métricas do Prometheus
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
@@ -117,20 +289,132 @@ func (a *App) setupRoutes(authHandler *handlers.AuthHandler) *gin.Engine {
 		// Rotas de autenticação
 		auth := api.Group("/auth")
 		{
+			auth.POST("/register", authHandler.Register)
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/password/reset", authHandler.RequestPasswordReset)
+			auth.POST("/password/reset/confirm", authHandler.ConfirmPasswordReset)
+			auth.POST("/email/confirm", authHandler.ConfirmEmailChange)
+			auth.POST("/email/cancel", authHandler.CancelEmailChange)
 			auth.POST("/magic-link", authHandler.SendMagicLink)
 			auth.POST("/verify", authHandler.VerifyMagicLink)
+			auth.POST("/mfa/verify", authHandler.VerifyMFA)
 			auth.POST("/refresh", authHandler.RefreshToken)
-			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/logout", authHandler.AuthMiddleware(), handlers.RequireSessionToken(), authHandler.Logout)
+			auth.POST("/logout/all", authHandler.AuthMiddleware(), handlers.RequireSessionToken(), authHandler.LogoutAll)
+
+			// Login social
+			auth.GET("/oauth/:provider/start", oauthHandler.Start)
+			auth.GET("/oauth/:provider/callback", oauthHandler.Callback)
+			auth.POST("/oauth/:provider/callback", oauthHandler.Callback)
+
+			// Passkeys (login principal e segundo fator)
+			auth.POST("/passkeys/login/begin", passkeyHandler.BeginLogin)
+			auth.POST("/passkeys/login/finish", passkeyHandler.FinishLogin)
+			auth.POST("/mfa/passkey/begin", passkeyHandler.BeginMFA)
+			auth.POST("/mfa/passkey/finish", passkeyHandler.FinishMFA)
+
+			// Código por SMS como segundo fator
+			auth.POST("/mfa/sms/send", phoneHandler.SendMFACode)
+			auth.POST("/mfa/sms/verify", phoneHandler.VerifyMFACode)
 		}
 
-		// Rotas protegidas
+		// Perfil do usuário autenticado; aceita também tokens de acesso pessoal
+		api.GET("/profile", authHandler.AuthMiddleware(), authHandler.GetProfile)
+
+		// Rotas protegidas (apenas sessões interativas)
 		protected := api.Group("/")
-		protected.Use(authHandler.AuthMiddleware())
+		protected.Use(authHandler.AuthMiddleware(), handlers.RequireSessionToken())
 		{
-			protected.GET("/profile", authHandler.GetProfile)
 			protected.PUT("/profile", authHandler.UpdateProfile)
+			protected.PUT("/password", authHandler.ChangePassword)
+			protected.POST("/email", authHandler.RequestEmailChange)
+
+			// Autenticação em dois fatores (TOTP)
+			protected.POST("/mfa/totp/enroll", authHandler.EnrollTOTP)
+			protected.POST("/mfa/totp/confirm", authHandler.ConfirmTOTP)
+			protected.POST("/mfa/totp/disable", authHandler.DisableTOTP)
+			protected.POST("/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+			// Celular verificado por SMS e SMS como segundo fator
+			protected.POST("/mobile", phoneHandler.StartVerification)
+			protected.POST("/mobile/verify", phoneHandler.ConfirmVerification)
+			protected.DELETE("/mobile", phoneHandler.RemoveMobile)
+			protected.POST("/mfa/sms/enable", phoneHandler.EnableSMSFactor)
+			protected.POST("/mfa/sms/disable", phoneHandler.DisableSMSFactor)
+
+			// Sessões e dispositivos
+			protected.GET("/sessions", authHandler.ListSessions)
+			protected.DELETE("/sessions/:id", authHandler.RevokeSession)
+			protected.GET("/devices", authHandler.ListDevices)
+			protected.PATCH("/devices/:id", authHandler.UpdateDevice)
+
+			// Identidades vinculadas
+			protected.GET("/identities", oauthHandler.ListIdentities)
+			protected.POST("/identities/:provider/link", oauthHandler.LinkIdentity)
+			protected.DELETE("/identities/:id", oauthHandler.UnlinkIdentity)
+
+			// Passkeys registradas
+			protected.GET("/passkeys", passkeyHandler.ListPasskeys)
+			protected.POST("/passkeys/register/begin", passkeyHandler.BeginRegistration)
+			protected.POST("/passkeys/register/finish", passkeyHandler.FinishRegistration)
+			protected.DELETE("/passkeys/:id", passkeyHandler.DeletePasskey)
+
+			// Organizações, membros e convites
+			protected.GET("/organizations", organizationHandler.ListOrganizations)
+			protected.POST("/organizations", organizationHandler.CreateOrganization)
+			protected.GET("/organizations/:id", organizationHandler.GetOrganization)
+			protected.PATCH("/organizations/:id", organizationHandler.UpdateOrganization)
+			protected.DELETE("/organizations/:id", organizationHandler.DeleteOrganization)
+			protected.GET("/organizations/:id/members", organizationHandler.ListMembers)
+			protected.PATCH("/organizations/:id/members/:user_id", organizationHandler.UpdateMember)
+			protected.DELETE("/organizations/:id/members/:user_id", organizationHandler.RemoveMember)
+			protected.GET("/organizations/:id/invitations", organizationHandler.ListInvitations)
+			protected.POST("/organizations/:id/invitations", organizationHandler.CreateInvitation)
+			protected.DELETE("/organizations/:id/invitations/:invitation_id", organizationHandler.RevokeInvitation)
+			protected.POST("/invitations/accept", organizationHandler.AcceptInvitation)
+			protected.PUT("/active-organization", organizationHandler.SwitchOrganization)
+
+			// Tokens de acesso pessoal
+			protected.GET("/tokens", authHandler.ListPersonalAccessTokens)
+			protected.POST("/tokens", authHandler.CreatePersonalAccessToken)
+			protected.DELETE("/tokens/:id", authHandler.RevokePersonalAccessToken)
+
+			// Histórico de segurança do usuário
+			protected.GET("/audit-logs", authHandler.ListAuditLogs)
+			protected.GET("/audit-logs/export", authHandler.ExportAuditLogs)
+
+			// Portabilidade e exclusão da conta
+			protected.POST("/account/export", privacyHandler.RequestExport)
+			protected.GET("/account/export/:id", privacyHandler.GetExport)
+			protected.GET("/account/export/:id/download", privacyHandler.DownloadExport)
+			protected.GET("/account/deletion", privacyHandler.GetDeletion)
+			protected.POST("/account/deletion", privacyHandler.ScheduleDeletion)
+			protected.DELETE("/account/deletion", privacyHandler.CancelDeletion)
+		}
+
+		// Administração de usuários e papéis
+		admin := api.Group("/admin")
+		admin.Use(authHandler.AuthMiddleware(), handlers.RequireSessionToken(), ginauthz.RequirePermission(authz.PermissionAdminUsers))
+		{
+			admin.GET("/users", authHandler.SearchUsers)
+			admin.GET("/users/:id", authHandler.GetUser)
+			admin.POST("/users/:id/suspend", authHandler.SuspendUser)
+			admin.POST("/users/:id/reactivate", authHandler.ReactivateUser)
+			admin.POST("/users/:id/logout", authHandler.ForceLogout)
+			admin.GET("/roles", authHandler.ListRoles)
+			admin.GET("/users/:id/roles", authHandler.ListUserRoles)
+			admin.POST("/users/:id/roles", authHandler.AssignRole)
+			admin.DELETE("/users/:id/roles/:role", authHandler.RemoveRole)
+		}
+
+		// Consulta e exportação do log de auditoria
+		adminAudit := api.Group("/admin/audit-logs")
+		adminAudit.Use(authHandler.AuthMiddleware(), handlers.RequireSessionToken(), ginauthz.RequirePermission(authz.PermissionAdminAudit))
+		{
+			adminAudit.GET("", authHandler.AdminListAuditLogs)
+			adminAudit.GET("/export", authHandler.AdminExportAuditLogs)
 		}
 	}
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/handlers"
+	"pagemagic/auth-svc/internal/ratelimit"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAbuseTestRouter expõe o endpoint de magic link sem AuthService: as
+// requisições usadas nos testes são barradas antes de chegar ao serviço
+func newAbuseTestRouter(t *testing.T, trustedProxies []string) (*gin.Engine, *services.AbuseGuard) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	guard := services.NewAbuseGuard(ratelimit.New(client, "test:"), nil)
+
+	router, err := newRouter(config.ServerConfig{TrustedProxies: trustedProxies})
+	require.NoError(t, err)
+	router.POST("/auth/magic-link", handlers.NewAuthHandler(nil, guard).SendMagicLink)
+
+	return router, guard
+}
+
+// exhaustIP esgota o limite por IP do magic link, um email por tentativa
+func exhaustIP(t *testing.T, guard *services.AbuseGuard, ip string) {
+	t.Helper()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, guard.Check(context.Background(), services.AbuseActionMagicLink,
+			services.AbuseSubject{Email: "user" + string(rune('a'+i)) + "@example.com", IP: ip}, ""))
+	}
+}
+
+func postMagicLink(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/auth/magic-link", strings.NewReader(`{"email":"new@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestRouterIgnoresSpoofedForwardedFor(t *testing.T) {
+	router, guard := newAbuseTestRouter(t, nil)
+	exhaustIP(t, guard, "203.0.113.7")
+
+	// Trocar o X-Forwarded-For não gera um novo contador por IP
+	assert.Equal(t, http.StatusTooManyRequests, postMagicLink(router, "203.0.113.7:4321", "198.51.100.1"))
+}
+
+func TestRouterTrustsConfiguredProxy(t *testing.T) {
+	router, guard := newAbuseTestRouter(t, []string{"10.0.0.0/8"})
+	exhaustIP(t, guard, "203.0.113.7")
+
+	// Atrás do proxy confiável o IP do cliente vem do cabeçalho
+	assert.Equal(t, http.StatusTooManyRequests, postMagicLink(router, "10.0.0.2:4321", "203.0.113.7"))
+}
+
+func TestNewRouterRejectsInvalidProxy(t *testing.T) {
+	_, err := newRouter(config.ServerConfig{TrustedProxies: []string{"not-a-cidr"}})
+	assert.Error(t, err)
+}
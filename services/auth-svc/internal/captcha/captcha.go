@@ -0,0 +1,84 @@
+// Package captcha define o desafio exigido quando os limites de abuso são
+// ultrapassados e suas implementações.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Verifier valida a resposta de um desafio CAPTCHA enviada pelo cliente
+type Verifier interface {
+	Verify(ctx context.Context, response, remoteIP string) (bool, error)
+}
+
+// SiteVerify implementação para provedores com API "siteverify" (Cloudflare
+// Turnstile, hCaptcha e reCAPTCHA compartilham o mesmo formato)
+type SiteVerify struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewSiteVerify cria um verificador para o endpoint e segredo do provedor
+func NewSiteVerify(verifyURL, secret string) *SiteVerify {
+	return &SiteVerify{
+		url:    verifyURL,
+		secret: secret,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (v *SiteVerify) Verify(ctx context.Context, response, remoteIP string) (bool, error) {
+	if response == "" {
+		return false, nil
+	}
+
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {response},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("failed to create captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify captcha: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to verify captcha: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode captcha response: %w", err)
+	}
+
+	return result.Success, nil
+}
+
+// FakePassToken resposta aceita pelo Fake
+const FakePassToken = "captcha-pass"
+
+// Fake verificador para desenvolvimento e testes: aceita apenas FakePassToken
+type Fake struct{}
+
+func (Fake) Verify(ctx context.Context, response, remoteIP string) (bool, error) {
+	return response == FakePassToken, nil
+}
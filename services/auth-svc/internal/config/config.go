@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,8 +17,15 @@ type Config struct {
 	Redis    RedisConfig
 	NATS     NATSConfig
 	Email    EmailConfig
+	SMS      SMSConfig
 	OAuth    OAuthConfig
-	Logging  LoggingConfig
+	WebAuthn WebAuthnConfig
+	// Introspection credenciais dos serviços internos que consultam tokens
+	Introspection IntrospectionConfig
+	Captcha       CaptchaConfig
+	Privacy       PrivacyConfig
+	Janitor       JanitorConfig
+	Logging       LoggingConfig
 }
 
 // ServerConfig configurações do servidor HTTP
@@ -27,6 +36,10 @@ type ServerConfig struct {
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
 	Environment  string
+	AppURL       string
+	// TrustedProxies proxies (IPs ou CIDRs) cujo X-Forwarded-For é aceito
+	// como IP do cliente; vazio usa o endereço da conexão
+	TrustedProxies []string
 }
 
 // DatabaseConfig configurações do banco de dados
@@ -39,14 +52,24 @@ type DatabaseConfig struct {
 	SSLMode  string
 	MaxConns int
 	MinConns int
+	// MigrateOnBoot aplica as migrações pendentes antes de iniciar o servidor
+	MigrateOnBoot bool
 }
 
 // JWTConfig configurações JWT
 type JWTConfig struct {
+	// Secret assina apenas tokens internos (desafio de MFA); access tokens
+	// usam as chaves assimétricas de SigningKeysDir
 	Secret             string
 	AccessTokenTTL     time.Duration
 	RefreshTokenTTL    time.Duration
 	RefreshTokenSecret string
+	// SigningKeysDir diretório com as chaves PEM (<kid>.pem); vazio gera uma
+	// chave efêmera, aceito apenas fora de produção
+	SigningKeysDir string
+	// ActiveKeyID kid da chave que assina novos tokens; opcional se houver
+	// uma única chave
+	ActiveKeyID string
 }
 
 // RedisConfig configurações Redis
@@ -76,6 +99,17 @@ type EmailConfig struct {
 	SMTPPort    string
 	SMTPUser    string
 	SMTPPass    string
+	OutboxDir   string
+}
+
+// SMSConfig configurações de envio de SMS (verificação de celular e 2FA)
+type SMSConfig struct {
+	// Provider twilio, fake ou vazio (SMS desativado)
+	Provider                  string
+	From                      string
+	TwilioAccountSID          string
+	TwilioAuthToken           string
+	TwilioMessagingServiceSID string
 }
 
 // OAuthConfig configurações OAuth
@@ -85,16 +119,25 @@ type OAuthConfig struct {
 	Apple  AppleOAuthConfig
 }
 
+// Os endpoints dos provedores são configuráveis para permitir apontar para
+// um IdP local em desenvolvimento e testes.
 type GoogleOAuthConfig struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
 }
 
 type GitHubOAuthConfig struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	EmailsURL    string
 }
 
 type AppleOAuthConfig struct {
@@ -104,6 +147,53 @@ type AppleOAuthConfig struct {
 	TeamID       string
 	KeyID        string
 	PrivateKey   string
+	AuthURL      string
+	TokenURL     string
+	Issuer       string
+}
+
+// WebAuthnConfig configurações do relying party para passkeys
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+}
+
+// IntrospectionConfig configurações do endpoint /oauth/introspect
+type IntrospectionConfig struct {
+	// Clients client_id -> client_secret dos serviços autorizados
+	Clients  map[string]string
+	CacheTTL time.Duration
+}
+
+// CaptchaConfig provedor de CAPTCHA exigido quando os limites de abuso são
+// atingidos (API siteverify compatível com reCAPTCHA, hCaptcha e Turnstile)
+type CaptchaConfig struct {
+	VerifyURL string
+	Secret    string
+}
+
+// PrivacyConfig prazos da exportação e da exclusão de dados (LGPD/GDPR)
+type PrivacyConfig struct {
+	// DeletionGracePeriod carência entre o pedido e a exclusão da conta
+	DeletionGracePeriod time.Duration
+	// ExportTTL tempo em que o arquivo exportado fica disponível
+	ExportTTL time.Duration
+}
+
+// JanitorConfig intervalos da limpeza periódica de registros expirados.
+// Apenas a réplica que obtiver o advisory lock executa os jobs.
+type JanitorConfig struct {
+	Enabled bool
+	// TokenInterval magic links, códigos SMS, resets de senha e trocas de email
+	TokenInterval time.Duration
+	// SessionInterval sessões e refresh tokens
+	SessionInterval time.Duration
+	// ExportInterval arquivos de exportação de dados vencidos
+	ExportInterval time.Duration
+	// OutboxInterval eventos já publicados há mais de OutboxRetention
+	OutboxInterval  time.Duration
+	OutboxRetention time.Duration
 }
 
 // LoggingConfig configurações de logging
@@ -114,14 +204,27 @@ type LoggingConfig struct {
 
 // Load carrega as configurações das variáveis de ambiente
 func Load() (*Config, error) {
+	// Durações inválidas são acumuladas e recusadas ao final: um valor zero
+	// silencioso (ex.: "7d") desativaria TTLs e timeouts
+	var errs []error
+	duration := func(key, defaultValue string) time.Duration {
+		d, err := parseDuration(getEnv(key, defaultValue))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+		return d
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:         getEnv("PORT", "8080"),
-			Host:         getEnv("HOST", "0.0.0.0"),
-			ReadTimeout:  parseDuration(getEnv("SERVER_READ_TIMEOUT", "10s")),
-			WriteTimeout: parseDuration(getEnv("SERVER_WRITE_TIMEOUT", "10s")),
-			IdleTimeout:  parseDuration(getEnv("SERVER_IDLE_TIMEOUT", "60s")),
-			Environment:  getEnv("NODE_ENV", "development"),
+			Port:           getEnv("PORT", "8080"),
+			Host:           getEnv("HOST", "0.0.0.0"),
+			ReadTimeout:    duration("SERVER_READ_TIMEOUT", "10s"),
+			WriteTimeout:   duration("SERVER_WRITE_TIMEOUT", "10s"),
+			IdleTimeout:    duration("SERVER_IDLE_TIMEOUT", "60s"),
+			Environment:    getEnv("NODE_ENV", "development"),
+			AppURL:         getEnv("APP_URL", "http://localhost:3000"),
+			TrustedProxies: parseList(getEnv("TRUSTED_PROXIES", "")),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("POSTGRES_HOST", "localhost"),
@@ -132,28 +235,32 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("POSTGRES_SSL_MODE", "disable"),
 			MaxConns: parseInt(getEnv("POSTGRES_MAX_CONNS", "25")),
 			MinConns: parseInt(getEnv("POSTGRES_MIN_CONNS", "5")),
+
+			MigrateOnBoot: parseBool(getEnv("POSTGRES_MIGRATE_ON_BOOT", "false")),
 		},
 		JWT: JWTConfig{
 			Secret:             getEnv("JWT_SECRET", "your-secret-key"),
-			AccessTokenTTL:     parseDuration(getEnv("JWT_ACCESS_TTL", "15m")),
-			RefreshTokenTTL:    parseDuration(getEnv("JWT_REFRESH_TTL", "7d")),
+			AccessTokenTTL:     duration("JWT_ACCESS_TTL", "15m"),
+			RefreshTokenTTL:    duration("JWT_REFRESH_TTL", "168h"),
 			RefreshTokenSecret: getEnv("REFRESH_TOKEN_SECRET", "your-refresh-secret"),
+			SigningKeysDir:     getEnv("JWT_SIGNING_KEYS_DIR", ""),
+			ActiveKeyID:        getEnv("JWT_ACTIVE_KID", ""),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
 			Port:     getEnv("REDIS_PORT", "6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       parseInt(getEnv("REDIS_DB", "0")),
-			TTL:      parseDuration(getEnv("REDIS_TTL", "24h")),
+			TTL:      duration("REDIS_TTL", "24h"),
 		},
 		NATS: NATSConfig{
 			URL:      getEnv("NATS_URL", "nats://localhost:4222"),
 			User:     getEnv("NATS_USER", ""),
 			Password: getEnv("NATS_PASSWORD", ""),
-			Timeout:  parseDuration(getEnv("NATS_TIMEOUT", "30s")),
+			Timeout:  duration("NATS_TIMEOUT", "30s"),
 		},
 		Email: EmailConfig{
-			Provider:    getEnv("EMAIL_PROVIDER", "sendgrid"),
+			Provider:    getEnv("EMAIL_PROVIDER", ""),
 			SendGridKey: getEnv("SENDGRID_API_KEY", ""),
 			FromEmail:   getEnv("FROM_EMAIL", "noreply@pagemagic.io"),
 			FromName:    getEnv("FROM_NAME", "Page Magic"),
@@ -161,17 +268,32 @@ func Load() (*Config, error) {
 			SMTPPort:    getEnv("SMTP_PORT", "587"),
 			SMTPUser:    getEnv("SMTP_USER", ""),
 			SMTPPass:    getEnv("SMTP_PASSWORD", ""),
+			OutboxDir:   getEnv("EMAIL_OUTBOX_DIR", ""),
+		},
+		SMS: SMSConfig{
+			Provider:                  getEnv("SMS_PROVIDER", ""),
+			From:                      getEnv("SMS_FROM", ""),
+			TwilioAccountSID:          getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:           getEnv("TWILIO_AUTH_TOKEN", ""),
+			TwilioMessagingServiceSID: getEnv("TWILIO_MESSAGING_SERVICE_SID", ""),
 		},
 		OAuth: OAuthConfig{
 			Google: GoogleOAuthConfig{
 				ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
 				ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
 				RedirectURL:  getEnv("GOOGLE_REDIRECT_URL", ""),
+				AuthURL:      getEnv("GOOGLE_AUTH_URL", "https://accounts.google.com/o/oauth2/v2/auth"),
+				TokenURL:     getEnv("GOOGLE_TOKEN_URL", "https://oauth2.googleapis.com/token"),
+				UserInfoURL:  getEnv("GOOGLE_USERINFO_URL", "https://openidconnect.googleapis.com/v1/userinfo"),
 			},
 			GitHub: GitHubOAuthConfig{
 				ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
 				ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
 				RedirectURL:  getEnv("GITHUB_REDIRECT_URL", ""),
+				AuthURL:      getEnv("GITHUB_AUTH_URL", "https://github.com/login/oauth/authorize"),
+				TokenURL:     getEnv("GITHUB_TOKEN_URL", "https://github.com/login/oauth/access_token"),
+				UserInfoURL:  getEnv("GITHUB_USERINFO_URL", "https://api.github.com/user"),
+				EmailsURL:    getEnv("GITHUB_EMAILS_URL", "https://api.github.com/user/emails"),
 			},
 			Apple: AppleOAuthConfig{
 				ClientID:     getEnv("APPLE_CLIENT_ID", ""),
@@ -180,14 +302,59 @@ func Load() (*Config, error) {
 				TeamID:       getEnv("APPLE_TEAM_ID", ""),
 				KeyID:        getEnv("APPLE_KEY_ID", ""),
 				PrivateKey:   getEnv("APPLE_PRIVATE_KEY", ""),
+				AuthURL:      getEnv("APPLE_AUTH_URL", "https://appleid.apple.com/auth/authorize"),
+				TokenURL:     getEnv("APPLE_TOKEN_URL", "https://appleid.apple.com/auth/token"),
+				Issuer:       getEnv("APPLE_ISSUER", "https://appleid.apple.com"),
 			},
 		},
+		WebAuthn: WebAuthnConfig{
+			RPID:          getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: getEnv("WEBAUTHN_RP_NAME", "Page Magic"),
+			RPOrigins:     parseList(getEnv("WEBAUTHN_RP_ORIGINS", getEnv("APP_URL", "http://localhost:3000"))),
+		},
+		Introspection: IntrospectionConfig{
+			Clients:  parseCredentials(getEnv("INTROSPECTION_CLIENTS", "")),
+			CacheTTL: duration("INTROSPECTION_CACHE_TTL", "30s"),
+		},
+		Captcha: CaptchaConfig{
+			VerifyURL: getEnv("CAPTCHA_VERIFY_URL", ""),
+			Secret:    getEnv("CAPTCHA_SECRET", ""),
+		},
+		Privacy: PrivacyConfig{
+			DeletionGracePeriod: duration("ACCOUNT_DELETION_GRACE_PERIOD", "720h"),
+			ExportTTL:           duration("DATA_EXPORT_TTL", "168h"),
+		},
+		Janitor: JanitorConfig{
+			Enabled:         parseBool(getEnv("JANITOR_ENABLED", "true")),
+			TokenInterval:   duration("JANITOR_TOKEN_INTERVAL", "5m"),
+			SessionInterval: duration("JANITOR_SESSION_INTERVAL", "1h"),
+			ExportInterval:  duration("JANITOR_EXPORT_INTERVAL", "1h"),
+			OutboxInterval:  duration("JANITOR_OUTBOX_INTERVAL", "1h"),
+			OutboxRetention: duration("JANITOR_OUTBOX_RETENTION", "168h"),
+		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
 	}
 
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid duration: %w", err)
+	}
+
+	// Fora de produção os emails vão para EMAIL_OUTBOX_DIR ou para o stdout,
+	// para que o serviço suba sem credenciais de um provedor real
+	if cfg.Email.Provider == "" {
+		switch {
+		case cfg.IsProduction():
+			cfg.Email.Provider = "sendgrid"
+		case cfg.Email.OutboxDir != "":
+			cfg.Email.Provider = "file"
+		default:
+			cfg.Email.Provider = "stdout"
+		}
+	}
+
 	// Validar configurações críticas
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -202,6 +369,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("JWT_SECRET must be set in production")
 	}
 
+	if c.JWT.SigningKeysDir == "" && c.Server.Environment == "production" {
+		return fmt.Errorf("JWT_SIGNING_KEYS_DIR must be set in production")
+	}
+
 	if c.Database.Password == "password" && c.Server.Environment == "production" {
 		return fmt.Errorf("POSTGRES_PASSWORD must be set in production")
 	}
@@ -254,11 +425,47 @@ func parseInt(s string) int {
 	return i
 }
 
-// parseDuration converte string para time.Duration
-func parseDuration(s string) time.Duration {
+// parseBool converte string para bool; valores inválidos são false
+func parseBool(s string) bool {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false
+	}
+	return b
+}
+
+// parseDuration converte string para time.Duration; aceita apenas as
+// unidades de time.ParseDuration (h, m, s...), não dias
+func parseDuration(s string) (time.Duration, error) {
 	d, err := time.ParseDuration(s)
 	if err != nil {
-		return 0
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %q", s)
+	}
+	return d, nil
+}
+
+// parseList converte uma lista separada por vírgulas, ignorando itens vazios
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseCredentials converte uma lista "id:segredo,id2:segredo2"
+func parseCredentials(s string) map[string]string {
+	credentials := make(map[string]string)
+	for _, item := range parseList(s) {
+		id, secret, ok := strings.Cut(item, ":")
+		if ok && id != "" && secret != "" {
+			credentials[id] = secret
+		}
 	}
-	return d
+	return credentials
 }
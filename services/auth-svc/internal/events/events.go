@@ -0,0 +1,110 @@
+// Package events define os eventos de ciclo de vida do usuário publicados
+// no NATS (JetStream) e o relay que os entrega a partir da outbox. O
+// formato segue shared/schemas/user-events.v1.json; mudanças incompatíveis
+// exigem um novo SchemaVersion.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion versão do envelope e dos payloads publicados
+const SchemaVersion = 1
+
+// Source serviço de origem informado no envelope
+const Source = "auth-svc"
+
+// Tipos de evento; também usados como subject no NATS
+const (
+	UserCreated  = "user.created"
+	UserVerified = "user.verified"
+	UserUpdated  = "user.updated"
+	UserDeleted  = "user.deleted"
+	UserLogin    = "user.login"
+)
+
+// Envelope formato comum a todos os eventos
+type Envelope struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       string      `json:"type"`
+	Version    int         `json:"version"`
+	Source     string      `json:"source"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// UserData estado do usuário em user.created, user.verified e user.updated
+type UserData struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	FirstName     *string   `json:"first_name"`
+	LastName      *string   `json:"last_name"`
+	Locale        string    `json:"locale"`
+	Timezone      string    `json:"timezone"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UserDeletedData payload de user.deleted; sem dados pessoais
+type UserDeletedData struct {
+	UserID    uuid.UUID `json:"user_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// UserLoginData payload de user.login
+type UserLoginData struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	SessionID uuid.UUID  `json:"session_id"`
+	DeviceID  *uuid.UUID `json:"device_id,omitempty"`
+}
+
+// NewUserData extrai do usuário os campos publicados
+func NewUserData(user *models.User) UserData {
+	return UserData{
+		UserID:        user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Locale:        user.Locale,
+		Timezone:      user.Timezone,
+		Status:        string(user.Status),
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// NewOutboxEvent monta o envelope do evento para gravação na outbox. O ID
+// do envelope é também o ID da linha e o Nats-Msg-Id usado na deduplicação.
+func NewOutboxEvent(eventType string, data interface{}) (*models.OutboxEvent, error) {
+	now := time.Now().UTC()
+	envelope := Envelope{
+		ID:         uuid.New(),
+		Type:       eventType,
+		Version:    SchemaVersion,
+		Source:     Source,
+		OccurredAt: now,
+		Data:       data,
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	return &models.OutboxEvent{
+		ID:            envelope.ID,
+		Subject:       eventType,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
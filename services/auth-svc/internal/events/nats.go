@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+
+	"github.com/nats-io/nats.go"
+)
+
+// StreamName stream do JetStream que armazena os eventos de usuário
+const StreamName = "USER_EVENTS"
+
+// Publisher entrega um evento ao broker; msgID permite deduplicar reenvios
+type Publisher interface {
+	Publish(ctx context.Context, subject, msgID string, payload []byte) error
+}
+
+// NATSPublisher publica no JetStream e aguarda a confirmação de
+// armazenamento. A conexão se refaz sozinha; o stream é criado na primeira
+// publicação bem-sucedida caso ainda não exista.
+type NATSPublisher struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+
+	mu          sync.Mutex
+	streamReady bool
+}
+
+// NewNATSPublisher conecta ao NATS. Uma falha inicial de conexão não impede
+// a inicialização: os eventos ficam na outbox até o broker voltar.
+func NewNATSPublisher(cfg config.NATSConfig) (*NATSPublisher, error) {
+	options := []nats.Option{
+		nats.Name(Source),
+		nats.Timeout(cfg.Timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+	if cfg.User != "" {
+		options = append(options, nats.UserInfo(cfg.User, cfg.Password))
+	}
+
+	conn, err := nats.Connect(cfg.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open jetstream context: %w", err)
+	}
+
+	return &NATSPublisher{conn: conn, js: js}, nil
+}
+
+// Publish publica o evento e aguarda o ack do JetStream
+func (p *NATSPublisher) Publish(ctx context.Context, subject, msgID string, payload []byte) error {
+	if err := p.ensureStream(); err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+	msg.Header.Set("Content-Type", "application/json")
+
+	if _, err := p.js.PublishMsg(msg, nats.MsgId(msgID), nats.Context(ctx)); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", subject, err)
+	}
+
+	return nil
+}
+
+// Close drena e encerra a conexão
+func (p *NATSPublisher) Close() {
+	if err := p.conn.Drain(); err != nil {
+		p.conn.Close()
+	}
+}
+
+// ensureStream cria o stream USER_EVENTS (subjects user.>) se necessário
+func (p *NATSPublisher) ensureStream() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.streamReady {
+		return nil
+	}
+
+	_, err := p.js.StreamInfo(StreamName)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = p.js.AddStream(&nats.StreamConfig{
+			Name:       StreamName,
+			Subjects:   []string{"user.>"},
+			Storage:    nats.FileStorage,
+			MaxAge:     7 * 24 * time.Hour,
+			Duplicates: 10 * time.Minute,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ensure stream %s: %w", StreamName, err)
+	}
+
+	p.streamReady = true
+	return nil
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pagemagic/auth-svc/internal/repository"
+)
+
+const (
+	relayInterval   = time.Second
+	relayBatchSize  = 100
+	relayMaxBackoff = 5 * time.Minute
+)
+
+// Relay publica os eventos pendentes da outbox. Cada lote é bloqueado numa
+// transação (FOR UPDATE SKIP LOCKED), então várias instâncias do serviço
+// podem rodar o relay ao mesmo tempo sem publicar o mesmo evento duas vezes;
+// reenvios após falhas são deduplicados pelo JetStream via Nats-Msg-Id.
+type Relay struct {
+	repo      *repository.Repository
+	publisher Publisher
+}
+
+// NewRelay cria o relay da outbox
+func NewRelay(repo *repository.Repository, publisher Publisher) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+// Run publica eventos até o contexto ser cancelado
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		// Esvaziar a fila antes de esperar o próximo ciclo
+		for {
+			published, err := r.relayBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Outbox relay failed: %v", err)
+				}
+				break
+			}
+			if published < relayBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publica um lote e retorna quantos eventos foram publicados
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	published := 0
+
+	err := r.repo.InTx(ctx, func(tx *repository.Repository) error {
+		pending, err := tx.Outbox.ClaimPending(ctx, relayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range pending {
+			if err := r.publisher.Publish(ctx, event.Subject, event.ID.String(), event.Payload); err != nil {
+				log.Printf("Failed to publish outbox event %s (%s): %v", event.ID, event.Subject, err)
+				if err := tx.Outbox.MarkFailed(ctx, event.ID, err.Error(), time.Now().Add(relayBackoff(event.Attempts))); err != nil {
+					return err
+				}
+				// Broker indisponível: não insistir com o restante do lote
+				break
+			}
+
+			if err := tx.Outbox.MarkPublished(ctx, event.ID); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+
+	return published, err
+}
+
+// relayBackoff espera exponencial entre tentativas: 1s, 2s, 4s... até 5min
+func relayBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 0; i < attempts && backoff < relayMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > relayMaxBackoff {
+		backoff = relayMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserSearchQuery filtros da busca de usuários
+type UserSearchQuery struct {
+	Email  string `form:"email"`
+	Name   string `form:"name"`
+	Status string `form:"status" binding:"omitempty,oneof=active inactive suspended deleted"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AdminUserResponse usuário visto pela administração, com dados que não
+// aparecem no perfil
+type AdminUserResponse struct {
+	UserResponse
+	LastLoginAt string `json:"last_login_at,omitempty"`
+	LockedUntil string `json:"locked_until,omitempty"`
+}
+
+// newAdminUserResponse converte o usuário para a resposta da administração
+func newAdminUserResponse(user *models.User) AdminUserResponse {
+	response := AdminUserResponse{UserResponse: newUserResponse(user)}
+	if user.LastLoginAt != nil {
+		response.LastLoginAt = user.LastLoginAt.UTC().Format(time.RFC3339)
+	}
+	if user.IsLocked() {
+		response.LockedUntil = user.LockedUntil.UTC().Format(time.RFC3339)
+	}
+	return response
+}
+
+// respondAdminUserError mapeia os erros da administração de usuários
+func respondAdminUserError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrCannotSuspendSelf):
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot suspend your own account"})
+	case errors.Is(err, services.ErrUserAlreadySuspended):
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already suspended"})
+	case errors.Is(err, services.ErrUserNotSuspended):
+		c.JSON(http.StatusConflict, gin.H{"error": "User is not suspended"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *AuthHandler) SearchUsers(c *gin.Context) {
+	var query UserSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.authService.SearchUsers(c.Request.Context(), models.UserFilter{
+		Email:  query.Email,
+		Name:   query.Name,
+		Status: models.UserStatus(query.Status),
+		Limit:  query.Limit,
+	}, query.Cursor)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+
+	response := make([]AdminUserResponse, 0, len(page.Users))
+	for _, user := range page.Users {
+		response = append(response, newAdminUserResponse(user))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       response,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+func (h *AuthHandler) GetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	details, err := h.authService.GetUserDetails(c.Request.Context(), userID)
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to get user")
+		return
+	}
+
+	providers := make([]IdentityResponse, 0, len(details.Providers))
+	for _, provider := range details.Providers {
+		providers = append(providers, newIdentityResponse(provider))
+	}
+
+	sessions := make([]SessionResponse, 0, len(details.Sessions))
+	for _, session := range details.Sessions {
+		sessions = append(sessions, newSessionResponse(session, uuid.Nil))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":       newAdminUserResponse(details.User),
+		"identities": providers,
+		"sessions":   sessions,
+	})
+}
+
+func (h *AuthHandler) SuspendUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req SuspendUserRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	user, err := h.authService.SuspendUser(c.Request.Context(), claims.UserID, userID, req.Reason)
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to suspend user")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (h *AuthHandler) ReactivateUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.authService.ReactivateUser(c.Request.Context(), userID)
+	if err != nil {
+		respondAdminUserError(c, err, "Failed to reactivate user")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (h *AuthHandler) ForceLogout(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.authService.ForceLogout(c.Request.Context(), userID); err != nil {
+		respondAdminUserError(c, err, "Failed to log out user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AuditLogQuery filtros aceitos pela listagem e exportação do log de
+// auditoria. UserID e ActorID só são considerados nas rotas de admin.
+type AuditLogQuery struct {
+	Action    string     `form:"action"`
+	Result    string     `form:"result" binding:"omitempty,oneof=success failure"`
+	IPAddress string     `form:"ip"`
+	UserID    string     `form:"user_id"`
+	ActorID   string     `form:"actor_id"`
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor    string     `form:"cursor"`
+	Limit     int        `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+type AuditLogResponse struct {
+	ID        string                 `json:"id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	ActorID   string                 `json:"actor_id,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"`
+	Action    string                 `json:"action"`
+	Result    string                 `json:"result"`
+	IPAddress string                 `json:"ip_address,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// newAuditLogResponse converte a entrada do log para a resposta da API
+func newAuditLogResponse(entry *models.AuditLog) AuditLogResponse {
+	response := AuditLogResponse{
+		ID:        entry.ID.String(),
+		Action:    string(entry.Action),
+		Result:    string(entry.Result),
+		IPAddress: stringValue(entry.IPAddress),
+		UserAgent: stringValue(entry.UserAgent),
+		Metadata:  entry.Metadata,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if entry.UserID != nil {
+		response.UserID = entry.UserID.String()
+	}
+	if entry.ActorID != nil {
+		response.ActorID = entry.ActorID.String()
+	}
+	if entry.SessionID != nil {
+		response.SessionID = entry.SessionID.String()
+	}
+	return response
+}
+
+// auditLogFilter converte a query em filtro; admin habilita a busca por
+// qualquer usuário ou ator
+func auditLogFilter(c *gin.Context, admin bool) (*models.AuditLogFilter, string, bool) {
+	var query AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+
+	filter := &models.AuditLogFilter{
+		Action:    models.AuditAction(query.Action),
+		Result:    models.AuditResult(query.Result),
+		IPAddress: query.IPAddress,
+		Since:     query.Since,
+		Until:     query.Until,
+		Limit:     query.Limit,
+	}
+
+	if !admin {
+		claims := c.MustGet("claims").(*models.JWTClaims)
+		filter.UserID = &claims.UserID
+		return filter, query.Cursor, true
+	}
+
+	if query.UserID != "" {
+		userID, err := uuid.Parse(query.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return nil, "", false
+		}
+		filter.UserID = &userID
+	}
+	if query.ActorID != "" {
+		actorID, err := uuid.Parse(query.ActorID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID"})
+			return nil, "", false
+		}
+		filter.ActorID = &actorID
+	}
+
+	return filter, query.Cursor, true
+}
+
+func (h *AuthHandler) ListAuditLogs(c *gin.Context) {
+	h.listAuditLogs(c, false)
+}
+
+func (h *AuthHandler) ExportAuditLogs(c *gin.Context) {
+	h.exportAuditLogs(c, false)
+}
+
+func (h *AuthHandler) AdminListAuditLogs(c *gin.Context) {
+	h.listAuditLogs(c, true)
+}
+
+func (h *AuthHandler) AdminExportAuditLogs(c *gin.Context) {
+	h.exportAuditLogs(c, true)
+}
+
+func (h *AuthHandler) listAuditLogs(c *gin.Context, admin bool) {
+	filter, cursor, ok := auditLogFilter(c, admin)
+	if !ok {
+		return
+	}
+
+	page, err := h.authService.ListAuditLogs(c.Request.Context(), *filter, cursor)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit logs"})
+		return
+	}
+
+	response := make([]AuditLogResponse, 0, len(page.Entries))
+	for _, entry := range page.Entries {
+		response = append(response, newAuditLogResponse(entry))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":     response,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// exportAuditLogs envia todas as entradas do filtro em NDJSON (um objeto
+// JSON por linha), em streaming
+func (h *AuthHandler) exportAuditLogs(c *gin.Context, admin bool) {
+	filter, _, ok := auditLogFilter(c, admin)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.ndjson", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	written := 0
+	err := h.authService.ExportAuditLogs(c.Request.Context(), *filter, func(entry *models.AuditLog) error {
+		if err := encoder.Encode(newAuditLogResponse(entry)); err != nil {
+			return err
+		}
+		written++
+		if written%500 == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// O status já foi enviado; o cliente percebe a interrupção pelo
+		// fim prematuro do corpo
+		log.Printf("Failed to export audit logs: %v", err)
+		return
+	}
+
+	c.Writer.Flush()
+}
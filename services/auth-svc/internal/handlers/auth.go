@@ -1,59 +1,155 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/password"
 	"pagemagic/auth-svc/internal/services"
+	"pagemagic/auth-svc/pkg/authz"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
 	authService *services.AuthService
+	abuseGuard  *services.AbuseGuard
 }
 
 type SendMagicLinkRequest struct {
-	Email string `json:"email" binding:"required,email"`
+	Email        string `json:"email" binding:"required,email"`
+	Locale       string `json:"locale"`
+	CaptchaToken string `json:"captcha_token"`
 }
 
+// VerifyMagicLinkRequest aceita o token do link ou, no app móvel, o código
+// do email com o nonce recebido ao pedir o link
 type VerifyMagicLinkRequest struct {
-	Token string `json:"token" binding:"required"`
+	Token        string `json:"token" binding:"required_without_all=Code DeviceNonce"`
+	Code         string `json:"code" binding:"required_with=DeviceNonce"`
+	DeviceNonce  string `json:"device_nonce" binding:"required_with=Code"`
+	CaptchaToken string `json:"captcha_token"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+type RegisterRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Password     string `json:"password" binding:"required"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Locale       string `json:"locale"`
+	Timezone     string `json:"timezone"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+type LoginRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Password     string `json:"password" binding:"required"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+// ChangePasswordRequest CurrentPassword é dispensado apenas para definir a
+// primeira senha de uma conta sem senha, logo após o login
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Locale       string `json:"locale"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
 type UpdateProfileRequest struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	AvatarURL string `json:"avatar_url"`
+	FirstName                *string `json:"first_name" binding:"omitempty,max=100"`
+	LastName                 *string `json:"last_name" binding:"omitempty,max=100"`
+	AvatarURL                *string `json:"avatar_url" binding:"omitempty,max=2048"`
+	Locale                   *string `json:"locale" binding:"omitempty,max=10"`
+	Timezone                 *string `json:"timezone" binding:"omitempty,max=64"`
+	PushNotificationsEnabled *bool   `json:"push_notifications_enabled"`
 }
 
 type AuthResponse struct {
-	User         UserResponse `json:"user"`
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
+	User         *UserResponse `json:"user,omitempty"`
+	AccessToken  string        `json:"access_token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresIn    int64         `json:"expires_in"`
+	MFARequired  bool          `json:"mfa_required,omitempty"`
+	MFAToken     string        `json:"mfa_token,omitempty"`
+	MFAMethods   []string      `json:"mfa_methods,omitempty"`
 }
 
 type UserResponse struct {
-	ID        string `json:"id"`
-	Email     string `json:"email"`
-	FirstName string `json:"first_name,omitempty"`
-	LastName  string `json:"last_name,omitempty"`
-	AvatarURL string `json:"avatar_url,omitempty"`
-	Status    string `json:"status"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID               string `json:"id"`
+	Email            string `json:"email"`
+	EmailVerified    bool   `json:"email_verified"`
+	FirstName        string `json:"first_name,omitempty"`
+	LastName         string `json:"last_name,omitempty"`
+	AvatarURL        string `json:"avatar_url,omitempty"`
+	MobileNumber     string `json:"mobile_number,omitempty"`
+	MobileVerified   bool   `json:"mobile_verified"`
+	Status           string `json:"status"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	SMSMFAEnabled    bool   `json:"sms_mfa_enabled"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, abuseGuard *services.AbuseGuard) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
+		abuseGuard:  abuseGuard,
 	}
 }
 
+// checkAbuse aplica os limites de abuso à requisição e responde com 429 ou
+// com o desafio CAPTCHA quando ela não pode prosseguir. As respostas não
+// dependem da existência do email.
+func (h *AuthHandler) checkAbuse(c *gin.Context, action services.AbuseAction, email, captchaToken string) bool {
+	err := h.abuseGuard.Check(c.Request.Context(), action, services.AbuseSubject{
+		Email: email,
+		IP:    c.ClientIP(),
+	}, captchaToken)
+	if err == nil {
+		return true
+	}
+
+	var limited *services.RateLimitError
+	switch {
+	case errors.As(err, &limited):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again later"})
+	case errors.Is(err, services.ErrChallengeRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Challenge required", "challenge": "captcha"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+
+	return false
+}
+
+// optionalString retorna nil para strings vazias
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 // stringValue retorna o valor de um ponteiro para string ou uma string vazia
 func stringValue(s *string) string {
 	if s == nil {
@@ -62,6 +158,46 @@ func stringValue(s *string) string {
 	return *s
 }
 
+// newUserResponse converte o modelo de usuário para a resposta da API
+func newUserResponse(user *models.User) UserResponse {
+	return UserResponse{
+		ID:               user.ID.String(),
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified,
+		FirstName:        stringValue(user.FirstName),
+		LastName:         stringValue(user.LastName),
+		AvatarURL:        stringValue(user.AvatarURL),
+		MobileNumber:     stringValue(user.MobileNumber),
+		MobileVerified:   user.MobileVerified,
+		Status:           string(user.Status),
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		SMSMFAEnabled:    user.SMSMFAEnabled,
+		CreatedAt:        user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// newAuthResponse converte o resultado da autenticação para a resposta da API.
+// Quando o segundo fator é exigido, apenas o token mfa_pending é devolvido.
+func newAuthResponse(auth *models.AuthResponse) AuthResponse {
+	if auth.MFAToken != "" {
+		return AuthResponse{
+			ExpiresIn:   auth.ExpiresIn,
+			MFARequired: true,
+			MFAToken:    auth.MFAToken,
+			MFAMethods:  auth.MFAMethods,
+		}
+	}
+
+	user := newUserResponse(auth.User)
+	return AuthResponse{
+		User:         &user,
+		AccessToken:  auth.AccessToken,
+		RefreshToken: auth.RefreshToken,
+		ExpiresIn:    auth.ExpiresIn,
+	}
+}
+
 func (h *AuthHandler) SendMagicLink(c *gin.Context) {
 	var req SendMagicLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -69,15 +205,20 @@ func (h *AuthHandler) SendMagicLink(c *gin.Context) {
 		return
 	}
 
-	magicLink, err := h.authService.SendMagicLink(c.Request.Context(), req.Email)
+	if !h.checkAbuse(c, services.AbuseActionMagicLink, req.Email, req.CaptchaToken) {
+		return
+	}
+
+	deviceNonce, err := h.authService.SendMagicLink(c.Request.Context(), req.Email, req.Locale)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send magic link"})
 		return
 	}
 
+	// Mesma resposta para emails cadastrados ou não
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Magic link sent successfully",
-		"token":   magicLink.Token, // Remover em produção
+		"message":      "If the email is valid, a magic link has been sent",
+		"device_nonce": deviceNonce,
 	})
 }
 
@@ -88,28 +229,27 @@ func (h *AuthHandler) VerifyMagicLink(c *gin.Context) {
 		return
 	}
 
-	user, accessToken, refreshToken, err := h.authService.VerifyMagicLink(c.Request.Context(), req.Token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+	if !h.checkAbuse(c, services.AbuseActionVerify, "", req.CaptchaToken) {
 		return
 	}
 
-	response := AuthResponse{
-		User: UserResponse{
-			ID:        user.ID.String(),
-			Email:     user.Email,
-			FirstName: stringValue(user.FirstName),
-			LastName:  stringValue(user.LastName),
-			AvatarURL: stringValue(user.AvatarURL),
-			Status:    string(user.Status),
-			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		},
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	var auth *models.AuthResponse
+	var err error
+	if req.Token != "" {
+		auth, err = h.authService.VerifyMagicLink(c.Request.Context(), req.Token)
+	} else {
+		auth, err = h.authService.VerifyMagicCode(c.Request.Context(), req.DeviceNonce, req.Code)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrAccountSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, newAuthResponse(auth))
 }
 
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
@@ -119,24 +259,186 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	auth, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken)
 	if err != nil {
+		if errors.Is(err, services.ErrAccountSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"access_token": accessToken,
+		"access_token":  auth.AccessToken,
+		"refresh_token": auth.RefreshToken,
+		"expires_in":    auth.ExpiresIn,
+	})
+}
+
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkAbuse(c, services.AbuseActionRegister, req.Email, req.CaptchaToken) {
+		return
+	}
+
+	auth, err := h.authService.Register(c.Request.Context(), &models.CreateUserRequest{
+		Email:     req.Email,
+		Password:  req.Password,
+		FirstName: optionalString(req.FirstName),
+		LastName:  optionalString(req.LastName),
+		Locale:    req.Locale,
+		Timezone:  req.Timezone,
+	})
+	if err != nil {
+		switch {
+		case password.IsPolicyError(err):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailAlreadyRegistered):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, newAuthResponse(auth))
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkAbuse(c, services.AbuseActionLogin, req.Email, req.CaptchaToken) {
+		return
+	}
+
+	auth, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		case errors.Is(err, services.ErrAccountLocked):
+			c.JSON(http.StatusLocked, gin.H{"error": "Account temporarily locked due to too many failed attempts"})
+		case errors.Is(err, services.ErrAccountSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(auth))
+}
+
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	err := h.authService.ChangePassword(c.Request.Context(), claims.UserID, claims.SessionID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch {
+		case password.IsPolicyError(err):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		case errors.Is(err, services.ErrAccountLocked):
+			c.JSON(http.StatusLocked, gin.H{"error": "Account temporarily locked due to too many failed attempts"})
+		case errors.Is(err, services.ErrRecentLoginRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sign in again to set a password"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+	})
+}
+
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkAbuse(c, services.AbuseActionPasswordReset, req.Email, req.CaptchaToken) {
+		return
+	}
+
+	h.authService.RequestPasswordReset(c.Request.Context(), req.Email, req.Locale)
+
+	// Mesma resposta para emails com e sem conta; o envio é assíncrono
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+func (h *AuthHandler) ConfirmPasswordReset(c *gin.Context) {
+	var req ConfirmPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		switch {
+		case password.IsPolicyError(err):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidResetToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
 	})
 }
 
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// TODO: Implementar invalidação de tokens
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.authService.Logout(c.Request.Context(), claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
 }
 
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.authService.LogoutAll(c.Request.Context(), claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of all sessions successfully",
+	})
+}
+
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
@@ -155,12 +457,33 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implementar atualização do perfil
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Profile updated successfully",
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	user, err := h.authService.UpdateProfile(c.Request.Context(), claims.UserID, &models.UpdateUserRequest{
+		FirstName:                req.FirstName,
+		LastName:                 req.LastName,
+		AvatarURL:                req.AvatarURL,
+		Locale:                   req.Locale,
+		Timezone:                 req.Timezone,
+		PushNotificationsEnabled: req.PushNotificationsEnabled,
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAvatarURL):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid avatar URL"})
+		case errors.Is(err, services.ErrInvalidTimezone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
 }
 
+// AuthMiddleware autentica a requisição com um access token (JWT) ou um
+// token de acesso pessoal (pm_pat_)
 func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -178,25 +501,55 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		token := tokenParts[1]
-		user, err := h.authService.ValidateAccessToken(token)
+		user, claims, err := h.authService.ValidateAccessToken(c.Request.Context(), token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			// Falhas do Redis ou do banco não invalidam o token: com 401 o
+			// cliente descartaria uma sessão válida durante a indisponibilidade
+			if errors.Is(err, services.ErrInvalidAccessToken) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			} else {
+				log.Printf("Failed to validate access token: %v", err)
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication temporarily unavailable"})
+			}
 			c.Abort()
 			return
 		}
 
-		userResponse := &UserResponse{
-			ID:        user.ID.String(),
-			Email:     user.Email,
-			FirstName: stringValue(user.FirstName),
-			LastName:  stringValue(user.LastName),
-			AvatarURL: stringValue(user.AvatarURL),
-			Status:    string(user.Status),
-			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		userResponse := newUserResponse(user)
+		c.Set("user", &userResponse)
+		c.Set("claims", claims)
+
+		// Claims de autorização para ginauthz.RequirePermission/RequireRole
+		authzClaims := &authz.Claims{
+			TokenID: claims.ID,
+			UserID:  claims.UserID.String(),
+			Email:   claims.Email,
+			Roles:   claims.Roles,
+			Scopes:  claims.Scopes,
+			OrgRole: string(claims.OrgRole),
+		}
+		if claims.Type == "access" {
+			authzClaims.SessionID = claims.SessionID.String()
 		}
+		if claims.OrgID != nil {
+			authzClaims.OrgID = claims.OrgID.String()
+		}
+		c.Request = c.Request.WithContext(authz.WithClaims(c.Request.Context(), authzClaims))
+		c.Next()
+	}
+}
 
-		c.Set("user", userResponse)
+// RequireSessionToken recusa tokens de acesso pessoal. Montado depois do
+// AuthMiddleware nas rotas de gerenciamento da conta, que exigem uma sessão
+// interativa.
+func RequireSessionToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("claims").(*models.JWTClaims)
+		if claims.Type != "access" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Personal access tokens cannot be used for this endpoint"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
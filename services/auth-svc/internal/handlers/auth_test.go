@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/captcha"
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/ratelimit"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+	"pagemagic/auth-svc/internal/signing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClientIP endereço de origem das requisições do httptest
+const testClientIP = "192.0.2.1"
+
+func newTestAbuseGuard(t *testing.T) *services.AbuseGuard {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return services.NewAbuseGuard(ratelimit.New(client, "test:"), captcha.Fake{})
+}
+
+// newAbuseTestRouter expõe o endpoint de magic link sem AuthService: as
+// requisições usadas nos testes são barradas antes de chegar ao serviço
+func newAbuseTestRouter(guard *services.AbuseGuard) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewAuthHandler(nil, guard)
+	router := gin.New()
+	router.POST("/auth/magic-link", handler.SendMagicLink)
+	return router
+}
+
+// hitGuard consome tentativas do email como requisições anteriores
+func hitGuard(t *testing.T, guard *services.AbuseGuard, email string, times int) {
+	t.Helper()
+
+	for i := 0; i < times; i++ {
+		require.NoError(t, guard.Check(context.Background(), services.AbuseActionMagicLink,
+			services.AbuseSubject{Email: email, IP: testClientIP}, captcha.FakePassToken))
+	}
+}
+
+func postMagicLink(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/magic-link", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestSendMagicLinkRequiresChallenge(t *testing.T) {
+	guard := newTestAbuseGuard(t)
+	router := newAbuseTestRouter(guard)
+	hitGuard(t, guard, "ana@example.com", 3)
+
+	recorder := postMagicLink(router, `{"email":"ana@example.com","captcha_token":"wrong"}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Retry-After"))
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, "captcha", body["challenge"])
+}
+
+func TestSendMagicLinkRateLimited(t *testing.T) {
+	guard := newTestAbuseGuard(t)
+	router := newAbuseTestRouter(guard)
+	hitGuard(t, guard, "ana@example.com", 5)
+
+	recorder := postMagicLink(router, `{"email":"ana@example.com","captcha_token":"captcha-pass"}`)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+	// No cool-down o tempo restante é arredondado para cima
+	recorder = postMagicLink(router, `{"email":"ana@example.com","captcha_token":"captcha-pass"}`)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+}
+
+func TestCheckAbuseAllows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewAuthHandler(nil, newTestAbuseGuard(t))
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+
+	assert.True(t, handler.checkAbuse(c, services.AbuseActionLogin, "ana@example.com", ""))
+	assert.False(t, c.Writer.Written())
+}
+
+func TestAuthResponseIncludesMFAMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.JSON(http.StatusOK, newAuthResponse(&models.AuthResponse{
+		MFAToken:   "mfa-token",
+		MFAMethods: []string{"totp", "recovery_code", "webauthn", "sms"},
+		ExpiresIn:  300,
+	}))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, true, body["mfa_required"])
+	assert.Equal(t, "mfa-token", body["mfa_token"])
+	assert.Equal(t, []interface{}{"totp", "recovery_code", "webauthn", "sms"}, body["mfa_methods"])
+	assert.NotContains(t, body, "access_token")
+}
+
+// newMiddlewareTestRouter protege uma rota com o AuthMiddleware; os tokens
+// usados nos testes são recusados antes da busca do usuário
+func newMiddlewareTestRouter(t *testing.T) (*gin.Engine, *signing.KeySet, services.TokenDenylist, *miniredis.Miniredis) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	keys, err := signing.NewEphemeral()
+	require.NoError(t, err)
+
+	denylist := services.NewRedisDenylist(client)
+	authService := services.NewAuthService(&repository.Repository{}, keys, denylist, nil, &config.Config{})
+
+	router := gin.New()
+	router.GET("/me", NewAuthHandler(authService, nil).AuthMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router, keys, denylist, mr
+}
+
+func getWithToken(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAuthMiddlewareStatus(t *testing.T) {
+	router, keys, denylist, mr := newMiddlewareTestRouter(t)
+
+	jti := uuid.NewString()
+	token, err := keys.Sign(jwt.MapClaims{
+		"type":    "access",
+		"jti":     jti,
+		"user_id": uuid.NewString(),
+		"sid":     uuid.NewString(),
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, denylist.Revoke(context.Background(), jti, time.Minute))
+
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(router, "not-a-jwt").Code)
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(router, token).Code)
+
+	// Com o Redis fora do ar o token não é dado como inválido
+	mr.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, getWithToken(router, token).Code)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailChangeRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Locale string `json:"locale"`
+}
+
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func respondEmailChangeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrEmailUnchanged):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New email is the same as the current one"})
+	case errors.Is(err, services.ErrEmailAlreadyRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+	case errors.Is(err, services.ErrInvalidEmailChange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	var req EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.authService.RequestEmailChange(c.Request.Context(), claims.UserID, req.Email, req.Locale); err != nil {
+		respondEmailChangeError(c, err, "Failed to request email change")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "A confirmation link has been sent to the new email",
+	})
+}
+
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	var req EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.ConfirmEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		respondEmailChangeError(c, err, "Failed to confirm email change")
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (h *AuthHandler) CancelEmailChange(c *gin.Context) {
+	var req EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.CancelEmailChange(c.Request.Context(), req.Token); err != nil {
+		respondEmailChangeError(c, err, "Failed to cancel email change")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email change canceled",
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IntrospectionHandler struct {
+	introspectionService *services.IntrospectionService
+}
+
+func NewIntrospectionHandler(introspectionService *services.IntrospectionService) *IntrospectionHandler {
+	return &IntrospectionHandler{
+		introspectionService: introspectionService,
+	}
+}
+
+// Introspect implementa o endpoint de introspecção da RFC 7662. O serviço
+// chamador se autentica com HTTP Basic (client_id:client_secret) e envia o
+// token no corpo form-encoded (token=...). Usado pelo gateway para trocar o
+// bearer token por headers de identidade confiáveis.
+func (h *IntrospectionHandler) Introspect(c *gin.Context) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok || !h.introspectionService.AuthenticateClient(clientID, clientSecret) {
+		c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	response, err := h.introspectionService.Introspect(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to introspect token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
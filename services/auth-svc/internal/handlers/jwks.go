@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"pagemagic/auth-svc/internal/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publica as chaves públicas de validação dos access tokens. O cache
+// curto permite que consumidores vejam chaves novas antes da rotação.
+func JWKS(keys *signing.KeySet) gin.HandlerFunc {
+	doc := keys.JWKS()
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// respondMFAError mapeia os erros de 2FA para respostas HTTP
+func respondMFAError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+	case errors.Is(err, services.ErrInvalidMFAToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+	case errors.Is(err, services.ErrAccountLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": "Account temporarily locked due to too many failed attempts"})
+	case errors.Is(err, services.ErrAccountSuspended):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+	case errors.Is(err, services.ErrMFANotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+	case errors.Is(err, services.ErrMFAEnrollmentNotStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Start two-factor enrollment first"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auth, err := h.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		respondMFAError(c, err, "Failed to verify two-factor code")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(auth))
+}
+
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	enrollment, err := h.authService.EnrollTOTP(c.Request.Context(), claims.UserID)
+	if err != nil {
+		respondMFAError(c, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURL: enrollment.URL,
+	})
+}
+
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	codes, err := h.authService.ConfirmTOTP(c.Request.Context(), claims.UserID, req.Code)
+	if err != nil {
+		respondMFAError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.authService.DisableTOTP(c.Request.Context(), claims.UserID, req.Code); err != nil {
+		respondMFAError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), claims.UserID, req.Code)
+	if err != nil {
+		respondMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
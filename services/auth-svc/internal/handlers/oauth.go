@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OAuthHandler struct {
+	oauthService *services.OAuthService
+}
+
+type IdentityResponse struct {
+	ID            string `json:"id"`
+	Provider      string `json:"provider"`
+	ProviderEmail string `json:"provider_email,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func NewOAuthHandler(oauthService *services.OAuthService) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// newIdentityResponse converte a identidade vinculada para a resposta da API
+func newIdentityResponse(identity *models.UserAuthProvider) IdentityResponse {
+	return IdentityResponse{
+		ID:            identity.ID.String(),
+		Provider:      string(identity.Provider),
+		ProviderEmail: stringValue(identity.ProviderEmail),
+		CreatedAt:     identity.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// oauthNonceCookie amarra o fluxo OAuth ao navegador que o iniciou
+const oauthNonceCookie = "oauth_nonce"
+
+// setOAuthNonceCookie grava (ou, com maxAge negativo, apaga) o cookie do
+// nonce. A Apple devolve o callback por POST entre sites (form_post), que
+// não leva cookies SameSite=Lax; para ela o cookie precisa ser None.
+func setOAuthNonceCookie(c *gin.Context, provider, nonce string, maxAge int) {
+	sameSite := http.SameSiteLaxMode
+	if provider == string(models.AuthProviderApple) {
+		sameSite = http.SameSiteNoneMode
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oauthNonceCookie,
+		Value:    nonce,
+		Path:     "/api/v1/auth/oauth/" + provider,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: sameSite,
+	})
+}
+
+func (h *OAuthHandler) Start(c *gin.Context) {
+	provider := c.Param("provider")
+
+	authURL, browserNonce, err := h.oauthService.Start(c.Request.Context(), provider)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start OAuth flow"})
+		return
+	}
+
+	setOAuthNonceCookie(c, provider, browserNonce, int(services.OAuthStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback aceita GET (query string) e POST (form_post, usado pela Apple)
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	if providerErr := c.Request.FormValue("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization denied by provider"})
+		return
+	}
+
+	state := c.Request.FormValue("state")
+	code := c.Request.FormValue("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing state or code"})
+		return
+	}
+
+	provider := c.Param("provider")
+	browserNonce, _ := c.Cookie(oauthNonceCookie)
+	setOAuthNonceCookie(c, provider, "", -1)
+
+	result, err := h.oauthService.Callback(c.Request.Context(), provider, state, code, browserNonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		case errors.Is(err, services.ErrInvalidOAuthState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired state"})
+		case errors.Is(err, services.ErrOAuthEmailRequired):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Provider did not share an email address"})
+		case errors.Is(err, services.ErrOAuthEmailNotVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+		case errors.Is(err, services.ErrOAuthSignupEmailNotVerified):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Verify your email address with the provider before signing up"})
+		case errors.Is(err, services.ErrIdentityLinkedToOtherUser):
+			c.JSON(http.StatusConflict, gin.H{"error": "This identity is already linked to another account"})
+		case errors.Is(err, services.ErrAccountSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		default:
+			log.Printf("OAuth callback failed: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuth sign-in failed"})
+		}
+		return
+	}
+
+	if result.Linked != nil {
+		c.JSON(http.StatusOK, newIdentityResponse(result.Linked))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(result.Auth))
+}
+
+func (h *OAuthHandler) ListIdentities(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	identities, err := h.oauthService.ListIdentities(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list identities"})
+		return
+	}
+
+	response := make([]IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		response = append(response, newIdentityResponse(identity))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": response})
+}
+
+// LinkIdentity retorna a URL de autorização; o callback do provedor conclui o vínculo
+func (h *OAuthHandler) LinkIdentity(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+	provider := c.Param("provider")
+
+	authURL, browserNonce, err := h.oauthService.StartLink(c.Request.Context(), provider, claims.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start OAuth flow"})
+		return
+	}
+
+	// O cliente abre a URL no mesmo navegador; o callback confere o cookie
+	setOAuthNonceCookie(c, provider, browserNonce, int(services.OAuthStateTTL.Seconds()))
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+func (h *OAuthHandler) UnlinkIdentity(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity ID"})
+		return
+	}
+
+	if err := h.oauthService.UnlinkIdentity(c.Request.Context(), claims.UserID, identityID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Identity not found"})
+		case errors.Is(err, services.ErrLastLoginMethod):
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot unlink the last remaining login method"})
+		case errors.Is(err, services.ErrEmailIdentityManaged):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The email identity cannot be unlinked"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked successfully"})
+}
@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	orgService *services.OrganizationService
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type InvitationRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Role   string `json:"role" binding:"required"`
+	Locale string `json:"locale"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type SwitchOrganizationRequest struct {
+	// OrganizationID nulo ou ausente desativa a organização da sessão
+	OrganizationID *string `json:"organization_id"`
+}
+
+type OrganizationResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+type MemberResponse struct {
+	UserID    string  `json:"user_id"`
+	Email     string  `json:"email"`
+	Name      *string `json:"name,omitempty"`
+	Role      string  `json:"role"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type InvitationResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func NewOrganizationHandler(orgService *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+	}
+}
+
+// newOrganizationResponse converte a organização para a resposta da API
+func newOrganizationResponse(membership *models.OrganizationMembership) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        membership.ID.String(),
+		Name:      membership.Name,
+		Role:      string(membership.Role),
+		CreatedAt: membership.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// newInvitationResponse converte o convite para a resposta da API
+func newInvitationResponse(invitation *models.OrganizationInvitation) InvitationResponse {
+	return InvitationResponse{
+		ID:        invitation.ID.String(),
+		Email:     invitation.Email,
+		Role:      string(invitation.Role),
+		ExpiresAt: invitation.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		CreatedAt: invitation.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func respondOrganizationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+	case errors.Is(err, services.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+	case errors.Is(err, services.ErrInsufficientOrgRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient organization role"})
+	case errors.Is(err, services.ErrInvalidOrgRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role; use owner, admin, editor or viewer"})
+	case errors.Is(err, services.ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+	case errors.Is(err, services.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": "Organization must keep at least one owner"})
+	case errors.Is(err, services.ErrInvalidInvitation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired invitation"})
+	case errors.Is(err, services.ErrInvitationEmailMismatch):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invitation was sent to a different email"})
+	case errors.Is(err, services.ErrInvitationEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email before accepting the invitation"})
+	case errors.Is(err, services.ErrSessionRevoked):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session revoked"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseUUIDParam lê um parâmetro de rota UUID, respondendo 400 se inválido
+func parseUUIDParam(c *gin.Context, name, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	memberships, err := h.orgService.List(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list organizations"})
+		return
+	}
+
+	response := make([]OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		response = append(response, newOrganizationResponse(membership))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": response})
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	membership, err := h.orgService.Create(c.Request.Context(), claims.UserID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newOrganizationResponse(membership))
+}
+
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	membership, err := h.orgService.Get(c.Request.Context(), claims.UserID, orgID)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to get organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrganizationResponse(membership))
+}
+
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	membership, err := h.orgService.Update(c.Request.Context(), claims.UserID, orgID, req.Name)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to update organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrganizationResponse(membership))
+}
+
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.orgService.Delete(c.Request.Context(), claims.UserID, orgID); err != nil {
+		respondOrganizationError(c, err, "Failed to delete organization")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	members, err := h.orgService.ListMembers(c.Request.Context(), claims.UserID, orgID)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to list members")
+		return
+	}
+
+	response := make([]MemberResponse, 0, len(members))
+	for _, member := range members {
+		response = append(response, MemberResponse{
+			UserID:    member.UserID.String(),
+			Email:     member.Email,
+			Name:      member.Name,
+			Role:      string(member.Role),
+			CreatedAt: member.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": response})
+}
+
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	memberID, ok := parseUUIDParam(c, "user_id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	err := h.orgService.UpdateMemberRole(c.Request.Context(), claims.UserID, orgID, memberID, models.OrgRole(req.Role))
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to update member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	memberID, ok := parseUUIDParam(c, "user_id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.orgService.RemoveMember(c.Request.Context(), claims.UserID, orgID, memberID); err != nil {
+		respondOrganizationError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) ListInvitations(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	invitations, err := h.orgService.ListInvitations(c.Request.Context(), claims.UserID, orgID)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to list invitations")
+		return
+	}
+
+	response := make([]InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, newInvitationResponse(invitation))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": response})
+}
+
+func (h *OrganizationHandler) CreateInvitation(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	var req InvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	invitation, err := h.orgService.Invite(c.Request.Context(), claims.UserID, orgID, req.Email, models.OrgRole(req.Role), req.Locale)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to send invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, newInvitationResponse(invitation))
+}
+
+func (h *OrganizationHandler) RevokeInvitation(c *gin.Context) {
+	orgID, ok := parseUUIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseUUIDParam(c, "invitation_id", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.orgService.RevokeInvitation(c.Request.Context(), claims.UserID, orgID, invitationID); err != nil {
+		respondOrganizationError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	membership, err := h.orgService.AcceptInvitation(c.Request.Context(), claims.UserID, req.Token)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrganizationResponse(membership))
+}
+
+// SwitchOrganization troca a organização ativa da sessão e devolve um novo
+// access token com os claims org_id/org_role
+func (h *OrganizationHandler) SwitchOrganization(c *gin.Context) {
+	var req SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var orgID *uuid.UUID
+	if req.OrganizationID != nil {
+		id, err := uuid.Parse(*req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+			return
+		}
+		orgID = &id
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	response, err := h.orgService.SwitchOrganization(c.Request.Context(), claims, orgID)
+	if err != nil {
+		respondOrganizationError(c, err, "Failed to switch organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(response))
+}
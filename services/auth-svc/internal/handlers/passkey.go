@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PasskeyHandler struct {
+	passkeyService *services.PasskeyService
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type BeginPasskeyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+type FinishPasskeyMFARequest struct {
+	MFAToken   string          `json:"mfa_token" binding:"required"`
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type PasskeyResponse struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Transports     []string `json:"transports"`
+	BackupEligible bool     `json:"backup_eligible"`
+	LastUsedAt     string   `json:"last_used_at,omitempty"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+func NewPasskeyHandler(passkeyService *services.PasskeyService) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+	}
+}
+
+// newPasskeyResponse converte a credencial para a resposta da API
+func newPasskeyResponse(credential *models.WebAuthnCredential) PasskeyResponse {
+	response := PasskeyResponse{
+		ID:             credential.ID.String(),
+		Name:           credential.Name,
+		Transports:     credential.Transports,
+		BackupEligible: credential.BackupEligible,
+		CreatedAt:      credential.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if credential.LastUsedAt != nil {
+		response.LastUsedAt = credential.LastUsedAt.Format("2006-01-02T15:04:05Z")
+	}
+	return response
+}
+
+// respondPasskeyError mapeia os erros das cerimônias WebAuthn
+func respondPasskeyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebAuthnSession):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired passkey session"})
+	case errors.Is(err, services.ErrInvalidPasskey):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey verification failed"})
+	default:
+		respondMFAError(c, err, fallback)
+	}
+}
+
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	options, sessionID, err := h.passkeyService.BeginRegistration(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	var req FinishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	credential, err := h.passkeyService.FinishRegistration(c.Request.Context(), claims.UserID, req.SessionID, req.Name, req.Credential)
+	if err != nil {
+		respondPasskeyError(c, err, "Failed to register passkey")
+		return
+	}
+
+	c.JSON(http.StatusCreated, newPasskeyResponse(credential))
+}
+
+func (h *PasskeyHandler) ListPasskeys(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	credentials, err := h.passkeyService.ListCredentials(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list passkeys"})
+		return
+	}
+
+	response := make([]PasskeyResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		response = append(response, newPasskeyResponse(credential))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"passkeys": response})
+}
+
+func (h *PasskeyHandler) DeletePasskey(c *gin.Context) {
+	credentialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey ID"})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.passkeyService.DeleteCredential(c.Request.Context(), claims.UserID, credentialID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete passkey"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PasskeyHandler) BeginLogin(c *gin.Context) {
+	options, sessionID, err := h.passkeyService.BeginLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+func (h *PasskeyHandler) FinishLogin(c *gin.Context) {
+	var req FinishPasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auth, err := h.passkeyService.FinishLogin(c.Request.Context(), req.SessionID, req.Credential)
+	if err != nil {
+		respondPasskeyError(c, err, "Failed to log in with passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(auth))
+}
+
+func (h *PasskeyHandler) BeginMFA(c *gin.Context) {
+	var req BeginPasskeyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, sessionID, err := h.passkeyService.BeginMFA(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		respondPasskeyError(c, err, "Failed to start passkey verification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+func (h *PasskeyHandler) FinishMFA(c *gin.Context) {
+	var req FinishPasskeyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auth, err := h.passkeyService.FinishMFA(c.Request.Context(), req.MFAToken, req.SessionID, req.Credential)
+	if err != nil {
+		respondPasskeyError(c, err, "Failed to verify passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(auth))
+}
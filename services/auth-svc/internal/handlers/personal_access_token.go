@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreatePersonalAccessTokenRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresAt opcional (RFC 3339); ausente cria um token sem expiração
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	LastUsedIP string   `json:"last_used_ip,omitempty"`
+	Expired    bool     `json:"expired"`
+	CreatedAt  string   `json:"created_at"`
+	// Token é retornado apenas na criação
+	Token string `json:"token,omitempty"`
+}
+
+// newPersonalAccessTokenResponse converte o token para a resposta da API
+func newPersonalAccessTokenResponse(token *models.PersonalAccessToken) PersonalAccessTokenResponse {
+	response := PersonalAccessTokenResponse{
+		ID:         token.ID.String(),
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		LastUsedIP: stringValue(token.LastUsedIP),
+		Expired:    !token.IsActive(),
+		CreatedAt:  token.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if token.ExpiresAt != nil {
+		response.ExpiresAt = token.ExpiresAt.Format("2006-01-02T15:04:05Z")
+	}
+	if token.LastUsedAt != nil {
+		response.LastUsedAt = token.LastUsedAt.Format("2006-01-02T15:04:05Z")
+	}
+	return response
+}
+
+func (h *AuthHandler) ListPersonalAccessTokens(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	tokens, err := h.authService.ListPersonalAccessTokens(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tokens"})
+		return
+	}
+
+	response := make([]PersonalAccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, newPersonalAccessTokenResponse(token))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": response})
+}
+
+func (h *AuthHandler) CreatePersonalAccessToken(c *gin.Context) {
+	var req CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	token, plain, err := h.authService.CreatePersonalAccessToken(c.Request.Context(), claims.UserID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidScope), errors.Is(err, services.ErrInvalidExpiry):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTooManyTokens):
+			c.JSON(http.StatusConflict, gin.H{"error": "Too many active tokens; revoke an existing one first"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		}
+		return
+	}
+
+	response := newPersonalAccessTokenResponse(token)
+	response.Token = plain
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *AuthHandler) RevokePersonalAccessToken(c *gin.Context) {
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.authService.RevokePersonalAccessToken(c.Request.Context(), claims.UserID, tokenID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
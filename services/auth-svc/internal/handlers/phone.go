@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/services"
+	"pagemagic/auth-svc/internal/sms"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PhoneHandler struct {
+	phoneService *services.PhoneService
+}
+
+type StartPhoneVerificationRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+}
+
+type SendSMSMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+func NewPhoneHandler(phoneService *services.PhoneService) *PhoneHandler {
+	return &PhoneHandler{
+		phoneService: phoneService,
+	}
+}
+
+func respondPhoneError(c *gin.Context, err error, fallback string) {
+	var limited *services.RateLimitError
+	switch {
+	case errors.As(err, &limited):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many codes requested, try again later"})
+	case errors.Is(err, sms.ErrInvalidPhoneNumber):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Phone number must be in international format, e.g. +5511912345678"})
+	case errors.Is(err, services.ErrInvalidSMSCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired code"})
+	case errors.Is(err, services.ErrMobileNotVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": "Verify a mobile number first"})
+	case errors.Is(err, services.ErrSMSMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "SMS two-factor authentication is already enabled"})
+	case errors.Is(err, services.ErrSMSMFANotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "SMS two-factor authentication is not enabled"})
+	case errors.Is(err, services.ErrSMSUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "SMS delivery is not available"})
+	default:
+		respondMFAError(c, err, fallback)
+	}
+}
+
+func (h *PhoneHandler) StartVerification(c *gin.Context) {
+	var req StartPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	phone, err := h.phoneService.StartVerification(c.Request.Context(), claims.UserID, req.PhoneNumber)
+	if err != nil {
+		respondPhoneError(c, err, "Failed to send verification code")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"phone_number": phone})
+}
+
+func (h *PhoneHandler) ConfirmVerification(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	user, err := h.phoneService.ConfirmVerification(c.Request.Context(), claims.UserID, req.Code)
+	if err != nil {
+		respondPhoneError(c, err, "Failed to verify mobile number")
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (h *PhoneHandler) RemoveMobile(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.phoneService.RemoveMobile(c.Request.Context(), claims.UserID); err != nil {
+		respondPhoneError(c, err, "Failed to remove mobile number")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PhoneHandler) EnableSMSFactor(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.phoneService.EnableSMSFactor(c.Request.Context(), claims.UserID); err != nil {
+		respondPhoneError(c, err, "Failed to enable SMS two-factor authentication")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PhoneHandler) DisableSMSFactor(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.phoneService.DisableSMSFactor(c.Request.Context(), claims.UserID); err != nil {
+		respondPhoneError(c, err, "Failed to disable SMS two-factor authentication")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PhoneHandler) SendMFACode(c *gin.Context) {
+	var req SendSMSMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.phoneService.SendMFACode(c.Request.Context(), req.MFAToken); err != nil {
+		respondPhoneError(c, err, "Failed to send two-factor code")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *PhoneHandler) VerifyMFACode(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auth, err := h.phoneService.VerifyMFACode(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		respondPhoneError(c, err, "Failed to verify two-factor code")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(auth))
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	privacyService *services.PrivacyService
+}
+
+type DataExportResponse struct {
+	ID          string  `json:"id"`
+	Status      string  `json:"status"`
+	CreatedAt   string  `json:"created_at"`
+	CompletedAt *string `json:"completed_at,omitempty"`
+	ExpiresAt   *string `json:"expires_at,omitempty"`
+}
+
+type AccountDeletionResponse struct {
+	RequestedAt  string `json:"requested_at"`
+	ScheduledFor string `json:"scheduled_for"`
+}
+
+func NewPrivacyHandler(privacyService *services.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// newDataExportResponse converte a exportação para a resposta da API
+func newDataExportResponse(export *models.DataExport) DataExportResponse {
+	response := DataExportResponse{
+		ID:        export.ID.String(),
+		Status:    string(export.Status),
+		CreatedAt: export.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if export.CompletedAt != nil {
+		completedAt := export.CompletedAt.Format("2006-01-02T15:04:05Z")
+		response.CompletedAt = &completedAt
+	}
+	if export.ExpiresAt != nil {
+		expiresAt := export.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+// newAccountDeletionResponse converte o pedido de exclusão para a resposta da API
+func newAccountDeletionResponse(deletion *models.AccountDeletion) AccountDeletionResponse {
+	return AccountDeletionResponse{
+		RequestedAt:  deletion.RequestedAt.Format("2006-01-02T15:04:05Z"),
+		ScheduledFor: deletion.ScheduledFor.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func respondPrivacyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+	case errors.Is(err, services.ErrExportInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": "A data export is already in progress"})
+	case errors.Is(err, services.ErrExportNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready or has expired"})
+	case errors.Is(err, services.ErrDeletionAlreadyScheduled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Account deletion already scheduled"})
+	case errors.Is(err, services.ErrDeletionNotScheduled):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No account deletion scheduled"})
+	case errors.Is(err, services.ErrSoleOrganizationOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": "Transfer ownership of your organizations before deleting your account"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *PrivacyHandler) RequestExport(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	export, err := h.privacyService.RequestExport(c.Request.Context(), claims.UserID)
+	if err != nil {
+		respondPrivacyError(c, err, "Failed to request data export")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newDataExportResponse(export))
+}
+
+func (h *PrivacyHandler) GetExport(c *gin.Context) {
+	exportID, ok := parseUUIDParam(c, "id", "Invalid export ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	export, err := h.privacyService.GetExport(c.Request.Context(), claims.UserID, exportID)
+	if err != nil {
+		respondPrivacyError(c, err, "Failed to get data export")
+		return
+	}
+
+	c.JSON(http.StatusOK, newDataExportResponse(export))
+}
+
+func (h *PrivacyHandler) DownloadExport(c *gin.Context) {
+	exportID, ok := parseUUIDParam(c, "id", "Invalid export ID")
+	if !ok {
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	archive, err := h.privacyService.DownloadExport(c.Request.Context(), claims.UserID, exportID)
+	if err != nil {
+		respondPrivacyError(c, err, "Failed to download data export")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="pagemagic-export-%s.zip"`, exportID))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func (h *PrivacyHandler) GetDeletion(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	deletion, err := h.privacyService.GetDeletion(c.Request.Context(), claims.UserID)
+	if err != nil {
+		respondPrivacyError(c, err, "Failed to get account deletion")
+		return
+	}
+
+	c.JSON(http.StatusOK, newAccountDeletionResponse(deletion))
+}
+
+func (h *PrivacyHandler) ScheduleDeletion(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	deletion, err := h.privacyService.ScheduleDeletion(c.Request.Context(), claims.UserID)
+	if err != nil {
+		respondPrivacyError(c, err, "Failed to schedule account deletion")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newAccountDeletionResponse(deletion))
+}
+
+func (h *PrivacyHandler) CancelDeletion(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.privacyService.CancelDeletion(c.Request.Context(), claims.UserID); err != nil {
+		respondPrivacyError(c, err, "Failed to cancel account deletion")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
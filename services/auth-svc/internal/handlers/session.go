@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateDeviceRequest struct {
+	Name    *string `json:"name" binding:"omitempty,max=100"`
+	Trusted *bool   `json:"trusted"`
+}
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	DeviceID   string `json:"device_id,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	Current    bool   `json:"current"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type DeviceResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Platform   string `json:"platform"`
+	IsTrusted  bool   `json:"is_trusted"`
+	LastSeenAt string `json:"last_seen_at"`
+	LastSeenIP string `json:"last_seen_ip,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// newSessionResponse converte a sessão para a resposta da API
+func newSessionResponse(session *models.Session, currentID uuid.UUID) SessionResponse {
+	response := SessionResponse{
+		ID:         session.ID.String(),
+		IPAddress:  stringValue(session.IPAddress),
+		UserAgent:  stringValue(session.UserAgent),
+		Current:    session.ID == currentID,
+		LastSeenAt: session.LastSeenAt.Format("2006-01-02T15:04:05Z"),
+		ExpiresAt:  session.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		CreatedAt:  session.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if session.DeviceID != nil {
+		response.DeviceID = session.DeviceID.String()
+	}
+	return response
+}
+
+// newDeviceResponse converte o dispositivo para a resposta da API
+func newDeviceResponse(device *models.Device) DeviceResponse {
+	return DeviceResponse{
+		ID:         device.ID.String(),
+		Name:       device.Name,
+		Type:       device.Type,
+		Platform:   device.Platform,
+		IsTrusted:  device.IsTrusted,
+		LastSeenAt: device.LastSeenAt.Format("2006-01-02T15:04:05Z"),
+		LastSeenIP: stringValue(device.LastSeenIP),
+		CreatedAt:  device.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// ClientInfoMiddleware disponibiliza IP, user agent e ID do dispositivo
+// para os serviços através do contexto da requisição
+func ClientInfoMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := services.WithClientInfo(c.Request.Context(), services.ClientInfo{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			DeviceID:  c.GetHeader("X-Device-ID"),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, newSessionResponse(session, claims.SessionID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": response})
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	if err := h.authService.RevokeSession(c.Request.Context(), claims.UserID, sessionID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ListDevices(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	devices, err := h.authService.ListDevices(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list devices"})
+		return
+	}
+
+	response := make([]DeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		response = append(response, newDeviceResponse(device))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"devices": response})
+}
+
+func (h *AuthHandler) UpdateDevice(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	var req UpdateDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(*models.JWTClaims)
+
+	device, err := h.authService.UpdateDevice(c.Request.Context(), claims.UserID, deviceID, req.Name, req.Trusted)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newDeviceResponse(device))
+}
@@ -0,0 +1,171 @@
+// Package janitor remove periodicamente tokens, códigos, sessões e eventos
+// expirados. Várias réplicas podem rodar o janitor; um advisory lock do
+// Postgres elege a única que executa os jobs.
+package janitor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/pglock"
+	"pagemagic/auth-svc/internal/repository"
+)
+
+// advisoryLockKey identifica o lock de liderança do janitor
+const advisoryLockKey int64 = 0x6175746873766302 // "authsvc" + 2
+
+const (
+	// leaderRetryInterval espera entre tentativas de obter a liderança
+	leaderRetryInterval = 30 * time.Second
+	// leaderCheckInterval frequência com que o líder confirma que ainda
+	// detém o lock
+	leaderCheckInterval = 15 * time.Second
+)
+
+// Job limpeza executada a cada Interval; Run retorna quantas linhas removeu
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// Janitor agenda os jobs de limpeza na réplica líder
+type Janitor struct {
+	repo *repository.Repository
+	jobs []Job
+}
+
+// New cria o janitor com os jobs padrão. Jobs com intervalo zero ficam
+// desativados.
+func New(repo *repository.Repository, cfg config.JanitorConfig) *Janitor {
+	outboxRetention := cfg.OutboxRetention
+
+	jobs := []Job{
+		{Name: "magic_links", Interval: cfg.TokenInterval, Run: repo.MagicLink.DeleteExpired},
+		{Name: "password_resets", Interval: cfg.TokenInterval, Run: repo.PasswordReset.DeleteExpired},
+		{Name: "phone_verifications", Interval: cfg.TokenInterval, Run: repo.Phone.DeleteExpired},
+		{Name: "email_changes", Interval: cfg.TokenInterval, Run: repo.EmailChange.DeleteExpired},
+		{Name: "refresh_tokens", Interval: cfg.SessionInterval, Run: repo.RefreshToken.DeleteExpired},
+		{Name: "sessions", Interval: cfg.SessionInterval, Run: repo.Session.DeleteExpired},
+		{Name: "data_exports", Interval: cfg.ExportInterval, Run: repo.DataExport.DeleteExpired},
+		{Name: "outbox_events", Interval: cfg.OutboxInterval, Run: func(ctx context.Context) (int64, error) {
+			return repo.Outbox.DeletePublishedBefore(ctx, time.Now().Add(-outboxRetention))
+		}},
+	}
+
+	enabled := jobs[:0]
+	for _, job := range jobs {
+		if job.Interval > 0 {
+			enabled = append(enabled, job)
+		}
+	}
+
+	return &Janitor{
+		repo: repo,
+		jobs: enabled,
+	}
+}
+
+// Run disputa a liderança e, enquanto a detiver, executa os jobs até o
+// contexto ser cancelado
+func (j *Janitor) Run(ctx context.Context) {
+	if len(j.jobs) == 0 {
+		return
+	}
+
+	for {
+		lock, err := pglock.TryAcquire(ctx, j.repo.DB(), advisoryLockKey)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Janitor failed to acquire leadership: %v", err)
+		}
+		if lock != nil {
+			j.lead(ctx, lock)
+			if err := lock.Release(context.Background()); err != nil {
+				log.Printf("Janitor failed to release leadership: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaderRetryInterval):
+		}
+	}
+}
+
+// lead executa os jobs até o contexto ser cancelado ou o lock ser perdido
+func (j *Janitor) lead(ctx context.Context, lock *pglock.Lock) {
+	log.Println("Janitor acquired leadership, running cleanup jobs")
+	leader.Set(1)
+	defer leader.Set(0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// Parar os jobs antes de liberar o lock
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for _, job := range j.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			j.schedule(ctx, job)
+		}(job)
+	}
+
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := lock.Alive(ctx); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Janitor lost leadership: %v", err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// schedule executa o job imediatamente e depois a cada intervalo
+func (j *Janitor) schedule(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		j.runJob(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob executa o job uma vez e registra as métricas
+func (j *Janitor) runJob(ctx context.Context, job Job) {
+	start := time.Now()
+	deleted, err := job.Run(ctx)
+	jobDuration.WithLabelValues(job.Name).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		if ctx.Err() == nil {
+			jobFailures.WithLabelValues(job.Name).Inc()
+			log.Printf("Janitor job %s failed: %v", job.Name, err)
+		}
+		return
+	}
+
+	rowsDeleted.WithLabelValues(job.Name).Add(float64(deleted))
+	lastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+}
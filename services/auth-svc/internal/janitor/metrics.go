@@ -0,0 +1,45 @@
+package janitor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Métricas expostas em /metrics pelo registry padrão do Prometheus
+var (
+	rowsDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "auth",
+		Subsystem: "janitor",
+		Name:      "rows_deleted_total",
+		Help:      "Rows removed by each cleanup job.",
+	}, []string{"job"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "auth",
+		Subsystem: "janitor",
+		Name:      "job_duration_seconds",
+		Help:      "Duration of each cleanup job run.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60},
+	}, []string{"job"})
+
+	jobFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "auth",
+		Subsystem: "janitor",
+		Name:      "job_failures_total",
+		Help:      "Cleanup job runs that returned an error.",
+	}, []string{"job"})
+
+	lastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "auth",
+		Subsystem: "janitor",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful run of each cleanup job.",
+	}, []string{"job"})
+
+	leader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "auth",
+		Subsystem: "janitor",
+		Name:      "leader",
+		Help:      "1 if this replica holds the janitor lock and runs the cleanup jobs.",
+	})
+)
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer grava os emails em disco (um arquivo .eml por mensagem) ou em
+// um io.Writer. Usado em desenvolvimento e testes.
+type FileMailer struct {
+	dir  string
+	out  io.Writer
+	from string
+	mu   sync.Mutex
+}
+
+// NewFileMailer cria uma nova instância do FileMailer. Se dir for vazio, as
+// mensagens são escritas em out.
+func NewFileMailer(cfg config.EmailConfig, dir string, out io.Writer) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		out:  out,
+		from: formatAddress(cfg.FromName, cfg.FromEmail),
+	}
+}
+
+// Send grava a mensagem
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	body, err := buildMIME(m.from, msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if m.dir == "" {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		_, err := fmt.Fprintf(m.out, "%s\r\n\r\n", body)
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.New())
+	if err := os.WriteFile(filepath.Join(m.dir, name), body, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	return nil
+}
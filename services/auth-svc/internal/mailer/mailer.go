@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// Message representa um email pronto para envio
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer interface para os backends de envio de email
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New cria o Mailer configurado em EmailConfig.Provider
+func New(cfg config.EmailConfig) (Mailer, error) {
+	switch cfg.Provider {
+	case "sendgrid":
+		if cfg.SendGridKey == "" {
+			return nil, fmt.Errorf("SENDGRID_API_KEY is required for the sendgrid email provider")
+		}
+		return NewSendGridMailer(cfg), nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp email provider")
+		}
+		return NewSMTPMailer(cfg), nil
+	case "file":
+		if cfg.OutboxDir == "" {
+			return nil, fmt.Errorf("EMAIL_OUTBOX_DIR is required for the file email provider")
+		}
+		return NewFileMailer(cfg, cfg.OutboxDir, nil), nil
+	case "stdout":
+		return NewFileMailer(cfg, "", os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown email provider: %s", cfg.Provider)
+	}
+}
+
+// buildMIME monta a mensagem multipart/alternative (texto + HTML)
+func buildMIME(from string, msg *Message) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+	for _, p := range parts {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@pagemagic.io>\r\n", uuid.New())
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// formatAddress formata o remetente no padrão "Nome <email>"
+func formatAddress(name, email string) string {
+	if name == "" {
+		return email
+	}
+	return fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", name), email)
+}
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/config"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// SendGridMailer envia emails pela API v3 do SendGrid
+type SendGridMailer struct {
+	client *sendgrid.Client
+	from   *mail.Email
+}
+
+// NewSendGridMailer cria uma nova instância do SendGridMailer
+func NewSendGridMailer(cfg config.EmailConfig) *SendGridMailer {
+	return &SendGridMailer{
+		client: sendgrid.NewSendClient(cfg.SendGridKey),
+		from:   mail.NewEmail(cfg.FromName, cfg.FromEmail),
+	}
+}
+
+// Send envia a mensagem
+func (m *SendGridMailer) Send(ctx context.Context, msg *Message) error {
+	email := mail.NewSingleEmail(m.from, msg.Subject, mail.NewEmail("", msg.To), msg.Text, msg.HTML)
+
+	resp, err := m.client.SendWithContext(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to send email via sendgrid: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid returned status %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+
+	"pagemagic/auth-svc/internal/config"
+)
+
+// SMTPMailer envia emails por um servidor SMTP
+type SMTPMailer struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	fromName string
+}
+
+// NewSMTPMailer cria uma nova instância do SMTPMailer
+func NewSMTPMailer(cfg config.EmailConfig) *SMTPMailer {
+	var auth smtp.Auth
+	if cfg.SMTPUser != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPHost)
+	}
+
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		auth:     auth,
+		from:     cfg.FromEmail,
+		fromName: cfg.FromName,
+	}
+}
+
+// Send envia a mensagem
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := buildMIME(formatAddress(m.fromName, m.from), msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, body); err != nil {
+		return fmt.Errorf("failed to send email via smtp: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale idioma usado quando não há template para o locale do usuário
+const DefaultLocale = "en"
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Cada arquivo templates/<nome>.<locale>.tmpl define os blocos "subject",
+// "text" e "html". O mesmo arquivo é interpretado por text/template e
+// html/template para que o HTML seja escapado corretamente.
+var textTemplates, htmlTemplates = mustParseTemplates()
+
+func mustParseTemplates() (map[string]*texttemplate.Template, map[string]*htmltemplate.Template) {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(fmt.Sprintf("mailer: failed to read templates: %v", err))
+	}
+
+	texts := make(map[string]*texttemplate.Template)
+	htmls := make(map[string]*htmltemplate.Template)
+	for _, f := range files {
+		key := strings.TrimSuffix(f.Name(), ".tmpl")
+		file := path.Join("templates", f.Name())
+		texts[key] = texttemplate.Must(texttemplate.ParseFS(templateFS, file))
+		htmls[key] = htmltemplate.Must(htmltemplate.ParseFS(templateFS, file))
+	}
+
+	return texts, htmls
+}
+
+// Render renderiza o template name no idioma mais próximo de locale
+// (ex: "pt-BR" → "pt-BR", "pt", DefaultLocale). O destinatário fica a cargo
+// de quem chama.
+func Render(name, locale string, data interface{}) (*Message, error) {
+	key, ok := resolveTemplate(name, locale)
+	if !ok {
+		return nil, fmt.Errorf("email template not found: %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTemplates[key].ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render email subject: %w", err)
+	}
+	if err := textTemplates[key].ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render email text: %w", err)
+	}
+	if err := htmlTemplates[key].ExecuteTemplate(&html, "html", data); err != nil {
+		return nil, fmt.Errorf("failed to render email html: %w", err)
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// resolveTemplate encontra a chave do template para o locale solicitado
+func resolveTemplate(name, locale string) (string, bool) {
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, DefaultLocale)
+
+	for _, l := range candidates {
+		key := name + "." + strings.ToLower(l)
+		if _, ok := textTemplates[key]; ok {
+			return key, true
+		}
+	}
+	return "", false
+}
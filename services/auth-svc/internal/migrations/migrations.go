@@ -0,0 +1,166 @@
+// Package migrations schema do auth-svc em migrações SQL versionadas,
+// embutidas no binário e aplicadas com golang-migrate
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"pagemagic/auth-svc/internal/pglock"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// migrationsTable tabela de controle própria, para não colidir com outros
+// serviços que usem golang-migrate no mesmo banco
+const migrationsTable = "auth_schema_migrations"
+
+// advisoryLockKey serializa as migrações entre réplicas que sobem juntas.
+// O golang-migrate tem lock próprio, mas desiste após 15s; este espera o
+// tempo que a migração levar.
+const advisoryLockKey int64 = 0x6175746873766301 // "authsvc" + 1
+
+// Migration arquivo de migração e se ele já foi aplicado
+type Migration struct {
+	Version    uint
+	Identifier string
+	Applied    bool
+}
+
+// Status versão atual do banco e migrações embutidas no binário. Dirty
+// indica uma migração que falhou no meio e precisa de correção manual.
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Up aplica todas as migrações pendentes sob o advisory lock
+func Up(ctx context.Context, databaseURL string) error {
+	return run(ctx, databaseURL, func(m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+// Down desfaz as últimas steps migrações aplicadas
+func Down(ctx context.Context, databaseURL string, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("invalid number of steps: %d", steps)
+	}
+
+	return run(ctx, databaseURL, func(m *migrate.Migrate) error {
+		if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to revert migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetStatus retorna a versão atual e a lista de migrações embutidas
+func GetStatus(ctx context.Context, databaseURL string) (*Status, error) {
+	status := &Status{}
+
+	err := run(ctx, databaseURL, func(m *migrate.Migrate) error {
+		version, dirty, err := m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return fmt.Errorf("failed to get schema version: %w", err)
+		}
+		status.Version = version
+		status.Dirty = dirty
+
+		status.Migrations, err = list(version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// list percorre as migrações embutidas em ordem de versão
+func list(current uint) ([]Migration, error) {
+	source, err := iofs.New(files, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	defer source.Close()
+
+	var migrations []Migration
+	version, err := source.First()
+	for err == nil {
+		r, identifier, readErr := source.ReadUp(version)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read migration %d: %w", version, readErr)
+		}
+		r.Close()
+
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Identifier: identifier,
+			Applied:    version <= current,
+		})
+
+		version, err = source.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	return migrations, nil
+}
+
+// run obtém o advisory lock e executa fn com as migrações embutidas. O
+// banco é aberto aqui e não reaproveita o pool da aplicação.
+func run(ctx context.Context, databaseURL string, fn func(m *migrate.Migrate) error) error {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	lock, err := pglock.Acquire(ctx, db, advisoryLockKey)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(context.Background())
+
+	source, err := iofs.New(files, "sql")
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	// Conexão separada da que detém o lock; m.Close a devolve ao pool
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{MigrationsTable: migrationsTable})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to initialize migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+	defer m.Close()
+
+	return fn(m)
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS magic_links;
+DROP TABLE IF EXISTS user_auth_providers;
+DROP TABLE IF EXISTS users;
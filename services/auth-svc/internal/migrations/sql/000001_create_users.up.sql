@@ -0,0 +1,75 @@
+-- Usuários e identidades de login
+CREATE TABLE users (
+    id                         UUID PRIMARY KEY,
+    email                      VARCHAR(255) NOT NULL,
+    email_verified             BOOLEAN NOT NULL DEFAULT FALSE,
+    password_hash              TEXT,
+    first_name                 VARCHAR(255),
+    last_name                  VARCHAR(255),
+    avatar_url                 TEXT,
+    status                     VARCHAR(20) NOT NULL DEFAULT 'active'
+                               CHECK (status IN ('active', 'inactive', 'suspended', 'deleted')),
+    locale                     VARCHAR(10) NOT NULL DEFAULT 'en',
+    timezone                   VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    mobile_verified            BOOLEAN NOT NULL DEFAULT FALSE,
+    mobile_number              VARCHAR(20),
+    push_notifications_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    failed_login_attempts      INTEGER NOT NULL DEFAULT 0,
+    locked_until               TIMESTAMPTZ,
+    two_factor_secret          TEXT,
+    two_factor_enabled         BOOLEAN NOT NULL DEFAULT FALSE,
+    sms_mfa_enabled            BOOLEAN NOT NULL DEFAULT FALSE,
+    last_login_at              TIMESTAMPTZ,
+    created_at                 TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                 TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+CREATE INDEX idx_users_created_at ON users (created_at DESC);
+
+CREATE TABLE user_auth_providers (
+    id               UUID PRIMARY KEY,
+    user_id          UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider         VARCHAR(20) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    provider_email   VARCHAR(255),
+    provider_data    JSONB,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_user_auth_providers_provider ON user_auth_providers (provider, provider_user_id);
+CREATE INDEX idx_user_auth_providers_user_id ON user_auth_providers (user_id);
+
+-- Magic links guardam o token do link e os hashes do código numérico e do
+-- nonce do dispositivo que fez o pedido
+CREATE TABLE magic_links (
+    id           UUID PRIMARY KEY,
+    email        VARCHAR(255) NOT NULL,
+    token        VARCHAR(255) NOT NULL,
+    code_hash    VARCHAR(64) NOT NULL,
+    device_nonce VARCHAR(64) NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    used         BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_magic_links_token ON magic_links (token);
+CREATE UNIQUE INDEX idx_magic_links_device_nonce ON magic_links (device_nonce);
+CREATE INDEX idx_magic_links_email ON magic_links (email);
+CREATE INDEX idx_magic_links_expires_at ON magic_links (expires_at);
+
+CREATE TABLE password_reset_tokens (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token      VARCHAR(64) NOT NULL,
+    used       BOOLEAN NOT NULL DEFAULT FALSE,
+    used_at    TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_password_reset_tokens_token ON password_reset_tokens (token);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE INDEX idx_password_reset_tokens_expires_at ON password_reset_tokens (expires_at);
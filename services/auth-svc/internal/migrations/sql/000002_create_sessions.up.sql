@@ -0,0 +1,49 @@
+-- Dispositivos, sessões de login e famílias de refresh tokens
+CREATE TABLE devices (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT NOT NULL DEFAULT '',
+    type         VARCHAR(20) NOT NULL DEFAULT '',
+    platform     VARCHAR(20) NOT NULL DEFAULT '',
+    fingerprint  VARCHAR(64) NOT NULL,
+    is_trusted   BOOLEAN NOT NULL DEFAULT FALSE,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_ip TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, fingerprint)
+);
+
+-- O ID da sessão é o mesmo da família de refresh tokens
+CREATE TABLE sessions (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    device_id     UUID REFERENCES devices (id) ON DELETE SET NULL,
+    active_org_id UUID,
+    ip_address    TEXT,
+    user_agent    TEXT,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions (user_id, last_seen_at DESC);
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
+
+CREATE TABLE refresh_tokens (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id  UUID NOT NULL,
+    token      VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used       BOOLEAN NOT NULL DEFAULT FALSE,
+    used_at    TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_refresh_tokens_token ON refresh_tokens (token);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS phone_verifications;
+DROP TABLE IF EXISTS webauthn_credentials;
+DROP TABLE IF EXISTS mfa_recovery_codes;
@@ -0,0 +1,46 @@
+-- Segundos fatores: códigos de recuperação, passkeys e códigos por SMS
+CREATE TABLE mfa_recovery_codes (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id, code_hash);
+
+CREATE TABLE webauthn_credentials (
+    id               UUID PRIMARY KEY,
+    user_id          UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name             VARCHAR(100) NOT NULL DEFAULT '',
+    credential_id    BYTEA NOT NULL,
+    public_key       BYTEA NOT NULL,
+    attestation_type VARCHAR(32) NOT NULL DEFAULT '',
+    transports       TEXT[] NOT NULL DEFAULT '{}',
+    aaguid           BYTEA,
+    sign_count       BIGINT NOT NULL DEFAULT 0,
+    backup_eligible  BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state     BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_webauthn_credentials_credential_id ON webauthn_credentials (credential_id);
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials (user_id);
+
+-- No máximo um código ativo por usuário e finalidade (verify, login)
+CREATE TABLE phone_verifications (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    phone_number VARCHAR(20) NOT NULL,
+    purpose      VARCHAR(20) NOT NULL,
+    code_hash    VARCHAR(64) NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    sends        INTEGER NOT NULL DEFAULT 1,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, purpose)
+);
+
+CREATE INDEX idx_phone_verifications_expires_at ON phone_verifications (expires_at);
@@ -0,0 +1,4 @@
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS fk_sessions_active_org;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
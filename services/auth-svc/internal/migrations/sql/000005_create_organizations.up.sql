@@ -0,0 +1,41 @@
+-- Organizações (workspaces), membros e convites por email
+CREATE TABLE organizations (
+    id         UUID PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organizations_created_by ON organizations (created_by);
+
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role            VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members (user_id);
+
+CREATE TABLE organization_invitations (
+    id              UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email           VARCHAR(255) NOT NULL,
+    role            VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+    token           VARCHAR(64) NOT NULL,
+    invited_by      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    accepted_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_organization_invitations_token ON organization_invitations (token);
+CREATE INDEX idx_organization_invitations_organization_id ON organization_invitations (organization_id, email);
+
+-- A organização ativa da sessão deixa de valer se a organização for removida
+ALTER TABLE sessions
+    ADD CONSTRAINT fk_sessions_active_org
+    FOREIGN KEY (active_org_id) REFERENCES organizations (id) ON DELETE SET NULL;
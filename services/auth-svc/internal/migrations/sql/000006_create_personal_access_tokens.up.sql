@@ -0,0 +1,17 @@
+-- Tokens de acesso pessoal; apenas o hash do segredo é armazenado
+CREATE TABLE personal_access_tokens (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(32) NOT NULL,
+    token_hash   VARCHAR(64) NOT NULL,
+    scopes       TEXT[] NOT NULL DEFAULT '{}',
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    last_used_ip TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_personal_access_tokens_token_hash ON personal_access_tokens (token_hash);
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens (user_id, created_at DESC);
@@ -0,0 +1,20 @@
+-- Log de auditoria append-only. Sem chave estrangeira para users: as
+-- entradas sobrevivem à exclusão da conta, apenas anonimizadas.
+CREATE TABLE auth_audit_logs (
+    id         UUID PRIMARY KEY,
+    user_id    UUID,
+    actor_id   UUID,
+    session_id UUID,
+    action     VARCHAR(64) NOT NULL,
+    result     VARCHAR(16) NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    metadata   JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Paginação por cursor (created_at, id) do mais recente para o mais antigo
+CREATE INDEX idx_auth_audit_logs_created_at ON auth_audit_logs (created_at DESC, id DESC);
+CREATE INDEX idx_auth_audit_logs_user_id ON auth_audit_logs (user_id, created_at DESC, id DESC);
+CREATE INDEX idx_auth_audit_logs_actor_id ON auth_audit_logs (actor_id, created_at DESC, id DESC);
+CREATE INDEX idx_auth_audit_logs_action ON auth_audit_logs (action, created_at DESC);
@@ -0,0 +1,14 @@
+-- Outbox transacional dos eventos de usuário publicados no NATS
+CREATE TABLE outbox_events (
+    id              UUID PRIMARY KEY,
+    subject         VARCHAR(255) NOT NULL,
+    payload         JSONB NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events (next_attempt_at, created_at) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,24 @@
+-- Exportação de dados e exclusão de conta (LGPD/GDPR)
+CREATE TABLE data_exports (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status       VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    archive      BYTEA,
+    error        TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at   TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports (user_id, created_at DESC);
+CREATE INDEX idx_data_exports_status ON data_exports (status, created_at);
+CREATE INDEX idx_data_exports_expires_at ON data_exports (expires_at);
+
+CREATE TABLE account_deletions (
+    user_id       UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    requested_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    scheduled_for TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_account_deletions_scheduled_for ON account_deletions (scheduled_for);
@@ -0,0 +1,14 @@
+-- Troca de email pendente; no máximo uma por usuário
+CREATE TABLE email_change_requests (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    new_email     VARCHAR(255) NOT NULL,
+    confirm_token VARCHAR(64) NOT NULL,
+    cancel_token  VARCHAR(64) NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_email_change_requests_confirm_token ON email_change_requests (confirm_token);
+CREATE UNIQUE INDEX idx_email_change_requests_cancel_token ON email_change_requests (cancel_token);
+CREATE INDEX idx_email_change_requests_expires_at ON email_change_requests (expires_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_status_created_at;
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+
+-- A extensão pg_trgm é mantida: outros esquemas do banco podem usá-la
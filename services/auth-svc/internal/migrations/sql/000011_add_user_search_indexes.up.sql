@@ -0,0 +1,9 @@
+-- Busca de usuários na administração por trecho do email ou do nome
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_users_email_trgm ON users USING gin (email gin_trgm_ops);
+CREATE INDEX idx_users_name_trgm ON users
+    USING gin ((COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) gin_trgm_ops);
+
+-- Listagem por status com paginação por cursor (created_at, id)
+CREATE INDEX idx_users_status_created_at ON users (status, created_at DESC, id DESC);
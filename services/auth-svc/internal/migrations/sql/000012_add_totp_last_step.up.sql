@@ -0,0 +1,3 @@
+-- Último período TOTP aceito; códigos do mesmo período ou de um anterior
+-- são recusados para que um código interceptado não possa ser reutilizado
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT;
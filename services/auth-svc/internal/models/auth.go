@@ -41,9 +41,15 @@ type User struct {
 	MobileVerified           bool       `json:"mobile_verified" db:"mobile_verified"`
 	MobileNumber             *string    `json:"mobile_number" db:"mobile_number"`
 	PushNotificationsEnabled bool       `json:"push_notifications_enabled" db:"push_notifications_enabled"`
-	LastLoginAt              *time.Time `json:"last_login_at" db:"last_login_at"`
-	CreatedAt                time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt                time.Time  `json:"updated_at" db:"updated_at"`
+	FailedLoginAttempts      int        `json:"-" db:"failed_login_attempts"`
+	LockedUntil              *time.Time `json:"-" db:"locked_until"`
+	TwoFactorSecret          *string    `json:"-" db:"two_factor_secret"`
+	TwoFactorEnabled         bool       `json:"two_factor_enabled" db:"two_factor_enabled"`
+	// SMSMFAEnabled aceita código por SMS no celular verificado como segundo fator
+	SMSMFAEnabled bool       `json:"sms_mfa_enabled" db:"sms_mfa_enabled"`
+	LastLoginAt   *time.Time `json:"last_login_at" db:"last_login_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // UserAuthProvider modelo de provedor de autenticação do usuário
@@ -58,24 +64,404 @@ type UserAuthProvider struct {
 	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
 }
 
-// MagicLink modelo de magic link
-type MagicLink struct {
+// WebAuthnCredential passkey/chave de segurança registrada pelo usuário.
+// Um usuário pode ter vários autenticadores.
+type WebAuthnCredential struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	Name            string     `json:"name" db:"name"`
+	CredentialID    []byte     `json:"-" db:"credential_id"`
+	PublicKey       []byte     `json:"-" db:"public_key"`
+	AttestationType string     `json:"attestation_type" db:"attestation_type"`
+	Transports      []string   `json:"transports" db:"transports"`
+	AAGUID          []byte     `json:"-" db:"aaguid"`
+	SignCount       uint32     `json:"-" db:"sign_count"`
+	BackupEligible  bool       `json:"backup_eligible" db:"backup_eligible"`
+	BackupState     bool       `json:"backup_state" db:"backup_state"`
+	LastUsedAt      *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Session sessão de login. O ID é o mesmo da família de refresh tokens e
+// vai no claim "sid" de todos os access tokens emitidos para a sessão.
+type Session struct {
+	ID       uuid.UUID  `json:"id" db:"id"`
+	UserID   uuid.UUID  `json:"user_id" db:"user_id"`
+	DeviceID *uuid.UUID `json:"device_id,omitempty" db:"device_id"`
+	// ActiveOrgID organização ativa, embutida nos access tokens da sessão
+	ActiveOrgID *uuid.UUID `json:"active_org_id,omitempty" db:"active_org_id"`
+	IPAddress   *string    `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent   *string    `json:"user_agent,omitempty" db:"user_agent"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Device dispositivo de onde o usuário já fez login, identificado por um
+// fingerprint (hash do ID enviado pelo cliente ou do user agent)
+type Device struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Type        string    `json:"type" db:"type"`         // web, mobile, desktop
+	Platform    string    `json:"platform" db:"platform"` // ios, android, windows, macos, linux
+	Fingerprint string    `json:"-" db:"fingerprint"`
+	IsTrusted   bool      `json:"is_trusted" db:"is_trusted"`
+	LastSeenAt  time.Time `json:"last_seen_at" db:"last_seen_at"`
+	LastSeenIP  *string   `json:"last_seen_ip,omitempty" db:"last_seen_ip"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Role papel atribuível a usuários; concede um conjunto de permissões
+type Role struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Permission permissão no formato recurso.ação (ex.: sites.update)
+type Permission struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Resource    string    `json:"resource" db:"resource"`
+	Action      string    `json:"action" db:"action"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// OrgRole papel do membro dentro de uma organização
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleEditor OrgRole = "editor"
+	OrgRoleViewer OrgRole = "viewer"
+)
+
+// orgRoleRank ordem de privilégio dos papéis de organização
+var orgRoleRank = map[OrgRole]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleAdmin:  3,
+	OrgRoleOwner:  4,
+}
+
+// IsValid verifica se o papel é conhecido
+func (r OrgRole) IsValid() bool {
+	return orgRoleRank[r] > 0
+}
+
+// AtLeast verifica se o papel tem pelo menos o privilégio de min
+func (r OrgRole) AtLeast(min OrgRole) bool {
+	return orgRoleRank[r] >= orgRoleRank[min]
+}
+
+// Organization organização (workspace) compartilhada entre usuários
+type Organization struct {
 	ID        uuid.UUID `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Token     string    `json:"token" db:"token"`
-	Used      bool      `json:"used" db:"used"`
-	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	Name      string    `json:"name" db:"name"`
+	CreatedBy uuid.UUID `json:"created_by" db:"created_by"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// OrganizationMember vínculo de um usuário com uma organização
+type OrganizationMember struct {
+	OrganizationID uuid.UUID `json:"organization_id" db:"organization_id"`
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`
+	Role           OrgRole   `json:"role" db:"role"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	// Email e Name são preenchidos nas listagens de membros
+	Email string  `json:"email" db:"-"`
+	Name  *string `json:"name,omitempty" db:"-"`
+}
+
+// OrganizationMembership organização acompanhada do papel do usuário nela
+type OrganizationMembership struct {
+	Organization
+	Role OrgRole `json:"role" db:"role"`
+}
+
+// OrganizationInvitation convite por email para uma organização. Token
+// guarda apenas o hash; o valor original segue no link do email.
+type OrganizationInvitation struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id" db:"organization_id"`
+	Email          string     `json:"email" db:"email"`
+	Role           OrgRole    `json:"role" db:"role"`
+	Token          string     `json:"-" db:"token"`
+	InvitedBy      uuid.UUID  `json:"invited_by" db:"invited_by"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsPending verifica se o convite ainda pode ser aceito
+func (i *OrganizationInvitation) IsPending() bool {
+	return i.AcceptedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// PersonalAccessToken token de longa duração para CI e scripts. Apenas o
+// hash do segredo é armazenado; Prefix identifica o token na interface.
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP *string    `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// IsActive verifica se o token não foi revogado nem expirou
+func (t *PersonalAccessToken) IsActive() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt))
+}
+
+// AuditAction evento registrado no log de auditoria
+type AuditAction string
+
+const (
+	AuditMagicLinkSent        AuditAction = "auth.magic_link.sent"
+	AuditMagicLinkVerified    AuditAction = "auth.magic_link.verified"
+	AuditRegister             AuditAction = "auth.register"
+	AuditLogin                AuditAction = "auth.login"
+	AuditOAuthLogin           AuditAction = "auth.oauth.login"
+	AuditPasskeyLogin         AuditAction = "auth.passkey.login"
+	AuditMFAVerified          AuditAction = "auth.mfa.verified"
+	AuditTokenRefreshed       AuditAction = "auth.token.refreshed"
+	AuditLogout               AuditAction = "auth.logout"
+	AuditLogoutAll            AuditAction = "auth.logout_all"
+	AuditSessionRevoked       AuditAction = "auth.session.revoked"
+	AuditPasswordChanged      AuditAction = "user.password.changed"
+	AuditPasswordReset        AuditAction = "user.password.reset"
+	AuditProfileUpdated       AuditAction = "user.profile.updated"
+	AuditProviderLinked       AuditAction = "user.provider.linked"
+	AuditProviderUnlinked     AuditAction = "user.provider.unlinked"
+	AuditTOTPEnabled          AuditAction = "user.mfa.totp_enabled"
+	AuditTOTPDisabled         AuditAction = "user.mfa.totp_disabled"
+	AuditPasskeyRegistered    AuditAction = "user.passkey.registered"
+	AuditPasskeyDeleted       AuditAction = "user.passkey.deleted"
+	AuditAccessTokenCreated   AuditAction = "user.access_token.created"
+	AuditAccessTokenRevoked   AuditAction = "user.access_token.revoked"
+	AuditRoleAssigned         AuditAction = "admin.role.assigned"
+	AuditRoleRemoved          AuditAction = "admin.role.removed"
+	AuditUserSuspended        AuditAction = "admin.user.suspended"
+	AuditUserReactivated      AuditAction = "admin.user.reactivated"
+	AuditDataExportRequested  AuditAction = "user.data_export.requested"
+	AuditDataExportDownloaded AuditAction = "user.data_export.downloaded"
+	AuditDeletionScheduled    AuditAction = "user.deletion.scheduled"
+	AuditDeletionCanceled     AuditAction = "user.deletion.canceled"
+	AuditAccountErased        AuditAction = "user.account.erased"
+	AuditEmailChangeRequested AuditAction = "user.email.change_requested"
+	AuditEmailChanged         AuditAction = "user.email.changed"
+	AuditEmailChangeCanceled  AuditAction = "user.email.change_canceled"
+	AuditMobileCodeSent       AuditAction = "user.mobile.code_sent"
+	AuditMobileVerified       AuditAction = "user.mobile.verified"
+	AuditMobileRemoved        AuditAction = "user.mobile.removed"
+	AuditSMSMFAEnabled        AuditAction = "user.mfa.sms_enabled"
+	AuditSMSMFADisabled       AuditAction = "user.mfa.sms_disabled"
+)
+
+// AuditResult resultado do evento auditado
+type AuditResult string
+
+const (
+	AuditResultSuccess AuditResult = "success"
+	AuditResultFailure AuditResult = "failure"
+)
+
+// AuditLog entrada do log de auditoria (somente inserção). UserID é o dono
+// da conta afetada e ActorID quem executou a ação; diferem em ações
+// administrativas. Em falhas antes da identificação do usuário ambos ficam
+// vazios e Metadata guarda o contexto disponível.
+type AuditLog struct {
+	ID        uuid.UUID              `json:"id" db:"id"`
+	UserID    *uuid.UUID             `json:"user_id,omitempty" db:"user_id"`
+	ActorID   *uuid.UUID             `json:"actor_id,omitempty" db:"actor_id"`
+	SessionID *uuid.UUID             `json:"session_id,omitempty" db:"session_id"`
+	Action    AuditAction            `json:"action" db:"action"`
+	Result    AuditResult            `json:"result" db:"result"`
+	IPAddress *string                `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent *string                `json:"user_agent,omitempty" db:"user_agent"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
+
+// AuditLogFilter filtros da consulta ao log de auditoria. A paginação é
+// por cursor (CreatedAt, ID) do último item da página anterior.
+type AuditLogFilter struct {
+	UserID    *uuid.UUID
+	ActorID   *uuid.UUID
+	Action    AuditAction
+	Result    AuditResult
+	IPAddress string
+	Since     *time.Time
+	Until     *time.Time
+	// BeforeTime e BeforeID retornam apenas entradas anteriores ao cursor
+	BeforeTime *time.Time
+	BeforeID   *uuid.UUID
+	Limit      int
+}
+
+// UserFilter filtros da busca de usuários na administração. Email e Name
+// buscam por trecho; sem Status, contas excluídas ficam de fora. A
+// paginação é por cursor (CreatedAt, ID) como em AuditLogFilter.
+type UserFilter struct {
+	Email      string
+	Name       string
+	Status     UserStatus
+	BeforeTime *time.Time
+	BeforeID   *uuid.UUID
+	Limit      int
+}
+
+// OutboxEvent evento de domínio gravado na mesma transação da mudança que o
+// originou; o relay publica no NATS e marca PublishedAt. Payload é o
+// envelope JSON completo (ver shared/schemas/user-events.v1.json).
+type OutboxEvent struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	Subject       string     `json:"subject" db:"subject"`
+	Payload       []byte     `json:"payload" db:"payload"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	PublishedAt   *time.Time `json:"published_at,omitempty" db:"published_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// DataExportStatus estado do job de exportação de dados
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+)
+
+// DataExport exportação dos dados da conta (portabilidade LGPD/GDPR). O
+// arquivo zip fica no banco até ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	UserID      uuid.UUID        `json:"user_id" db:"user_id"`
+	Status      DataExportStatus `json:"status" db:"status"`
+	Archive     []byte           `json:"-" db:"archive"`
+	Error       *string          `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	StartedAt   *time.Time       `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// AccountDeletion pedido de exclusão da conta; os dados são apagados em
+// ScheduledFor, ao fim do período de carência, se não for cancelado
+type AccountDeletion struct {
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	RequestedAt  time.Time `json:"requested_at" db:"requested_at"`
+	ScheduledFor time.Time `json:"scheduled_for" db:"scheduled_for"`
+}
+
+// MagicLink modelo de magic link. Junto com o link o email traz um código
+// numérico para o app móvel, aceito apenas com o nonce devolvido ao
+// dispositivo que fez o pedido; CodeHash e DeviceNonce guardam hashes.
+type MagicLink struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Email       string    `json:"email" db:"email"`
+	Token       string    `json:"token" db:"token"`
+	CodeHash    string    `json:"-" db:"code_hash"`
+	DeviceNonce string    `json:"-" db:"device_nonce"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	Used        bool      `json:"used" db:"used"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// PasswordResetToken token de redefinição de senha. Token guarda o hash
+// SHA-256 do valor enviado por email.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Token     string     `json:"-" db:"token"`
+	Used      bool       `json:"used" db:"used"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// EmailChangeRequest troca de email pendente. A troca só é aplicada com o
+// link enviado ao novo endereço (ConfirmToken); o link enviado ao endereço
+// atual (CancelToken) a cancela. Os tokens guardam apenas o hash SHA-256.
+type EmailChangeRequest struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	NewEmail     string    `json:"new_email" db:"new_email"`
+	ConfirmToken string    `json:"-" db:"confirm_token"`
+	CancelToken  string    `json:"-" db:"cancel_token"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
-// RefreshToken modelo de refresh token
+// PhoneVerificationPurpose finalidade do código enviado por SMS
+type PhoneVerificationPurpose string
+
+const (
+	// PhoneVerificationVerify confirma a posse de um novo número
+	PhoneVerificationVerify PhoneVerificationPurpose = "verify"
+	// PhoneVerificationLogin segundo fator de login
+	PhoneVerificationLogin PhoneVerificationPurpose = "login"
+)
+
+// PhoneVerification código de uso único enviado por SMS; há no máximo um
+// ativo por usuário e finalidade. CodeHash guarda o hash SHA-256 do código
+// e Sends conta os reenvios antes de ExpiresAt.
+type PhoneVerification struct {
+	ID          uuid.UUID                `json:"id" db:"id"`
+	UserID      uuid.UUID                `json:"user_id" db:"user_id"`
+	PhoneNumber string                   `json:"phone_number" db:"phone_number"`
+	Purpose     PhoneVerificationPurpose `json:"purpose" db:"purpose"`
+	CodeHash    string                   `json:"-" db:"code_hash"`
+	Attempts    int                      `json:"attempts" db:"attempts"`
+	Sends       int                      `json:"sends" db:"sends"`
+	ExpiresAt   time.Time                `json:"expires_at" db:"expires_at"`
+	LastSentAt  time.Time                `json:"last_sent_at" db:"last_sent_at"`
+	CreatedAt   time.Time                `json:"created_at" db:"created_at"`
+}
+
+// RecoveryCode código de recuperação de 2FA de uso único. CodeHash guarda
+// o hash SHA-256 do código normalizado.
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RefreshToken modelo de refresh token. Token guarda o hash SHA-256 do
+// token entregue ao cliente; todos os tokens emitidos a partir do mesmo
+// login compartilham o FamilyID.
 type RefreshToken struct {
 	ID        uuid.UUID  `json:"id" db:"id"`
 	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
-	Token     string     `json:"token" db:"token"`
+	FamilyID  uuid.UUID  `json:"family_id" db:"family_id"`
+	Token     string     `json:"-" db:"token"`
 	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
 	Used      bool       `json:"used" db:"used"`
 	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 }
 
@@ -117,13 +503,12 @@ type UpdateUserRequest struct {
 	AvatarURL                *string `json:"avatar_url"`
 	Locale                   *string `json:"locale"`
 	Timezone                 *string `json:"timezone"`
-	MobileNumber             *string `json:"mobile_number"`
 	PushNotificationsEnabled *bool   `json:"push_notifications_enabled"`
 }
 
 // ChangePasswordRequest request de mudança de senha
 type ChangePasswordRequest struct {
-	CurrentPassword string `json:"current_password" validate:"required"`
+	CurrentPassword string `json:"current_password"`
 	NewPassword     string `json:"new_password" validate:"required,min=8"`
 }
 
@@ -133,15 +518,43 @@ type AuthResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"`
+	// MFAToken é preenchido no lugar dos tokens quando o usuário tem 2FA
+	// ativo; deve ser trocado junto com um dos fatores em MFAMethods
+	MFAToken   string   `json:"mfa_token,omitempty"`
+	MFAMethods []string `json:"mfa_methods,omitempty"`
+}
+
+// IntrospectionResponse resposta da introspecção de token (RFC 7662). Para
+// tokens inválidos, expirados ou revogados apenas Active (false) é retornado.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	TokenType string   `json:"token_type,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	OrgID     string   `json:"org_id,omitempty"`
+	OrgRole   string   `json:"org_role,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	JTI       string   `json:"jti,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
 }
 
 // JWTClaims claims do JWT
 type JWTClaims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Exp    int64     `json:"exp"`
-	Iat    int64     `json:"iat"`
-	Type   string    `json:"type"` // "access" ou "refresh"
+	ID        string    `json:"jti"`
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"sid"`
+	Email     string    `json:"email"`
+	Exp       int64     `json:"exp"`
+	Iat       int64     `json:"iat"`
+	Type      string    `json:"type"` // "access", "refresh" ou "pat"
+	Roles     []string  `json:"roles,omitempty"`
+	Scopes    []string  `json:"scope,omitempty"` // permissões; no token, separadas por espaço
+	// OrgID e OrgRole organização ativa da sessão e papel do usuário nela
+	OrgID   *uuid.UUID `json:"org_id,omitempty"`
+	OrgRole OrgRole    `json:"org_role,omitempty"`
 }
 
 // OAuthUserInfo informações do usuário OAuth
@@ -195,6 +608,11 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive
 }
 
+// IsLocked verifica se a conta está bloqueada por tentativas de login falhas
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
 // IsExpired verifica se o magic link expirou
 func (m *MagicLink) IsExpired() bool {
 	return time.Now().After(m.ExpiresAt)
@@ -205,12 +623,32 @@ func (m *MagicLink) IsValid() bool {
 	return !m.Used && !m.IsExpired()
 }
 
+// IsExpired verifica se o token de redefinição expirou
+func (p *PasswordResetToken) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// IsValid verifica se o token de redefinição é válido (não usado e não expirado)
+func (p *PasswordResetToken) IsValid() bool {
+	return !p.Used && !p.IsExpired()
+}
+
+// IsActive verifica se a sessão não foi revogada nem expirou
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
 // IsExpired verifica se o refresh token expirou
 func (r *RefreshToken) IsExpired() bool {
 	return time.Now().After(r.ExpiresAt)
 }
 
-// IsValid verifica se o refresh token é válido (não usado e não expirado)
+// IsRevoked verifica se a família do refresh token foi revogada
+func (r *RefreshToken) IsRevoked() bool {
+	return r.RevokedAt != nil
+}
+
+// IsValid verifica se o refresh token é válido (não usado, não revogado e não expirado)
 func (r *RefreshToken) IsValid() bool {
-	return !r.Used && !r.IsExpired()
+	return !r.Used && !r.IsRevoked() && !r.IsExpired()
 }
@@ -0,0 +1,171 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// AppleProvider login com Apple (Sign in with Apple)
+type AppleProvider struct {
+	oauth      *oauth2.Config
+	issuer     string
+	teamID     string
+	keyID      string
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewAppleProvider cria uma nova instância do AppleProvider. Quando
+// ClientSecret não é informado, o client secret é gerado a partir da chave
+// privada (.p8) da conta de desenvolvedor.
+func NewAppleProvider(cfg config.AppleOAuthConfig) (*AppleProvider, error) {
+	p := &AppleProvider{
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{"name", "email"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   cfg.AuthURL,
+				TokenURL:  cfg.TokenURL,
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		},
+		issuer: cfg.Issuer,
+		teamID: cfg.TeamID,
+		keyID:  cfg.KeyID,
+	}
+
+	if cfg.ClientSecret == "" {
+		block, _ := pem.Decode([]byte(strings.ReplaceAll(cfg.PrivateKey, `\n`, "\n")))
+		if block == nil {
+			return nil, fmt.Errorf("APPLE_PRIVATE_KEY is not a valid PEM key")
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse apple private key: %w", err)
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("apple private key must be an ECDSA key")
+		}
+		p.privateKey = ecKey
+	}
+
+	return p, nil
+}
+
+func (p *AppleProvider) Name() models.AuthProvider {
+	return models.AuthProviderApple
+}
+
+func (p *AppleProvider) AuthCodeURL(state, verifier string) string {
+	// A Apple exige response_mode=form_post quando name/email são solicitados
+	return p.oauth.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("response_mode", "form_post"),
+	)
+}
+
+func (p *AppleProvider) Exchange(ctx context.Context, code, verifier string) (*models.OAuthUserInfo, error) {
+	cfg := *p.oauth
+	if p.privateKey != nil {
+		secret, err := p.clientSecret()
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientSecret = secret
+	}
+
+	token, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return nil, fmt.Errorf("apple token response without id_token")
+	}
+
+	// O id_token foi recebido diretamente do token endpoint via TLS com
+	// autenticação do cliente, então a validação TLS substitui a verificação
+	// da assinatura (OpenID Connect Core 1.0, seção 3.1.3.7). Issuer,
+	// audience e expiração continuam sendo verificados.
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+		return nil, fmt.Errorf("invalid apple id_token: %w", err)
+	}
+
+	if iss, _ := claims.GetIssuer(); iss != p.issuer {
+		return nil, fmt.Errorf("unexpected apple id_token issuer: %s", iss)
+	}
+	aud, _ := claims.GetAudience()
+	if !containsString(aud, p.oauth.ClientID) {
+		return nil, fmt.Errorf("apple id_token audience mismatch")
+	}
+	exp, _ := claims.GetExpirationTime()
+	if exp == nil || exp.Before(time.Now()) {
+		return nil, fmt.Errorf("apple id_token expired")
+	}
+
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return nil, fmt.Errorf("apple id_token without subject")
+	}
+
+	email, _ := claims["email"].(string)
+
+	// email_verified pode vir como booleano ou como string
+	var emailVerified bool
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		emailVerified = v
+	case string:
+		emailVerified = v == "true"
+	}
+
+	return &models.OAuthUserInfo{
+		ID:            sub,
+		Email:         email,
+		EmailVerified: emailVerified,
+		Provider:      string(models.AuthProviderApple),
+	}, nil
+}
+
+// clientSecret gera o client secret JWT (ES256) exigido pela Apple
+func (p *AppleProvider) clientSecret() (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    p.teamID,
+		Subject:   p.oauth.ClientID,
+		Audience:  jwt.ClaimStrings{p.issuer},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+	})
+	token.Header["kid"] = p.keyID
+
+	secret, err := token.SignedString(p.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign apple client secret: %w", err)
+	}
+	return secret, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FakeProfile conta do usuário no FakeIdP. Para o GitHub, ID precisa ser
+// numérico.
+type FakeProfile struct {
+	ID            string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+// fakeGrant código de autorização emitido pelo FakeIdP
+type fakeGrant struct {
+	clientID  string
+	challenge string
+	profile   FakeProfile
+}
+
+// FakeIdP provedor OAuth 2.0 local, para testes e desenvolvimento. Atende
+// os endpoints de Google, GitHub e Apple num único servidor: códigos de uso
+// único, PKCE (S256) obrigatório e id_token no formato da Apple.
+type FakeIdP struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant
+	tokens map[string]FakeProfile
+}
+
+// NewFakeIdP inicia o servidor do FakeIdP; Close deve ser chamado ao final
+func NewFakeIdP() *FakeIdP {
+	f := &FakeIdP{
+		grants: make(map[string]fakeGrant),
+		tokens: make(map[string]FakeProfile),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", f.handleToken)
+	mux.HandleFunc("/userinfo", f.handleGoogleUserInfo)
+	mux.HandleFunc("/user", f.handleGitHubUser)
+	mux.HandleFunc("/user/emails", f.handleGitHubEmails)
+	f.server = httptest.NewServer(mux)
+
+	return f
+}
+
+// Close encerra o servidor
+func (f *FakeIdP) Close() {
+	f.server.Close()
+}
+
+// URL endereço base do servidor, também usado como issuer do id_token
+func (f *FakeIdP) URL() string {
+	return f.server.URL
+}
+
+func (f *FakeIdP) GoogleConfig() config.GoogleOAuthConfig {
+	return config.GoogleOAuthConfig{
+		ClientID:     "google-client",
+		ClientSecret: "google-secret",
+		RedirectURL:  "http://localhost/api/v1/auth/oauth/google/callback",
+		AuthURL:      f.URL() + "/authorize",
+		TokenURL:     f.URL() + "/token",
+		UserInfoURL:  f.URL() + "/userinfo",
+	}
+}
+
+func (f *FakeIdP) GitHubConfig() config.GitHubOAuthConfig {
+	return config.GitHubOAuthConfig{
+		ClientID:     "github-client",
+		ClientSecret: "github-secret",
+		RedirectURL:  "http://localhost/api/v1/auth/oauth/github/callback",
+		AuthURL:      f.URL() + "/authorize",
+		TokenURL:     f.URL() + "/token",
+		UserInfoURL:  f.URL() + "/user",
+		EmailsURL:    f.URL() + "/user/emails",
+	}
+}
+
+// AppleConfig usa um client secret fixo no lugar da chave .p8
+func (f *FakeIdP) AppleConfig() config.AppleOAuthConfig {
+	return config.AppleOAuthConfig{
+		ClientID:     "apple-client",
+		ClientSecret: "apple-secret",
+		RedirectURL:  "http://localhost/api/v1/auth/oauth/apple/callback",
+		AuthURL:      f.URL() + "/authorize",
+		TokenURL:     f.URL() + "/token",
+		Issuer:       f.URL(),
+	}
+}
+
+// Authorize simula o consentimento do usuário na URL de autorização e
+// retorna o state recebido e o código que o provedor enviaria ao callback
+func (f *FakeIdP) Authorize(authURL string, profile FakeProfile) (state, code string, err error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.HasPrefix(authURL, f.URL()+"/authorize") {
+		return "", "", fmt.Errorf("authorization URL not served by the fake idp: %s", authURL)
+	}
+
+	query := parsed.Query()
+	if query.Get("response_type") != "code" {
+		return "", "", fmt.Errorf("unsupported response_type: %s", query.Get("response_type"))
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		return "", "", fmt.Errorf("missing S256 code challenge")
+	}
+
+	code, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.grants[code] = fakeGrant{
+		clientID:  query.Get("client_id"),
+		challenge: query.Get("code_challenge"),
+		profile:   profile,
+	}
+	return query.Get("state"), code, nil
+}
+
+func (f *FakeIdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+
+	// O código é de uso único, mesmo quando a troca falha
+	f.mu.Lock()
+	grant, found := f.grants[r.PostForm.Get("code")]
+	delete(f.grants, r.PostForm.Get("code"))
+	f.mu.Unlock()
+
+	if !found || r.PostForm.Get("grant_type") != "authorization_code" || grant.clientID != clientID {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifierHash[:]) != grant.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	accessToken, err := randomToken()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// A assinatura não é verificada pelo AppleProvider (o id_token vem direto
+	// do token endpoint), então basta uma chave qualquer
+	idToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":            f.URL(),
+		"aud":            clientID,
+		"sub":            grant.profile.ID,
+		"email":          grant.profile.Email,
+		"email_verified": strconv.FormatBool(grant.profile.EmailVerified),
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(10 * time.Minute).Unix(),
+	}).SignedString([]byte(accessToken))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	f.mu.Lock()
+	f.tokens[accessToken] = grant.profile
+	f.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (f *FakeIdP) handleGoogleUserInfo(w http.ResponseWriter, r *http.Request) {
+	profile, ok := f.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            profile.ID,
+		"email":          profile.Email,
+		"email_verified": profile.EmailVerified,
+		"given_name":     profile.FirstName,
+		"family_name":    profile.LastName,
+	})
+}
+
+func (f *FakeIdP) handleGitHubUser(w http.ResponseWriter, r *http.Request) {
+	profile, ok := f.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(profile.ID, 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":    id,
+		"login": "user" + profile.ID,
+		"name":  strings.TrimSpace(profile.FirstName + " " + profile.LastName),
+	})
+}
+
+// handleGitHubEmails lista o email do perfil como primário, depois de um
+// secundário verificado, como faz o GitHub para contas com vários emails
+func (f *FakeIdP) handleGitHubEmails(w http.ResponseWriter, r *http.Request) {
+	profile, ok := f.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, []map[string]interface{}{
+		{"email": "secondary-" + profile.ID + "@users.noreply.github.com", "primary": false, "verified": true},
+		{"email": profile.Email, "primary": true, "verified": profile.EmailVerified},
+	})
+}
+
+func (f *FakeIdP) authenticate(w http.ResponseWriter, r *http.Request) (FakeProfile, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	f.mu.Lock()
+	profile, ok := f.tokens[token]
+	f.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	return profile, ok
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+
+	"golang.org/x/oauth2"
+)
+
+// GitHubProvider login com GitHub (OAuth 2.0)
+type GitHubProvider struct {
+	oauth       *oauth2.Config
+	userInfoURL string
+	emailsURL   string
+}
+
+// NewGitHubProvider cria uma nova instância do GitHubProvider
+func NewGitHubProvider(cfg config.GitHubOAuthConfig) *GitHubProvider {
+	return &GitHubProvider{
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{"read:user", "user:email"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.AuthURL,
+				TokenURL: cfg.TokenURL,
+			},
+		},
+		userInfoURL: cfg.UserInfoURL,
+		emailsURL:   cfg.EmailsURL,
+	}
+}
+
+func (p *GitHubProvider) Name() models.AuthProvider {
+	return models.AuthProviderGitHub
+}
+
+func (p *GitHubProvider) AuthCodeURL(state, verifier string) string {
+	return p.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code, verifier string) (*models.OAuthUserInfo, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	client := p.oauth.Client(ctx, token)
+
+	var profile struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, client, p.userInfoURL, &profile); err != nil {
+		return nil, err
+	}
+	if profile.ID == 0 {
+		return nil, fmt.Errorf("github profile without id")
+	}
+
+	// O email do perfil pode ser privado; usar o email primário da lista
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, p.emailsURL, &emails); err != nil {
+		return nil, err
+	}
+
+	info := &models.OAuthUserInfo{
+		ID:        strconv.FormatInt(profile.ID, 10),
+		AvatarURL: optionalString(profile.AvatarURL),
+		Provider:  string(models.AuthProviderGitHub),
+	}
+	for _, e := range emails {
+		if e.Primary {
+			info.Email = e.Email
+			info.EmailVerified = e.Verified
+			break
+		}
+	}
+
+	name := profile.Name
+	if name == "" {
+		name = profile.Login
+	}
+	info.FirstName, info.LastName = splitName(name)
+
+	return info, nil
+}
@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+
+	"golang.org/x/oauth2"
+)
+
+// GoogleProvider login com Google (OpenID Connect)
+type GoogleProvider struct {
+	oauth       *oauth2.Config
+	userInfoURL string
+}
+
+// NewGoogleProvider cria uma nova instância do GoogleProvider
+func NewGoogleProvider(cfg config.GoogleOAuthConfig) *GoogleProvider {
+	return &GoogleProvider{
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{"openid", "email", "profile"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.AuthURL,
+				TokenURL: cfg.TokenURL,
+			},
+		},
+		userInfoURL: cfg.UserInfoURL,
+	}
+}
+
+func (p *GoogleProvider) Name() models.AuthProvider {
+	return models.AuthProviderGoogle
+}
+
+func (p *GoogleProvider) AuthCodeURL(state, verifier string) string {
+	return p.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *GoogleProvider) Exchange(ctx context.Context, code, verifier string) (*models.OAuthUserInfo, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	var profile struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+		Picture       string `json:"picture"`
+	}
+	if err := getJSON(ctx, p.oauth.Client(ctx, token), p.userInfoURL, &profile); err != nil {
+		return nil, err
+	}
+	if profile.Sub == "" {
+		return nil, fmt.Errorf("google profile without subject")
+	}
+
+	return &models.OAuthUserInfo{
+		ID:            profile.Sub,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+		FirstName:     optionalString(profile.GivenName),
+		LastName:      optionalString(profile.FamilyName),
+		AvatarURL:     optionalString(profile.Picture),
+		Provider:      string(models.AuthProviderGoogle),
+	}, nil
+}
@@ -0,0 +1,121 @@
+package oauth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newFakeIdP(t *testing.T) *FakeIdP {
+	t.Helper()
+
+	idp := NewFakeIdP()
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func fakeProviders(t *testing.T, idp *FakeIdP) []Provider {
+	t.Helper()
+
+	apple, err := NewAppleProvider(idp.AppleConfig())
+	require.NoError(t, err)
+
+	return []Provider{
+		NewGoogleProvider(idp.GoogleConfig()),
+		NewGitHubProvider(idp.GitHubConfig()),
+		apple,
+	}
+}
+
+// authorize percorre a autorização e retorna o código e o verifier usados
+func authorize(t *testing.T, idp *FakeIdP, provider Provider, profile FakeProfile) (string, string) {
+	t.Helper()
+
+	verifier := oauth2.GenerateVerifier()
+	state, code, err := idp.Authorize(provider.AuthCodeURL("state-1", verifier), profile)
+	require.NoError(t, err)
+	require.Equal(t, "state-1", state)
+	return code, verifier
+}
+
+func TestExchangeMapsProfile(t *testing.T) {
+	idp := newFakeIdP(t)
+	profile := FakeProfile{ID: "1234", Email: "ana@example.com", EmailVerified: true, FirstName: "Ana", LastName: "Souza"}
+
+	for _, provider := range fakeProviders(t, idp) {
+		t.Run(string(provider.Name()), func(t *testing.T) {
+			code, verifier := authorize(t, idp, provider, profile)
+
+			info, err := provider.Exchange(context.Background(), code, verifier)
+			require.NoError(t, err)
+			assert.Equal(t, "1234", info.ID)
+			assert.Equal(t, "ana@example.com", info.Email)
+			assert.True(t, info.EmailVerified)
+			assert.Equal(t, string(provider.Name()), info.Provider)
+		})
+	}
+}
+
+func TestExchangeReportsUnverifiedEmail(t *testing.T) {
+	idp := newFakeIdP(t)
+	profile := FakeProfile{ID: "1234", Email: "ana@example.com"}
+
+	for _, provider := range fakeProviders(t, idp) {
+		t.Run(string(provider.Name()), func(t *testing.T) {
+			code, verifier := authorize(t, idp, provider, profile)
+
+			info, err := provider.Exchange(context.Background(), code, verifier)
+			require.NoError(t, err)
+			assert.False(t, info.EmailVerified)
+		})
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndReusedCode(t *testing.T) {
+	idp := newFakeIdP(t)
+	profile := FakeProfile{ID: "1234", Email: "ana@example.com", EmailVerified: true}
+
+	for _, provider := range fakeProviders(t, idp) {
+		t.Run(string(provider.Name()), func(t *testing.T) {
+			code, verifier := authorize(t, idp, provider, profile)
+
+			_, err := provider.Exchange(context.Background(), code, oauth2.GenerateVerifier())
+			assert.Error(t, err)
+
+			// O código é consumido mesmo na troca que falhou
+			_, err = provider.Exchange(context.Background(), code, verifier)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGitHubUsesPrimaryEmail(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := NewGitHubProvider(idp.GitHubConfig())
+	code, verifier := authorize(t, idp, provider, FakeProfile{ID: "42", Email: "ana@example.com", FirstName: "Ana", LastName: "Maria Souza"})
+
+	info, err := provider.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+	assert.Equal(t, "ana@example.com", info.Email)
+	assert.False(t, info.EmailVerified)
+	require.NotNil(t, info.FirstName)
+	require.NotNil(t, info.LastName)
+	assert.Equal(t, "Ana", *info.FirstName)
+	assert.Equal(t, "Maria Souza", *info.LastName)
+}
+
+func TestAppleRejectsUnexpectedIssuer(t *testing.T) {
+	idp := newFakeIdP(t)
+	cfg := idp.AppleConfig()
+	cfg.Issuer = "https://appleid.apple.com"
+	provider, err := NewAppleProvider(cfg)
+	require.NoError(t, err)
+
+	code, verifier := authorize(t, idp, provider, FakeProfile{ID: "001.abc", Email: "ana@example.com", EmailVerified: true})
+
+	_, err = provider.Exchange(context.Background(), code, verifier)
+	assert.ErrorContains(t, err, "issuer")
+}
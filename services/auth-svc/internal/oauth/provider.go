@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+)
+
+// Provider provedor de login social (OAuth 2.0 / OpenID Connect)
+type Provider interface {
+	Name() models.AuthProvider
+	// AuthCodeURL retorna a URL de autorização com state e o desafio PKCE (S256)
+	AuthCodeURL(state, verifier string) string
+	// Exchange troca o código de autorização pelo perfil do usuário
+	Exchange(ctx context.Context, code, verifier string) (*models.OAuthUserInfo, error)
+}
+
+// NewProviders cria os provedores que possuem ClientID configurado
+func NewProviders(cfg config.OAuthConfig) (map[models.AuthProvider]Provider, error) {
+	providers := make(map[models.AuthProvider]Provider)
+
+	if cfg.Google.ClientID != "" {
+		providers[models.AuthProviderGoogle] = NewGoogleProvider(cfg.Google)
+	}
+	if cfg.GitHub.ClientID != "" {
+		providers[models.AuthProviderGitHub] = NewGitHubProvider(cfg.GitHub)
+	}
+	if cfg.Apple.ClientID != "" {
+		apple, err := NewAppleProvider(cfg.Apple)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure apple provider: %w", err)
+		}
+		providers[models.AuthProviderApple] = apple
+	}
+
+	return providers, nil
+}
+
+// getJSON faz um GET autenticado e decodifica a resposta JSON
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// splitName divide um nome completo em primeiro nome e sobrenome
+func splitName(name string) (*string, *string) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	first := fields[0]
+	if len(fields) == 1 {
+		return &first, nil
+	}
+	last := strings.Join(fields[1:], " ")
+	return &first, &last
+}
+
+// optionalString retorna nil para strings vazias
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
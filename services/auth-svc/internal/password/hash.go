@@ -0,0 +1,105 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parâmetros do argon2id (RFC 9106 / recomendações OWASP)
+const (
+	argonMemory      = 64 * 1024 // KiB
+	argonIterations  = 3
+	argonParallelism = 2
+	argonSaltLength  = 16
+	argonKeyLength   = 32
+)
+
+// ErrUnsupportedHash formato de hash desconhecido
+var ErrUnsupportedHash = errors.New("unsupported password hash format")
+
+// dummyHash é usado para igualar o tempo de resposta quando o usuário não existe
+var dummyHash, _ = Hash("pagemagic-timing-equalizer")
+
+// Hash gera o hash argon2id no formato PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func Hash(password string) (string, error) {
+	salt := make([]byte, argonSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonIterations, argonMemory, argonParallelism, argonKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonIterations, argonParallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify compara a senha com o hash armazenado (argon2id ou bcrypt legado).
+// needsRehash indica que o hash deve ser regravado com os parâmetros atuais.
+func Verify(password, encoded string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	default:
+		return false, false, ErrUnsupportedHash
+	}
+}
+
+// VerifyDummy executa uma verificação descartável com o mesmo custo de Verify
+func VerifyDummy(password string) {
+	_, _, _ = Verify(password, dummyHash)
+}
+
+func verifyArgon2id(password, encoded string) (bool, bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnsupportedHash
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash := memory != argonMemory || iterations != argonIterations || parallelism != argonParallelism
+	return true, needsRehash, nil
+}
@@ -0,0 +1,72 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MinLength = 8
+	MaxLength = 128
+)
+
+var (
+	ErrTooShort     = errors.New("password must be at least 8 characters")
+	ErrTooLong      = errors.New("password must be at most 128 characters")
+	ErrTooCommon    = errors.New("password is too common or appeared in a data breach")
+	ErrContainsUser = errors.New("password must not contain your email address")
+)
+
+// Lista local de senhas vazadas/mais comuns, uma por linha, em minúsculas
+//
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+func loadCommonPasswords(data string) map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
+
+// Check valida a senha contra a política: tamanho, lista de senhas
+// comuns/vazadas e ausência do email do usuário
+func Check(password, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < MinLength {
+		return ErrTooShort
+	}
+	if length > MaxLength {
+		return ErrTooLong
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		return ErrTooCommon
+	}
+
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) >= 4 && strings.Contains(lower, local) {
+		return ErrContainsUser
+	}
+
+	return nil
+}
+
+// IsPolicyError verifica se o erro é uma violação da política de senha
+func IsPolicyError(err error) bool {
+	return errors.Is(err, ErrTooShort) ||
+		errors.Is(err, ErrTooLong) ||
+		errors.Is(err, ErrTooCommon) ||
+		errors.Is(err, ErrContainsUser)
+}
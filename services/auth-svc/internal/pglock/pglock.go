@@ -0,0 +1,73 @@
+// Package pglock advisory locks de sessão do Postgres, usados para
+// coordenar réplicas do serviço
+package pglock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Lock advisory lock mantido numa conexão dedicada; o Postgres o libera
+// sozinho se a conexão cair
+type Lock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Acquire espera até obter o lock ou ctx ser cancelado
+func Acquire(ctx context.Context, db *sql.DB, key int64) (*Lock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+
+	return &Lock{conn: conn, key: key}, nil
+}
+
+// TryAcquire tenta obter o lock sem esperar; retorna nil se outra sessão
+// já o detém
+func TryAcquire(ctx context.Context, db *sql.DB, key int64) (*Lock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &Lock{conn: conn, key: key}, nil
+}
+
+// Alive confirma que a conexão que detém o lock continua aberta. Se ela
+// caiu, o Postgres já liberou o lock e outra réplica pode tê-lo obtido.
+func (l *Lock) Alive(ctx context.Context) error {
+	if err := l.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("advisory lock connection lost: %w", err)
+	}
+
+	return nil
+}
+
+// Release libera o lock e devolve a conexão ao pool
+func (l *Lock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+
+	if _, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package ratelimit implementa janelas deslizantes e cool-downs exponenciais
+// sobre Redis, compartilhados entre todas as instâncias do serviço.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// hitScript registra um evento na janela (sorted set com timestamps em ms)
+// e retorna a contagem atual e o timestamp do evento mais antigo
+var hitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+redis.call('ZADD', KEYS[1], now, ARGV[3])
+redis.call('PEXPIRE', KEYS[1], window)
+local count = redis.call('ZCARD', KEYS[1])
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {count, tonumber(oldest[2])}
+`)
+
+// Limiter contador de janela deslizante e cool-down por chave
+type Limiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// New cria um limiter; prefix isola as chaves no Redis
+func New(client *redis.Client, prefix string) *Limiter {
+	return &Limiter{client: client, prefix: prefix}
+}
+
+// Hit registra um evento na janela da chave e retorna quantos eventos
+// ocorreram na janela (incluindo este) e quando o mais antigo sai dela
+func (l *Limiter) Hit(ctx context.Context, key string, window time.Duration) (int, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	member := strconv.FormatInt(now, 10) + "-" + strconv.FormatInt(rand.Int63(), 36)
+
+	result, err := hitScript.Run(ctx, l.client, []string{l.prefix + "window:" + key},
+		now, window.Milliseconds(), member,
+	).Int64Slice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to record rate limit hit: %w", err)
+	}
+
+	count, oldest := int(result[0]), result[1]
+	resetIn := time.Duration(oldest+window.Milliseconds()-now) * time.Millisecond
+	if resetIn < 0 {
+		resetIn = 0
+	}
+
+	return count, resetIn, nil
+}
+
+// Cooldown retorna quanto falta para o cool-down da chave terminar (zero se
+// não houver cool-down ativo)
+func (l *Limiter) Cooldown(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := l.client.PTTL(ctx, l.prefix+"cooldown:"+key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cooldown: %w", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Strike inicia um cool-down que dobra a cada reincidência (base, 2×base,
+// 4×base... até max). As reincidências são esquecidas após resetAfter sem
+// novas ocorrências.
+func (l *Limiter) Strike(ctx context.Context, key string, base, max, resetAfter time.Duration) (time.Duration, error) {
+	strikesKey := l.prefix + "strikes:" + key
+
+	pipe := l.client.TxPipeline()
+	incr := pipe.Incr(ctx, strikesKey)
+	pipe.Expire(ctx, strikesKey, resetAfter)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record strike: %w", err)
+	}
+
+	cooldown := base
+	for i := int64(1); i < incr.Val() && cooldown < max; i++ {
+		cooldown *= 2
+	}
+	if cooldown > max {
+		cooldown = max
+	}
+
+	if err := l.client.Set(ctx, l.prefix+"cooldown:"+key, 1, cooldown).Err(); err != nil {
+		return 0, fmt.Errorf("failed to set cooldown: %w", err)
+	}
+
+	return cooldown, nil
+}
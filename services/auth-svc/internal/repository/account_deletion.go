@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresAccountDeletionRepository implementação PostgreSQL do AccountDeletionRepository
+type PostgresAccountDeletionRepository struct {
+	db DBTX
+}
+
+// NewPostgresAccountDeletionRepository cria uma nova instância do repositório
+func NewPostgresAccountDeletionRepository(db DBTX) *PostgresAccountDeletionRepository {
+	return &PostgresAccountDeletionRepository{db: db}
+}
+
+const accountDeletionColumns = `user_id, requested_at, scheduled_for`
+
+func scanAccountDeletion(row rowScanner) (*models.AccountDeletion, error) {
+	deletion := &models.AccountDeletion{}
+	if err := row.Scan(&deletion.UserID, &deletion.RequestedAt, &deletion.ScheduledFor); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// Schedule agenda a exclusão da conta; um pedido existente é mantido
+func (r *PostgresAccountDeletionRepository) Schedule(ctx context.Context, deletion *models.AccountDeletion) error {
+	query := `
+		INSERT INTO account_deletions (` + accountDeletionColumns + `)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, deletion.UserID, deletion.RequestedAt, deletion.ScheduledFor)
+	if err != nil {
+		return fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID busca o pedido de exclusão do usuário
+func (r *PostgresAccountDeletionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error) {
+	query := `SELECT ` + accountDeletionColumns + ` FROM account_deletions WHERE user_id = $1`
+
+	deletion, err := scanAccountDeletion(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+
+	return deletion, nil
+}
+
+// Cancel remove o pedido de exclusão do usuário
+func (r *PostgresAccountDeletionRepository) Cancel(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// ListDue lista os pedidos cujo período de carência terminou antes de before
+func (r *PostgresAccountDeletionRepository) ListDue(ctx context.Context, before time.Time, limit int) ([]*models.AccountDeletion, error) {
+	query := `
+		SELECT ` + accountDeletionColumns + ` FROM account_deletions
+		WHERE scheduled_for <= $1
+		ORDER BY scheduled_for
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var deletions []*models.AccountDeletion
+	for rows.Next() {
+		deletion, err := scanAccountDeletion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account deletion: %w", err)
+		}
+		deletions = append(deletions, deletion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate account deletions: %w", err)
+	}
+
+	return deletions, nil
+}
+
+// Erase apaga os dados de autenticação do usuário e anonimiza o que precisa
+// ser mantido. A linha de users permanece como lápide anônima (status
+// deleted) para não quebrar referências de outros serviços, que removem
+// seus dados ao receber user.deleted. O log de auditoria perde IP, user
+// agent e metadados, mas mantém as ações para fins de segurança.
+func (r *PostgresAccountDeletionRepository) Erase(ctx context.Context, userID uuid.UUID, email string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`DELETE FROM sessions WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM refresh_tokens WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM devices WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM user_auth_providers WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM webauthn_credentials WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM password_reset_tokens WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM personal_access_tokens WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM email_change_requests WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM phone_verifications WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM user_roles WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM organization_invitations WHERE invited_by = $1 OR email = $2`, []interface{}{userID, email}},
+		// Organizações em que o usuário é o único membro, seja quem for o
+		// criador; membros e convites saem em cascata
+		{`DELETE FROM organizations o
+			WHERE EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id <> $1)`, []interface{}{userID}},
+		{`DELETE FROM organization_members WHERE user_id = $1`, []interface{}{userID}},
+		{`DELETE FROM magic_links WHERE email = $1`, []interface{}{email}},
+		{`DELETE FROM data_exports WHERE user_id = $1`, []interface{}{userID}},
+		{`UPDATE auth_audit_logs SET ip_address = NULL, user_agent = NULL, metadata = NULL
+			WHERE user_id = $1 OR actor_id = $1`, []interface{}{userID}},
+		{`UPDATE users SET
+			email = $2, email_verified = false, password_hash = NULL,
+			first_name = NULL, last_name = NULL, avatar_url = NULL,
+			mobile_number = NULL, mobile_verified = false, push_notifications_enabled = false,
+			two_factor_secret = NULL, two_factor_enabled = false, sms_mfa_enabled = false,
+			failed_login_attempts = 0, locked_until = NULL, last_login_at = NULL,
+			status = 'deleted', updated_at = $3
+			WHERE id = $1`, []interface{}{userID, erasedEmail(userID), time.Now()}},
+		{`DELETE FROM account_deletions WHERE user_id = $1`, []interface{}{userID}},
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("failed to erase account data: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// erasedEmail endereço não roteável que libera o email original para um
+// novo cadastro e mantém a restrição de unicidade
+func erasedEmail(userID uuid.UUID) string {
+	return "erased+" + userID.String() + "@deleted.invalid"
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"pagemagic/auth-svc/internal/models"
+)
+
+// PostgresAuditLogRepository implementação PostgreSQL do AuditLogRepository.
+// O log é somente inserção: não há atualização nem remoção de entradas.
+type PostgresAuditLogRepository struct {
+	db DBTX
+}
+
+// NewPostgresAuditLogRepository cria uma nova instância do repositório
+func NewPostgresAuditLogRepository(db DBTX) *PostgresAuditLogRepository {
+	return &PostgresAuditLogRepository{db: db}
+}
+
+const auditLogColumns = `
+	id, user_id, actor_id, session_id, action, result, ip_address, user_agent,
+	metadata, created_at`
+
+func scanAuditLog(row rowScanner) (*models.AuditLog, error) {
+	entry := &models.AuditLog{}
+	var metadata []byte
+	err := row.Scan(
+		&entry.ID, &entry.UserID, &entry.ActorID, &entry.SessionID, &entry.Action,
+		&entry.Result, &entry.IPAddress, &entry.UserAgent, &metadata, &entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &entry.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode audit metadata: %w", err)
+		}
+	}
+
+	return entry, nil
+}
+
+// Create registra uma entrada no log
+func (r *PostgresAuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	var metadata []byte
+	if len(entry.Metadata) > 0 {
+		encoded, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit metadata: %w", err)
+		}
+		metadata = encoded
+	}
+
+	query := `
+		INSERT INTO auth_audit_logs (` + auditLogColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.UserID, entry.ActorID, entry.SessionID, entry.Action,
+		entry.Result, entry.IPAddress, entry.UserAgent, metadata, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// List retorna as entradas que atendem ao filtro, da mais recente para a
+// mais antiga
+func (r *PostgresAuditLogRepository) List(ctx context.Context, filter models.AuditLogFilter) ([]*models.AuditLog, error) {
+	var entries []*models.AuditLog
+	err := r.Stream(ctx, filter, func(entry *models.AuditLog) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Stream percorre as entradas que atendem ao filtro sem carregá-las todas
+// em memória; usado na exportação
+func (r *PostgresAuditLogRepository) Stream(ctx context.Context, filter models.AuditLogFilter, fn func(*models.AuditLog) error) error {
+	where, args := auditLogWhere(filter)
+
+	query := `SELECT` + auditLogColumns + ` FROM auth_audit_logs` + where + ` ORDER BY created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate audit logs: %w", err)
+	}
+
+	return nil
+}
+
+// auditLogWhere monta a cláusula WHERE do filtro. Uma ação terminada em
+// "." filtra pelo prefixo (ex.: "auth.").
+func auditLogWhere(filter models.AuditLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != nil {
+		add("user_id = $%d", *filter.UserID)
+	}
+	if filter.ActorID != nil {
+		add("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(string(filter.Action), ".") {
+			add("action LIKE $%d || '%%'", string(filter.Action))
+		} else {
+			add("action = $%d", string(filter.Action))
+		}
+	}
+	if filter.Result != "" {
+		add("result = $%d", string(filter.Result))
+	}
+	if filter.IPAddress != "" {
+		add("ip_address = $%d", filter.IPAddress)
+	}
+	if filter.Since != nil {
+		add("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("created_at < $%d", *filter.Until)
+	}
+	if filter.BeforeTime != nil && filter.BeforeID != nil {
+		args = append(args, *filter.BeforeTime, *filter.BeforeID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// exportStaleAfter tempo após o qual um job em processamento é considerado
+// abandonado (instância encerrada no meio da geração) e volta à fila
+const exportStaleAfter = 15 * time.Minute
+
+// PostgresDataExportRepository implementação PostgreSQL do DataExportRepository
+type PostgresDataExportRepository struct {
+	db DBTX
+}
+
+// NewPostgresDataExportRepository cria uma nova instância do repositório
+func NewPostgresDataExportRepository(db DBTX) *PostgresDataExportRepository {
+	return &PostgresDataExportRepository{db: db}
+}
+
+// dataExportColumns não inclui o arquivo, lido apenas por GetArchive
+const dataExportColumns = `
+	id, user_id, status, error, created_at, started_at, completed_at, expires_at`
+
+func scanDataExport(row rowScanner) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	err := row.Scan(
+		&export.ID, &export.UserID, &export.Status, &export.Error,
+		&export.CreatedAt, &export.StartedAt, &export.CompletedAt, &export.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Create registra um pedido de exportação
+func (r *PostgresDataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	query := `
+		INSERT INTO data_exports (id, user_id, status, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, export.ID, export.UserID, export.Status, export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create data export: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID busca uma exportação do usuário, sem o arquivo
+func (r *PostgresDataExportRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*models.DataExport, error) {
+	query := `SELECT` + dataExportColumns + ` FROM data_exports WHERE id = $1 AND user_id = $2`
+
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+
+	return export, nil
+}
+
+// GetArchive retorna o arquivo de uma exportação pronta e ainda válida
+func (r *PostgresDataExportRepository) GetArchive(ctx context.Context, userID, id uuid.UUID) ([]byte, error) {
+	query := `
+		SELECT archive FROM data_exports
+		WHERE id = $1 AND user_id = $2 AND status = $3 AND expires_at > $4`
+
+	var archive []byte
+	err := r.db.QueryRowContext(ctx, query, id, userID, models.DataExportReady, time.Now()).Scan(&archive)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+// GetActiveByUserID busca a exportação pendente, em processamento ou pronta
+// (e não expirada) mais recente do usuário
+func (r *PostgresDataExportRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	query := `
+		SELECT` + dataExportColumns + ` FROM data_exports
+		WHERE user_id = $1
+		  AND (status IN ($2, $3) OR (status = $4 AND expires_at > $5))
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, query, userID,
+		models.DataExportPending, models.DataExportProcessing, models.DataExportReady, time.Now()))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active data export: %w", err)
+	}
+
+	return export, nil
+}
+
+// ClaimPending marca a exportação pendente mais antiga como em
+// processamento e a retorna. Jobs abandonados em processamento são
+// retomados; instâncias concorrentes nunca recebem o mesmo job.
+func (r *PostgresDataExportRepository) ClaimPending(ctx context.Context) (*models.DataExport, error) {
+	now := time.Now()
+	query := `
+		UPDATE data_exports SET status = $1, started_at = $2
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = $3 OR (status = $1 AND started_at < $4)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING` + dataExportColumns
+
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, query,
+		models.DataExportProcessing, now, models.DataExportPending, now.Add(-exportStaleAfter)))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+
+	return export, nil
+}
+
+// Complete guarda o arquivo gerado e marca a exportação como pronta
+func (r *PostgresDataExportRepository) Complete(ctx context.Context, id uuid.UUID, archive []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = $2, archive = $3, error = NULL, completed_at = $4, expires_at = $5
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, models.DataExportReady, archive, time.Now(), expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete data export: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// Fail registra a falha na geração da exportação
+func (r *PostgresDataExportRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `UPDATE data_exports SET status = $2, error = $3, completed_at = $4 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, models.DataExportFailed, reason, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark data export as failed: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// DeleteExpired remove exportações expiradas e falhas antigas
+func (r *PostgresDataExportRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM data_exports
+		WHERE expires_at < $1 OR (status = $2 AND completed_at < $3)`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, now, models.DataExportFailed, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired data exports: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX operações comuns a *sql.DB e *sql.Tx. Os repositórios aceitam
+// qualquer um dos dois, o que permite agrupá-los numa transação (InTx).
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txScope transação usada por um método de repositório. Quando o
+// repositório já opera dentro de uma transação externa, ela é reutilizada e
+// Commit/Rollback ficam a cargo de quem a abriu.
+type txScope struct {
+	DBTX
+	owned *sql.Tx
+}
+
+// beginTx inicia uma transação sobre db ou reutiliza a transação em curso
+func beginTx(ctx context.Context, db DBTX) (*txScope, error) {
+	switch conn := db.(type) {
+	case *sql.DB:
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &txScope{DBTX: tx, owned: tx}, nil
+	case *sql.Tx:
+		return &txScope{DBTX: conn}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database handle %T", db)
+	}
+}
+
+// Commit confirma a transação, se ela pertencer a este escopo
+func (t *txScope) Commit() error {
+	if t.owned == nil {
+		return nil
+	}
+	return t.owned.Commit()
+}
+
+// Rollback desfaz a transação, se ela pertencer a este escopo
+func (t *txScope) Rollback() error {
+	if t.owned == nil {
+		return nil
+	}
+	return t.owned.Rollback()
+}
+
+// InTx executa fn com repositórios que compartilham uma única transação;
+// tudo é desfeito se fn retornar erro. Repositórios sem conexão própria (os
+// de uma transação em curso ou os em memória dos testes) executam fn
+// diretamente, na transação de quem os criou.
+func (r *Repository) InTx(ctx context.Context, fn func(tx *Repository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(newRepository(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
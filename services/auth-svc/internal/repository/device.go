@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresDeviceRepository implementação PostgreSQL do DeviceRepository
+type PostgresDeviceRepository struct {
+	db DBTX
+}
+
+// NewPostgresDeviceRepository cria uma nova instância do repositório
+func NewPostgresDeviceRepository(db DBTX) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{db: db}
+}
+
+const deviceColumns = `
+	id, user_id, name, type, platform, fingerprint, is_trusted, last_seen_at,
+	last_seen_ip, created_at, updated_at`
+
+func scanDevice(row rowScanner) (*models.Device, error) {
+	device := &models.Device{}
+	err := row.Scan(
+		&device.ID, &device.UserID, &device.Name, &device.Type, &device.Platform,
+		&device.Fingerprint, &device.IsTrusted, &device.LastSeenAt,
+		&device.LastSeenIP, &device.CreatedAt, &device.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// Upsert registra o dispositivo ou, se o fingerprint já for conhecido para o
+// usuário, atualiza o último acesso. Nome e confiança definidos pelo usuário
+// são preservados.
+func (r *PostgresDeviceRepository) Upsert(ctx context.Context, device *models.Device) (*models.Device, error) {
+	query := `
+		INSERT INTO devices (` + deviceColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET
+			last_seen_at = EXCLUDED.last_seen_at,
+			last_seen_ip = EXCLUDED.last_seen_ip,
+			updated_at = EXCLUDED.updated_at
+		RETURNING` + deviceColumns
+
+	stored, err := scanDevice(r.db.QueryRowContext(ctx, query,
+		device.ID, device.UserID, device.Name, device.Type, device.Platform,
+		device.Fingerprint, device.IsTrusted, device.LastSeenAt,
+		device.LastSeenIP, device.CreatedAt, device.UpdatedAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert device: %w", err)
+	}
+
+	return stored, nil
+}
+
+// GetByID busca um dispositivo do usuário
+func (r *PostgresDeviceRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Device, error) {
+	query := `SELECT` + deviceColumns + ` FROM devices WHERE id = $1 AND user_id = $2`
+
+	device, err := scanDevice(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+
+	return device, nil
+}
+
+// ListByUserID lista os dispositivos do usuário
+func (r *PostgresDeviceRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Device, error) {
+	query := `SELECT` + deviceColumns + ` FROM devices WHERE user_id = $1 ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*models.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// Update atualiza nome e confiança do dispositivo
+func (r *PostgresDeviceRepository) Update(ctx context.Context, device *models.Device) error {
+	query := `UPDATE devices SET name = $3, is_trusted = $4, updated_at = $5 WHERE id = $1 AND user_id = $2`
+
+	device.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, device.ID, device.UserID, device.Name, device.IsTrusted, device.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update device: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
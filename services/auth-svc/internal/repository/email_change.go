@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresEmailChangeRepository implementação PostgreSQL do EmailChangeRepository
+type PostgresEmailChangeRepository struct {
+	db DBTX
+}
+
+// NewPostgresEmailChangeRepository cria uma nova instância do repositório
+func NewPostgresEmailChangeRepository(db DBTX) *PostgresEmailChangeRepository {
+	return &PostgresEmailChangeRepository{db: db}
+}
+
+const emailChangeColumns = `
+	id, user_id, new_email, confirm_token, cancel_token, expires_at, created_at`
+
+func scanEmailChange(row rowScanner) (*models.EmailChangeRequest, error) {
+	change := &models.EmailChangeRequest{}
+	err := row.Scan(
+		&change.ID, &change.UserID, &change.NewEmail, &change.ConfirmToken,
+		&change.CancelToken, &change.ExpiresAt, &change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// Create registra a troca de email, substituindo um pedido anterior do
+// usuário; apenas os links mais recentes permanecem válidos
+func (r *PostgresEmailChangeRepository) Create(ctx context.Context, change *models.EmailChangeRequest) error {
+	query := `
+		INSERT INTO email_change_requests (` + emailChangeColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			id = EXCLUDED.id,
+			new_email = EXCLUDED.new_email,
+			confirm_token = EXCLUDED.confirm_token,
+			cancel_token = EXCLUDED.cancel_token,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		change.ID, change.UserID, change.NewEmail, change.ConfirmToken,
+		change.CancelToken, change.ExpiresAt, change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	return nil
+}
+
+// GetByConfirmToken busca o pedido pelo hash do token de confirmação
+func (r *PostgresEmailChangeRepository) GetByConfirmToken(ctx context.Context, token string) (*models.EmailChangeRequest, error) {
+	return r.getBy(ctx, "confirm_token", token)
+}
+
+// GetByCancelToken busca o pedido pelo hash do token de cancelamento
+func (r *PostgresEmailChangeRepository) GetByCancelToken(ctx context.Context, token string) (*models.EmailChangeRequest, error) {
+	return r.getBy(ctx, "cancel_token", token)
+}
+
+func (r *PostgresEmailChangeRepository) getBy(ctx context.Context, column, token string) (*models.EmailChangeRequest, error) {
+	query := `SELECT` + emailChangeColumns + ` FROM email_change_requests WHERE ` + column + ` = $1`
+
+	change, err := scanEmailChange(r.db.QueryRowContext(ctx, query, token))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email change request: %w", err)
+	}
+
+	return change, nil
+}
+
+// Delete remove o pedido de forma atômica; retorna ErrNotFound se ele já
+// tiver sido confirmado ou cancelado por outra requisição
+func (r *PostgresEmailChangeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM email_change_requests WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete email change request: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// DeleteExpired remove pedidos expirados
+func (r *PostgresEmailChangeRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM email_change_requests WHERE expires_at < $1`, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired email change requests: %w", err)
+	}
+
+	return result.RowsAffected()
+}
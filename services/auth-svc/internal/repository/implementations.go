@@ -10,96 +10,40 @@ import (
 	"github.com/google/uuid"
 )
 
-// PostgresMagicLinkRepository implementação PostgreSQL do MagicLinkRepository
-type PostgresMagicLinkRepository struct {
-	db *sql.DB
-}
-
-func NewPostgresMagicLinkRepository(db *sql.DB) *PostgresMagicLinkRepository {
-	return &PostgresMagicLinkRepository{db: db}
-}
-
-func (r *PostgresMagicLinkRepository) Create(ctx context.Context, link *models.MagicLink) error {
-	query := `
-		INSERT INTO magic_links (id, email, token, expires_at, used, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
-
-	_, err := r.db.ExecContext(ctx, query,
-		link.ID, link.Email, link.Token, link.ExpiresAt, link.Used, link.CreatedAt,
-	)
-	return err
-}
-
-func (r *PostgresMagicLinkRepository) GetByToken(ctx context.Context, token string) (*models.MagicLink, error) {
-	query := `
-		SELECT id, email, token, expires_at, used, created_at
-		FROM magic_links WHERE token = $1`
-
-	link := &models.MagicLink{}
-	err := r.db.QueryRowContext(ctx, query, token).Scan(
-		&link.ID, &link.Email, &link.Token, &link.ExpiresAt, &link.Used, &link.CreatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("magic link not found")
-		}
-		return nil, fmt.Errorf("failed to get magic link: %w", err)
-	}
-
-	return link, nil
-}
-
-func (r *PostgresMagicLinkRepository) MarkAsUsed(ctx context.Context, token string) error {
-	query := `UPDATE magic_links SET used = true WHERE token = $1`
-	_, err := r.db.ExecContext(ctx, query, token)
-	return err
-}
-
-func (r *PostgresMagicLinkRepository) DeleteExpired(ctx context.Context) error {
-	query := `DELETE FROM magic_links WHERE expires_at < NOW()`
-	_, err := r.db.ExecContext(ctx, query)
-	return err
-}
-
-func (r *PostgresMagicLinkRepository) DeleteByEmail(ctx context.Context, email string) error {
-	query := `DELETE FROM magic_links WHERE email = $1`
-	_, err := r.db.ExecContext(ctx, query, email)
-	return err
-}
-
 // PostgresRefreshTokenRepository implementação PostgreSQL do RefreshTokenRepository
 type PostgresRefreshTokenRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPostgresRefreshTokenRepository(db *sql.DB) *PostgresRefreshTokenRepository {
+func NewPostgresRefreshTokenRepository(db DBTX) *PostgresRefreshTokenRepository {
 	return &PostgresRefreshTokenRepository{db: db}
 }
 
 func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
 	query := `
-		INSERT INTO refresh_tokens (id, user_id, token, expires_at, used, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		INSERT INTO refresh_tokens (id, user_id, family_id, token, expires_at, used, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
 	_, err := r.db.ExecContext(ctx, query,
-		token.ID, token.UserID, token.Token, token.ExpiresAt, token.Used, token.CreatedAt,
+		token.ID, token.UserID, token.FamilyID, token.Token, token.ExpiresAt, token.Used, token.CreatedAt,
 	)
 	return err
 }
 
 func (r *PostgresRefreshTokenRepository) GetByToken(ctx context.Context, token string) (*models.RefreshToken, error) {
 	query := `
-		SELECT id, user_id, token, expires_at, used, created_at
+		SELECT id, user_id, family_id, token, expires_at, used, used_at, revoked_at, created_at
 		FROM refresh_tokens WHERE token = $1`
 
 	refreshToken := &models.RefreshToken{}
 	err := r.db.QueryRowContext(ctx, query, token).Scan(
-		&refreshToken.ID, &refreshToken.UserID, &refreshToken.Token,
-		&refreshToken.ExpiresAt, &refreshToken.Used, &refreshToken.CreatedAt,
+		&refreshToken.ID, &refreshToken.UserID, &refreshToken.FamilyID, &refreshToken.Token,
+		&refreshToken.ExpiresAt, &refreshToken.Used, &refreshToken.UsedAt,
+		&refreshToken.RevokedAt, &refreshToken.CreatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("refresh token not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get refresh token: %w", err)
 	}
@@ -107,9 +51,30 @@ func (r *PostgresRefreshTokenRepository) GetByToken(ctx context.Context, token s
 	return refreshToken, nil
 }
 
+// MarkAsUsed consome o token de forma atômica; retorna ErrAlreadyUsed se ele
+// já tiver sido usado por outra requisição
 func (r *PostgresRefreshTokenRepository) MarkAsUsed(ctx context.Context, token string) error {
-	query := `UPDATE refresh_tokens SET used = true WHERE token = $1`
-	_, err := r.db.ExecContext(ctx, query, token)
+	query := `UPDATE refresh_tokens SET used = true, used_at = NOW() WHERE token = $1 AND used = false`
+	result, err := r.db.ExecContext(ctx, query, token)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrAlreadyUsed
+	}
+
+	return nil
+}
+
+// RevokeFamily revoga todos os refresh tokens de uma família
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, familyID)
 	return err
 }
 
@@ -119,46 +84,50 @@ func (r *PostgresRefreshTokenRepository) DeleteByUserID(ctx context.Context, use
 	return err
 }
 
-func (r *PostgresRefreshTokenRepository) DeleteExpired(ctx context.Context) error {
+func (r *PostgresRefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
 	query := `DELETE FROM refresh_tokens WHERE expires_at < NOW()`
-	_, err := r.db.ExecContext(ctx, query)
-	return err
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	return result.RowsAffected()
 }
 
 // PostgresAuthProviderRepository implementação PostgreSQL do AuthProviderRepository
 type PostgresAuthProviderRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPostgresAuthProviderRepository(db *sql.DB) *PostgresAuthProviderRepository {
+func NewPostgresAuthProviderRepository(db DBTX) *PostgresAuthProviderRepository {
 	return &PostgresAuthProviderRepository{db: db}
 }
 
 func (r *PostgresAuthProviderRepository) Create(ctx context.Context, provider *models.UserAuthProvider) error {
 	query := `
-		INSERT INTO user_auth_providers (id, user_id, provider, provider_user_id, provider_data, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		INSERT INTO user_auth_providers (
+			id, user_id, provider, provider_user_id, provider_email, provider_data, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err := r.db.ExecContext(ctx, query,
-		provider.ID, provider.UserID, provider.Provider,
-		provider.ProviderUserID, provider.ProviderData, provider.CreatedAt,
+		provider.ID, provider.UserID, provider.Provider, provider.ProviderUserID,
+		provider.ProviderEmail, provider.ProviderData, provider.CreatedAt, provider.UpdatedAt,
 	)
 	return err
 }
 
 func (r *PostgresAuthProviderRepository) GetByUserIDAndProvider(ctx context.Context, userID uuid.UUID, provider models.AuthProvider) (*models.UserAuthProvider, error) {
 	query := `
-		SELECT id, user_id, provider, provider_user_id, provider_data, created_at
+		SELECT id, user_id, provider, provider_user_id, provider_email, provider_data, created_at, updated_at
 		FROM user_auth_providers WHERE user_id = $1 AND provider = $2`
 
 	authProvider := &models.UserAuthProvider{}
 	err := r.db.QueryRowContext(ctx, query, userID, provider).Scan(
-		&authProvider.ID, &authProvider.UserID, &authProvider.Provider,
-		&authProvider.ProviderUserID, &authProvider.ProviderData, &authProvider.CreatedAt,
+		&authProvider.ID, &authProvider.UserID, &authProvider.Provider, &authProvider.ProviderUserID,
+		&authProvider.ProviderEmail, &authProvider.ProviderData, &authProvider.CreatedAt, &authProvider.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("auth provider not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get auth provider: %w", err)
 	}
@@ -168,17 +137,17 @@ func (r *PostgresAuthProviderRepository) GetByUserIDAndProvider(ctx context.Cont
 
 func (r *PostgresAuthProviderRepository) GetByProviderAndUserID(ctx context.Context, provider models.AuthProvider, providerUserID string) (*models.UserAuthProvider, error) {
 	query := `
-		SELECT id, user_id, provider, provider_user_id, provider_data, created_at
+		SELECT id, user_id, provider, provider_user_id, provider_email, provider_data, created_at, updated_at
 		FROM user_auth_providers WHERE provider = $1 AND provider_user_id = $2`
 
 	authProvider := &models.UserAuthProvider{}
 	err := r.db.QueryRowContext(ctx, query, provider, providerUserID).Scan(
-		&authProvider.ID, &authProvider.UserID, &authProvider.Provider,
-		&authProvider.ProviderUserID, &authProvider.ProviderData, &authProvider.CreatedAt,
+		&authProvider.ID, &authProvider.UserID, &authProvider.Provider, &authProvider.ProviderUserID,
+		&authProvider.ProviderEmail, &authProvider.ProviderData, &authProvider.CreatedAt, &authProvider.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("auth provider not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get auth provider: %w", err)
 	}
@@ -194,8 +163,9 @@ func (r *PostgresAuthProviderRepository) Delete(ctx context.Context, id uuid.UUI
 
 func (r *PostgresAuthProviderRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserAuthProvider, error) {
 	query := `
-		SELECT id, user_id, provider, provider_user_id, provider_data, created_at
-		FROM user_auth_providers WHERE user_id = $1`
+		SELECT id, user_id, provider, provider_user_id, provider_email, provider_data, created_at, updated_at
+		FROM user_auth_providers WHERE user_id = $1
+		ORDER BY created_at`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -207,8 +177,8 @@ func (r *PostgresAuthProviderRepository) ListByUserID(ctx context.Context, userI
 	for rows.Next() {
 		provider := &models.UserAuthProvider{}
 		err := rows.Scan(
-			&provider.ID, &provider.UserID, &provider.Provider,
-			&provider.ProviderUserID, &provider.ProviderData, &provider.CreatedAt,
+			&provider.ID, &provider.UserID, &provider.Provider, &provider.ProviderUserID,
+			&provider.ProviderEmail, &provider.ProviderData, &provider.CreatedAt, &provider.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan auth provider: %w", err)
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresOrganizationRepository implementação PostgreSQL do OrganizationRepository
+type PostgresOrganizationRepository struct {
+	db DBTX
+}
+
+// NewPostgresOrganizationRepository cria uma nova instância do repositório
+func NewPostgresOrganizationRepository(db DBTX) *PostgresOrganizationRepository {
+	return &PostgresOrganizationRepository{db: db}
+}
+
+// Create cria a organização junto com o membro proprietário
+func (r *PostgresOrganizationRepository) Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO organizations (id, name, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := tx.ExecContext(ctx, query, org.ID, org.Name, org.CreatedBy, org.CreatedAt, org.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	if err := addMember(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit organization: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID busca organização por ID
+func (r *PostgresOrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	query := `SELECT id, name, created_by, created_at, updated_at FROM organizations WHERE id = $1`
+
+	org := &models.Organization{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt, &org.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return org, nil
+}
+
+// Update atualiza os dados da organização
+func (r *PostgresOrganizationRepository) Update(ctx context.Context, org *models.Organization) error {
+	query := `UPDATE organizations SET name = $2, updated_at = $3 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, org.ID, org.Name, org.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// Delete remove a organização; membros e convites são removidos em cascata
+func (r *PostgresOrganizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM organizations WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// ListByUserID lista as organizações das quais o usuário é membro
+func (r *PostgresOrganizationRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMembership, error) {
+	query := `
+		SELECT o.id, o.name, o.created_by, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var memberships []*models.OrganizationMembership
+	for rows.Next() {
+		membership := &models.OrganizationMembership{}
+		err := rows.Scan(
+			&membership.ID, &membership.Name, &membership.CreatedBy,
+			&membership.CreatedAt, &membership.UpdatedAt, &membership.Role,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		memberships = append(memberships, membership)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate organizations: %w", err)
+	}
+
+	return memberships, nil
+}
+
+// GetMember busca o vínculo do usuário com a organização
+func (r *PostgresOrganizationRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	query := `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at, m.updated_at, u.email, u.first_name
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1 AND m.user_id = $2`
+
+	member, err := scanMember(r.db.QueryRowContext(ctx, query, orgID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization member: %w", err)
+	}
+
+	return member, nil
+}
+
+// ListMembers lista os membros da organização
+func (r *PostgresOrganizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error) {
+	query := `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at, m.updated_at, u.email, u.first_name
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY m.created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*models.OrganizationMember
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organization member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate organization members: %w", err)
+	}
+
+	return members, nil
+}
+
+func scanMember(row rowScanner) (*models.OrganizationMember, error) {
+	member := &models.OrganizationMember{}
+	err := row.Scan(
+		&member.OrganizationID, &member.UserID, &member.Role,
+		&member.CreatedAt, &member.UpdatedAt, &member.Email, &member.Name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// AddMember adiciona um membro; se o usuário já for membro, o papel atual
+// é mantido
+func (r *PostgresOrganizationRepository) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	return addMember(ctx, r.db, member)
+}
+
+func addMember(ctx context.Context, db DBTX, member *models.OrganizationMember) error {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id, user_id) DO NOTHING`
+
+	_, err := db.ExecContext(ctx, query,
+		member.OrganizationID, member.UserID, member.Role, member.CreatedAt, member.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add organization member: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMemberRole altera o papel de um membro
+func (r *PostgresOrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.OrgRole) error {
+	query := `
+		UPDATE organization_members SET role = $3, updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, orgID, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to update organization member: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// RemoveMember remove um membro da organização
+func (r *PostgresOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	query := `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove organization member: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// CountOwners conta os proprietários da organização
+func (r *PostgresOrganizationRepository) CountOwners(ctx context.Context, orgID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, orgID, models.OrgRoleOwner).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count organization owners: %w", err)
+	}
+
+	return count, nil
+}
+
+// expectRow retorna ErrNotFound se o comando não afetou nenhuma linha
+func expectRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresOrganizationInvitationRepository implementação PostgreSQL do OrganizationInvitationRepository
+type PostgresOrganizationInvitationRepository struct {
+	db DBTX
+}
+
+// NewPostgresOrganizationInvitationRepository cria uma nova instância do repositório
+func NewPostgresOrganizationInvitationRepository(db DBTX) *PostgresOrganizationInvitationRepository {
+	return &PostgresOrganizationInvitationRepository{db: db}
+}
+
+const invitationColumns = `
+	id, organization_id, email, role, token, invited_by, expires_at,
+	accepted_at, created_at`
+
+func scanInvitation(row rowScanner) (*models.OrganizationInvitation, error) {
+	invitation := &models.OrganizationInvitation{}
+	err := row.Scan(
+		&invitation.ID, &invitation.OrganizationID, &invitation.Email, &invitation.Role,
+		&invitation.Token, &invitation.InvitedBy, &invitation.ExpiresAt,
+		&invitation.AcceptedAt, &invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// Create cria um novo convite
+func (r *PostgresOrganizationInvitationRepository) Create(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	query := `
+		INSERT INTO organization_invitations (` + invitationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		invitation.ID, invitation.OrganizationID, invitation.Email, invitation.Role,
+		invitation.Token, invitation.InvitedBy, invitation.ExpiresAt,
+		invitation.AcceptedAt, invitation.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByToken busca convite pelo hash do token
+func (r *PostgresOrganizationInvitationRepository) GetByToken(ctx context.Context, token string) (*models.OrganizationInvitation, error) {
+	query := `SELECT` + invitationColumns + ` FROM organization_invitations WHERE token = $1`
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// MarkAsAccepted consome o convite de forma atômica; retorna ErrAlreadyUsed
+// se ele já tiver sido aceito
+func (r *PostgresOrganizationInvitationRepository) MarkAsAccepted(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE organization_invitations SET accepted_at = NOW() WHERE id = $1 AND accepted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	if err := expectRow(result); err != nil {
+		if err == ErrNotFound {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListPendingByOrganization lista os convites ainda não aceitos e não expirados
+func (r *PostgresOrganizationInvitationRepository) ListPendingByOrganization(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationInvitation, error) {
+	query := `
+		SELECT` + invitationColumns + ` FROM organization_invitations
+		WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > $2
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*models.OrganizationInvitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// Delete remove (revoga) um convite da organização
+func (r *PostgresOrganizationInvitationRepository) Delete(ctx context.Context, orgID, id uuid.UUID) error {
+	query := `DELETE FROM organization_invitations WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// DeletePendingByEmail remove convites pendentes do email, antes de um reenvio
+func (r *PostgresOrganizationInvitationRepository) DeletePendingByEmail(ctx context.Context, orgID uuid.UUID, email string) error {
+	query := `DELETE FROM organization_invitations WHERE organization_id = $1 AND email = $2 AND accepted_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, orgID, email)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitations: %w", err)
+	}
+
+	return nil
+}
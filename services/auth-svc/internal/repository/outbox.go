@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresOutboxRepository implementação PostgreSQL do OutboxRepository
+type PostgresOutboxRepository struct {
+	db DBTX
+}
+
+// NewPostgresOutboxRepository cria uma nova instância do repositório
+func NewPostgresOutboxRepository(db DBTX) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+const outboxColumns = `
+	id, subject, payload, attempts, last_error, next_attempt_at, published_at, created_at`
+
+func scanOutboxEvent(row rowScanner) (*models.OutboxEvent, error) {
+	event := &models.OutboxEvent{}
+	err := row.Scan(
+		&event.ID, &event.Subject, &event.Payload, &event.Attempts, &event.LastError,
+		&event.NextAttemptAt, &event.PublishedAt, &event.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Create grava um evento para publicação. Deve ser chamado dentro da mesma
+// transação da mudança que o originou (Repository.InTx).
+func (r *PostgresOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (` + outboxColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.Subject, event.Payload, event.Attempts, event.LastError,
+		event.NextAttemptAt, event.PublishedAt, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimPending bloqueia até limit eventos prontos para publicação, na ordem
+// em que foram gravados. Eventos bloqueados por outra instância do relay
+// são ignorados; deve ser usado dentro de uma transação.
+func (r *PostgresOutboxRepository) ClaimPending(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	query := `
+		SELECT` + outboxColumns + ` FROM outbox_events
+		WHERE published_at IS NULL AND next_attempt_at <= $1
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkPublished registra a publicação do evento
+func (r *PostgresOutboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE outbox_events SET published_at = $2, attempts = attempts + 1, last_error = NULL WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event as published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed registra uma falha de publicação e reagenda o evento
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, reason, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event as failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePublishedBefore remove eventos já publicados antes de before
+func (r *PostgresOutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
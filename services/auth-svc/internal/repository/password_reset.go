@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresPasswordResetRepository implementação PostgreSQL do PasswordResetRepository
+type PostgresPasswordResetRepository struct {
+	db DBTX
+}
+
+// NewPostgresPasswordResetRepository cria uma nova instância do repositório
+func NewPostgresPasswordResetRepository(db DBTX) *PostgresPasswordResetRepository {
+	return &PostgresPasswordResetRepository{db: db}
+}
+
+// Create cria um novo token de redefinição
+func (r *PostgresPasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token, used, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID, token.UserID, token.Token, token.Used, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByToken busca token de redefinição pelo hash
+func (r *PostgresPasswordResetRepository) GetByToken(ctx context.Context, token string) (*models.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token, used, used_at, expires_at, created_at
+		FROM password_reset_tokens WHERE token = $1`
+
+	reset := &models.PasswordResetToken{}
+	err := r.db.QueryRowContext(ctx, query, token).Scan(
+		&reset.ID, &reset.UserID, &reset.Token, &reset.Used, &reset.UsedAt,
+		&reset.ExpiresAt, &reset.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return reset, nil
+}
+
+// MarkAsUsed consome o token de forma atômica; retorna ErrAlreadyUsed se ele
+// já tiver sido usado por outra requisição
+func (r *PostgresPasswordResetRepository) MarkAsUsed(ctx context.Context, token string) error {
+	query := `UPDATE password_reset_tokens SET used = true, used_at = NOW() WHERE token = $1 AND used = false`
+
+	result, err := r.db.ExecContext(ctx, query, token)
+	if err != nil {
+		return fmt.Errorf("failed to mark password reset token as used: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrAlreadyUsed
+	}
+
+	return nil
+}
+
+// DeleteByUserID remove os tokens de redefinição do usuário
+func (r *PostgresPasswordResetRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM password_reset_tokens WHERE user_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired remove tokens de redefinição expirados e retorna quantos foram removidos
+func (r *PostgresPasswordResetRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM password_reset_tokens WHERE expires_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
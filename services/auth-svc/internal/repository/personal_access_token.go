@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresPersonalAccessTokenRepository implementação PostgreSQL do PersonalAccessTokenRepository
+type PostgresPersonalAccessTokenRepository struct {
+	db DBTX
+}
+
+// NewPostgresPersonalAccessTokenRepository cria uma nova instância do repositório
+func NewPostgresPersonalAccessTokenRepository(db DBTX) *PostgresPersonalAccessTokenRepository {
+	return &PostgresPersonalAccessTokenRepository{db: db}
+}
+
+const personalAccessTokenColumns = `
+	id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at,
+	last_used_ip, created_at, revoked_at`
+
+func scanPersonalAccessToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	token := &models.PersonalAccessToken{}
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.TokenHash,
+		pq.Array(&token.Scopes), &token.ExpiresAt, &token.LastUsedAt,
+		&token.LastUsedIP, &token.CreatedAt, &token.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Create cria um novo token de acesso pessoal
+func (r *PostgresPersonalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (` + personalAccessTokenColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID, token.UserID, token.Name, token.Prefix, token.TokenHash,
+		pq.Array(token.Scopes), token.ExpiresAt, token.LastUsedAt,
+		token.LastUsedIP, token.CreatedAt, token.RevokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash busca token pelo hash do segredo
+func (r *PostgresPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	query := `SELECT` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = $1`
+
+	token, err := scanPersonalAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListByUserID lista os tokens não revogados do usuário, incluindo expirados
+func (r *PostgresPersonalAccessTokenRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	query := `
+		SELECT` + personalAccessTokenColumns + ` FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate personal access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// CountActiveByUserID conta os tokens não revogados e não expirados do usuário
+func (r *PostgresPersonalAccessTokenRepository) CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, time.Now()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count personal access tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// TouchLastUsed registra o último uso do token
+func (r *PostgresPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, ipAddress *string) error {
+	query := `
+		UPDATE personal_access_tokens
+		SET last_used_at = NOW(), last_used_ip = COALESCE($2, last_used_ip)
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, ipAddress)
+	if err != nil {
+		return fmt.Errorf("failed to touch personal access token: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke revoga um token do usuário
+func (r *PostgresPersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	query := `
+		UPDATE personal_access_tokens SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// RevokeAllByUserID revoga todos os tokens do usuário
+func (r *PostgresPersonalAccessTokenRepository) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE personal_access_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresPhoneVerificationRepository implementação PostgreSQL do PhoneVerificationRepository
+type PostgresPhoneVerificationRepository struct {
+	db DBTX
+}
+
+// NewPostgresPhoneVerificationRepository cria uma nova instância do repositório
+func NewPostgresPhoneVerificationRepository(db DBTX) *PostgresPhoneVerificationRepository {
+	return &PostgresPhoneVerificationRepository{db: db}
+}
+
+const phoneVerificationColumns = `
+	id, user_id, phone_number, purpose, code_hash, attempts, sends,
+	expires_at, last_sent_at, created_at`
+
+func scanPhoneVerification(row rowScanner) (*models.PhoneVerification, error) {
+	v := &models.PhoneVerification{}
+	err := row.Scan(
+		&v.ID, &v.UserID, &v.PhoneNumber, &v.Purpose, &v.CodeHash, &v.Attempts, &v.Sends,
+		&v.ExpiresAt, &v.LastSentAt, &v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Upsert grava o código do usuário para a finalidade, substituindo o anterior
+func (r *PostgresPhoneVerificationRepository) Upsert(ctx context.Context, v *models.PhoneVerification) error {
+	query := `
+		INSERT INTO phone_verifications (` + phoneVerificationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, purpose) DO UPDATE SET
+			id = EXCLUDED.id,
+			phone_number = EXCLUDED.phone_number,
+			code_hash = EXCLUDED.code_hash,
+			attempts = EXCLUDED.attempts,
+			sends = EXCLUDED.sends,
+			expires_at = EXCLUDED.expires_at,
+			last_sent_at = EXCLUDED.last_sent_at,
+			created_at = EXCLUDED.created_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		v.ID, v.UserID, v.PhoneNumber, v.Purpose, v.CodeHash, v.Attempts, v.Sends,
+		v.ExpiresAt, v.LastSentAt, v.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store phone verification: %w", err)
+	}
+
+	return nil
+}
+
+// Get busca o código ativo do usuário para a finalidade
+func (r *PostgresPhoneVerificationRepository) Get(ctx context.Context, userID uuid.UUID, purpose models.PhoneVerificationPurpose) (*models.PhoneVerification, error) {
+	query := `SELECT` + phoneVerificationColumns + ` FROM phone_verifications WHERE user_id = $1 AND purpose = $2`
+
+	v, err := scanPhoneVerification(r.db.QueryRowContext(ctx, query, userID, purpose))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get phone verification: %w", err)
+	}
+
+	return v, nil
+}
+
+// IncrementAttempts registra uma tentativa errada e retorna o total
+func (r *PostgresPhoneVerificationRepository) IncrementAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	query := `UPDATE phone_verifications SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts`
+
+	var attempts int
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record phone verification attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Delete consome o código de forma atômica; retorna ErrNotFound se ele já
+// tiver sido usado por outra requisição
+func (r *PostgresPhoneVerificationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM phone_verifications WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete phone verification: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// DeleteByUserID remove todos os códigos do usuário
+func (r *PostgresPhoneVerificationRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM phone_verifications WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete phone verifications: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired remove códigos expirados
+func (r *PostgresPhoneVerificationRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM phone_verifications WHERE expires_at < $1`, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired phone verifications: %w", err)
+	}
+
+	return result.RowsAffected()
+}
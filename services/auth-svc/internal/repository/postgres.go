@@ -18,22 +18,68 @@ func New(databaseURL string) (*Repository, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	repo := newRepository(db)
+	repo.db = db
+
+	return repo, nil
+}
+
+// newRepository monta os repositórios sobre a conexão ou transação informada
+func newRepository(db DBTX) *Repository {
 	// Inicializar repositórios específicos
 	userRepo := NewPostgresUserRepository(db)
 	magicLinkRepo := NewPostgresMagicLinkRepository(db)
 	refreshTokenRepo := NewPostgresRefreshTokenRepository(db)
 	authProviderRepo := NewPostgresAuthProviderRepository(db)
+	passwordResetRepo := NewPostgresPasswordResetRepository(db)
+	recoveryCodeRepo := NewPostgresRecoveryCodeRepository(db)
+	webAuthnRepo := NewPostgresWebAuthnCredentialRepository(db)
+	sessionRepo := NewPostgresSessionRepository(db)
+	deviceRepo := NewPostgresDeviceRepository(db)
+	roleRepo := NewPostgresRoleRepository(db)
+	organizationRepo := NewPostgresOrganizationRepository(db)
+	invitationRepo := NewPostgresOrganizationInvitationRepository(db)
+	accessTokenRepo := NewPostgresPersonalAccessTokenRepository(db)
+	auditRepo := NewPostgresAuditLogRepository(db)
+	outboxRepo := NewPostgresOutboxRepository(db)
+	emailChangeRepo := NewPostgresEmailChangeRepository(db)
+	phoneRepo := NewPostgresPhoneVerificationRepository(db)
+	dataExportRepo := NewPostgresDataExportRepository(db)
+	deletionRepo := NewPostgresAccountDeletionRepository(db)
 
 	return &Repository{
-		User:         userRepo,
-		MagicLink:    magicLinkRepo,
-		RefreshToken: refreshTokenRepo,
-		AuthProvider: authProviderRepo,
-	}, nil
+		User:          userRepo,
+		MagicLink:     magicLinkRepo,
+		RefreshToken:  refreshTokenRepo,
+		AuthProvider:  authProviderRepo,
+		PasswordReset: passwordResetRepo,
+		RecoveryCode:  recoveryCodeRepo,
+		WebAuthn:      webAuthnRepo,
+		Session:       sessionRepo,
+		Device:        deviceRepo,
+		Role:          roleRepo,
+		Organization:  organizationRepo,
+		Invitation:    invitationRepo,
+		AccessToken:   accessTokenRepo,
+		Audit:         auditRepo,
+		Outbox:        outboxRepo,
+		EmailChange:   emailChangeRepo,
+		Phone:         phoneRepo,
+		DataExport:    dataExportRepo,
+		Deletion:      deletionRepo,
+	}
+}
+
+// DB pool de conexões do repositório, para recursos fora dos repositórios
+// como advisory locks
+func (r *Repository) DB() *sql.DB {
+	return r.db
 }
 
 // Close fecha a conexão com o banco de dados
 func (r *Repository) Close() error {
-	// Implementar close para cada repositório
-	return nil
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Close()
 }
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresRecoveryCodeRepository implementação PostgreSQL do RecoveryCodeRepository
+type PostgresRecoveryCodeRepository struct {
+	db DBTX
+}
+
+// NewPostgresRecoveryCodeRepository cria uma nova instância do repositório
+func NewPostgresRecoveryCodeRepository(db DBTX) *PostgresRecoveryCodeRepository {
+	return &PostgresRecoveryCodeRepository{db: db}
+}
+
+// Replace substitui todos os códigos de recuperação do usuário
+func (r *PostgresRecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, codes []*models.RecoveryCode) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query := `
+		INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx, query, code.ID, userID, code.CodeHash, code.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marca o código como usado de forma atômica; retorna ErrNotFound se
+// o código não existir ou já tiver sido usado
+func (r *PostgresRecoveryCodeRepository) Consume(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CountUnused conta os códigos de recuperação ainda disponíveis
+func (r *PostgresRecoveryCodeRepository) CountUnused(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteByUserID remove os códigos de recuperação do usuário
+func (r *PostgresRecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM mfa_recovery_codes WHERE user_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
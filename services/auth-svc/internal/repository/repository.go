@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"pagemagic/auth-svc/internal/models"
@@ -12,15 +14,30 @@ import (
 	"github.com/lib/pq"
 )
 
+// ErrNotFound indica que o registro não existe
+var ErrNotFound = errors.New("record not found")
+
+// ErrAlreadyUsed indica que o token já havia sido consumido
+var ErrAlreadyUsed = errors.New("token already used")
+
 // UserRepository interface para repositório de usuários
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status models.UserStatus) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]*models.User, error)
+	List(ctx context.Context, filter models.UserFilter) ([]*models.User, error)
 	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
+	RecordFailedLogin(ctx context.Context, id uuid.UUID, maxAttempts int, lockFor time.Duration) (int, *time.Time, error)
+	ResetFailedLogins(ctx context.Context, id uuid.UUID) error
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, previous *string, hash string) error
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) error
+	UpdateTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID, secret string) error
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
 }
 
 // AuthProviderRepository interface para repositório de provedores de auth
@@ -32,42 +49,243 @@ type AuthProviderRepository interface {
 	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserAuthProvider, error)
 }
 
+// WebAuthnCredentialRepository interface para repositório de passkeys
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, credential *models.WebAuthnCredential) error
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error)
+	UpdateUsage(ctx context.Context, id uuid.UUID, signCount uint32, backupState bool) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+}
+
 // MagicLinkRepository interface para repositório de magic links
 type MagicLinkRepository interface {
 	Create(ctx context.Context, link *models.MagicLink) error
 	GetByToken(ctx context.Context, token string) (*models.MagicLink, error)
+	GetByDeviceNonce(ctx context.Context, nonce string) (*models.MagicLink, error)
+	ClaimAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (int, error)
 	MarkAsUsed(ctx context.Context, token string) error
-	DeleteExpired(ctx context.Context) error
+	DeleteExpired(ctx context.Context) (int64, error)
 	DeleteByEmail(ctx context.Context, email string) error
 }
 
+// PasswordResetRepository interface para repositório de tokens de redefinição de senha
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByToken(ctx context.Context, token string) (*models.PasswordResetToken, error)
+	MarkAsUsed(ctx context.Context, token string) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// RecoveryCodeRepository interface para repositório de códigos de recuperação de 2FA
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uuid.UUID, codes []*models.RecoveryCode) error
+	Consume(ctx context.Context, userID uuid.UUID, codeHash string) error
+	CountUnused(ctx context.Context, userID uuid.UUID) (int, error)
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// SessionRepository interface para repositório de sessões
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Session, error)
+	ListActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error)
+	Touch(ctx context.Context, id uuid.UUID, ipAddress *string, expiresAt time.Time) error
+	SetActiveOrganization(ctx context.Context, userID, id uuid.UUID, orgID *uuid.UUID) error
+	Revoke(ctx context.Context, userID, id uuid.UUID) error
+	RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// DeviceRepository interface para repositório de dispositivos
+type DeviceRepository interface {
+	Upsert(ctx context.Context, device *models.Device) (*models.Device, error)
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Device, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Device, error)
+	Update(ctx context.Context, device *models.Device) error
+}
+
+// RoleRepository interface para repositório de papéis e permissões
+type RoleRepository interface {
+	EnsureDefaults(ctx context.Context, permissions []models.Permission, roles []models.Role) error
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	List(ctx context.Context) ([]*models.Role, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Role, error)
+	AssignToUser(ctx context.Context, userID uuid.UUID, roleName string) error
+	RemoveFromUser(ctx context.Context, userID uuid.UUID, roleName string) error
+}
+
+// OrganizationRepository interface para repositório de organizações e membros
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	Update(ctx context.Context, org *models.Organization) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMembership, error)
+	GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error)
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error)
+	AddMember(ctx context.Context, member *models.OrganizationMember) error
+	UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.OrgRole) error
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+	CountOwners(ctx context.Context, orgID uuid.UUID) (int, error)
+}
+
+// OrganizationInvitationRepository interface para repositório de convites
+type OrganizationInvitationRepository interface {
+	Create(ctx context.Context, invitation *models.OrganizationInvitation) error
+	GetByToken(ctx context.Context, token string) (*models.OrganizationInvitation, error)
+	MarkAsAccepted(ctx context.Context, id uuid.UUID) error
+	ListPendingByOrganization(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationInvitation, error)
+	Delete(ctx context.Context, orgID, id uuid.UUID) error
+	DeletePendingByEmail(ctx context.Context, orgID uuid.UUID, email string) error
+}
+
+// PersonalAccessTokenRepository interface para repositório de tokens de acesso pessoal
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error)
+	CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, ipAddress *string) error
+	Revoke(ctx context.Context, userID, id uuid.UUID) error
+	RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// AuditLogRepository interface para o log de auditoria (somente inserção)
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	List(ctx context.Context, filter models.AuditLogFilter) ([]*models.AuditLog, error)
+	Stream(ctx context.Context, filter models.AuditLogFilter, fn func(*models.AuditLog) error) error
+}
+
+// OutboxRepository interface para a outbox de eventos de domínio
+type OutboxRepository interface {
+	Create(ctx context.Context, event *models.OutboxEvent) error
+	ClaimPending(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string, nextAttemptAt time.Time) error
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PhoneVerificationRepository interface para códigos enviados por SMS
+type PhoneVerificationRepository interface {
+	Upsert(ctx context.Context, verification *models.PhoneVerification) error
+	Get(ctx context.Context, userID uuid.UUID, purpose models.PhoneVerificationPurpose) (*models.PhoneVerification, error)
+	IncrementAttempts(ctx context.Context, id uuid.UUID) (int, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// EmailChangeRepository interface para trocas de email pendentes
+type EmailChangeRepository interface {
+	Create(ctx context.Context, change *models.EmailChangeRequest) error
+	GetByConfirmToken(ctx context.Context, token string) (*models.EmailChangeRequest, error)
+	GetByCancelToken(ctx context.Context, token string) (*models.EmailChangeRequest, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// DataExportRepository interface para os jobs de exportação de dados da conta
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*models.DataExport, error)
+	GetArchive(ctx context.Context, userID, id uuid.UUID) ([]byte, error)
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*models.DataExport, error)
+	ClaimPending(ctx context.Context) (*models.DataExport, error)
+	Complete(ctx context.Context, id uuid.UUID, archive []byte, expiresAt time.Time) error
+	Fail(ctx context.Context, id uuid.UUID, reason string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// AccountDeletionRepository interface para pedidos de exclusão de conta
+type AccountDeletionRepository interface {
+	Schedule(ctx context.Context, deletion *models.AccountDeletion) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error)
+	Cancel(ctx context.Context, userID uuid.UUID) error
+	ListDue(ctx context.Context, before time.Time, limit int) ([]*models.AccountDeletion, error)
+	Erase(ctx context.Context, userID uuid.UUID, email string) error
+}
+
 // RefreshTokenRepository interface para repositório de refresh tokens
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, token *models.RefreshToken) error
 	GetByToken(ctx context.Context, token string) (*models.RefreshToken, error)
 	MarkAsUsed(ctx context.Context, token string) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
 	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
-	DeleteExpired(ctx context.Context) error
+	DeleteExpired(ctx context.Context) (int64, error)
 }
 
 // Repository agregador de todos os repositórios
 type Repository struct {
-	User         UserRepository
-	AuthProvider AuthProviderRepository
-	MagicLink    MagicLinkRepository
-	RefreshToken RefreshTokenRepository
+	User          UserRepository
+	AuthProvider  AuthProviderRepository
+	MagicLink     MagicLinkRepository
+	RefreshToken  RefreshTokenRepository
+	PasswordReset PasswordResetRepository
+	RecoveryCode  RecoveryCodeRepository
+	WebAuthn      WebAuthnCredentialRepository
+	Session       SessionRepository
+	Device        DeviceRepository
+	Role          RoleRepository
+	Organization  OrganizationRepository
+	Invitation    OrganizationInvitationRepository
+	AccessToken   PersonalAccessTokenRepository
+	Audit         AuditLogRepository
+	Outbox        OutboxRepository
+	EmailChange   EmailChangeRepository
+	Phone         PhoneVerificationRepository
+	DataExport    DataExportRepository
+	Deletion      AccountDeletionRepository
+
+	// db conexão usada por InTx; nil nos repositórios de uma transação e nos
+	// montados diretamente (testes)
+	db *sql.DB
 }
 
 // PostgresUserRepository implementação PostgreSQL do UserRepository
 type PostgresUserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewPostgresUserRepository cria uma nova instância do repositório
-func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+func NewPostgresUserRepository(db DBTX) *PostgresUserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
+// userColumns colunas selecionadas pelas consultas de usuário (ordem de scanUser)
+const userColumns = `
+	id, email, email_verified, password_hash, first_name, last_name,
+	avatar_url, status, locale, timezone, mobile_verified, mobile_number,
+	push_notifications_enabled, failed_login_attempts, locked_until,
+	two_factor_secret, two_factor_enabled, sms_mfa_enabled, last_login_at,
+	created_at, updated_at`
+
+// rowScanner abstrai *sql.Row e *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser lê um usuário na ordem de userColumns
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(
+		&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
+		&user.FirstName, &user.LastName, &user.AvatarURL, &user.Status,
+		&user.Locale, &user.Timezone, &user.MobileVerified, &user.MobileNumber,
+		&user.PushNotificationsEnabled, &user.FailedLoginAttempts, &user.LockedUntil,
+		&user.TwoFactorSecret, &user.TwoFactorEnabled, &user.SMSMFAEnabled, &user.LastLoginAt,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // Create cria um novo usuário
 func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
@@ -98,23 +316,28 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User)
 
 // GetByID busca usuário por ID
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	query := `
-		SELECT id, email, email_verified, password_hash, first_name, last_name,
-			   avatar_url, status, locale, timezone, mobile_verified, mobile_number,
-			   push_notifications_enabled, last_login_at, created_at, updated_at
-		FROM users WHERE id = $1`
+	query := `SELECT` + userColumns + ` FROM users WHERE id = $1`
 
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.AvatarURL, &user.Status,
-		&user.Locale, &user.Timezone, &user.MobileVerified, &user.MobileNumber,
-		&user.PushNotificationsEnabled, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetByIDForUpdate busca o usuário bloqueando a linha até o fim da
+// transação; fora de InTx o bloqueio não tem efeito
+func (r *PostgresUserRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `SELECT` + userColumns + ` FROM users WHERE id = $1 FOR UPDATE`
 
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -124,23 +347,12 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 
 // GetByEmail busca usuário por email
 func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `
-		SELECT id, email, email_verified, password_hash, first_name, last_name,
-			   avatar_url, status, locale, timezone, mobile_verified, mobile_number,
-			   push_notifications_enabled, last_login_at, created_at, updated_at
-		FROM users WHERE email = $1`
-
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.AvatarURL, &user.Status,
-		&user.Locale, &user.Timezone, &user.MobileVerified, &user.MobileNumber,
-		&user.PushNotificationsEnabled, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
-	)
+	query := `SELECT` + userColumns + ` FROM users WHERE email = $1`
 
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -148,23 +360,27 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 	return user, nil
 }
 
-// Update atualiza um usuário
+// Update atualiza os dados do usuário. O status não é gravado aqui: cópias
+// carregadas antes de uma suspensão desfariam a mudança; use UpdateStatus.
+// Contas já apagadas (lápides anônimas) não são regravadas.
 func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users SET
 			email = $2, email_verified = $3, password_hash = $4, first_name = $5,
-			last_name = $6, avatar_url = $7, status = $8, locale = $9, timezone = $10,
-			mobile_verified = $11, mobile_number = $12, push_notifications_enabled = $13,
-			updated_at = $14
-		WHERE id = $1`
+			last_name = $6, avatar_url = $7, locale = $8, timezone = $9,
+			mobile_verified = $10, mobile_number = $11, push_notifications_enabled = $12,
+			failed_login_attempts = $13, locked_until = $14, two_factor_secret = $15,
+			two_factor_enabled = $16, sms_mfa_enabled = $17, updated_at = $18
+		WHERE id = $1 AND status != 'deleted'`
 
 	user.UpdatedAt = time.Now()
 
 	result, err := r.db.ExecContext(ctx, query,
 		user.ID, user.Email, user.EmailVerified, user.PasswordHash,
-		user.FirstName, user.LastName, user.AvatarURL, user.Status,
+		user.FirstName, user.LastName, user.AvatarURL,
 		user.Locale, user.Timezone, user.MobileVerified, user.MobileNumber,
-		user.PushNotificationsEnabled, user.UpdatedAt,
+		user.PushNotificationsEnabled, user.FailedLoginAttempts, user.LockedUntil,
+		user.TwoFactorSecret, user.TwoFactorEnabled, user.SMSMFAEnabled, user.UpdatedAt,
 	)
 
 	if err != nil {
@@ -177,12 +393,24 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrNotFound
 	}
 
 	return nil
 }
 
+// UpdateStatus altera apenas o status do usuário
+func (r *PostgresUserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.UserStatus) error {
+	query := `UPDATE users SET status = $2, updated_at = $3 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, status, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	return expectRow(result)
+}
+
 // Delete remove um usuário (soft delete)
 func (r *PostgresUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE users SET status = 'deleted', updated_at = $2 WHERE id = $1`
@@ -198,24 +426,46 @@ func (r *PostgresUserRepository) Delete(ctx context.Context, id uuid.UUID) error
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrNotFound
 	}
 
 	return nil
 }
 
-// List lista usuários com paginação
-func (r *PostgresUserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
-	query := `
-		SELECT id, email, email_verified, password_hash, first_name, last_name,
-			   avatar_url, status, locale, timezone, mobile_verified, mobile_number,
-			   push_notifications_enabled, last_login_at, created_at, updated_at
-		FROM users 
-		WHERE status != 'deleted'
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
-
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+// List retorna os usuários que atendem ao filtro, do mais recente para o
+// mais antigo
+func (r *PostgresUserRepository) List(ctx context.Context, filter models.UserFilter) ([]*models.User, error) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Email != "" {
+		add("email ILIKE '%%' || $%d || '%%'", escapeLike(filter.Email))
+	}
+	if filter.Name != "" {
+		add("(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) ILIKE '%%' || $%d || '%%'", escapeLike(filter.Name))
+	}
+	if filter.Status != "" {
+		add("status = $%d", string(filter.Status))
+	} else {
+		conditions = append(conditions, "status != 'deleted'")
+	}
+	if filter.BeforeTime != nil && filter.BeforeID != nil {
+		args = append(args, *filter.BeforeTime, *filter.BeforeID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `SELECT` + userColumns + ` FROM users WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -223,13 +473,7 @@ func (r *PostgresUserRepository) List(ctx context.Context, limit, offset int) ([
 
 	var users []*models.User
 	for rows.Next() {
-		user := &models.User{}
-		err := rows.Scan(
-			&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash,
-			&user.FirstName, &user.LastName, &user.AvatarURL, &user.Status,
-			&user.Locale, &user.Timezone, &user.MobileVerified, &user.MobileNumber,
-			&user.PushNotificationsEnabled, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
@@ -243,6 +487,136 @@ func (r *PostgresUserRepository) List(ctx context.Context, limit, offset int) ([
 	return users, nil
 }
 
+// escapeLike escapa os curingas de LIKE para que o termo seja buscado literalmente
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// RecordFailedLogin incrementa o contador de falhas no próprio banco, para
+// que tentativas concorrentes não se percam. Ao atingir maxAttempts a conta
+// fica bloqueada por lockFor e o contador volta a zero. Retorna o contador
+// e o bloqueio resultantes.
+func (r *PostgresUserRepository) RecordFailedLogin(ctx context.Context, id uuid.UUID, maxAttempts int, lockFor time.Duration) (int, *time.Time, error) {
+	query := `
+		UPDATE users SET
+			failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= $2 THEN 0 ELSE failed_login_attempts + 1 END,
+			locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN $3 ELSE locked_until END,
+			updated_at = $4
+		WHERE id = $1
+		RETURNING failed_login_attempts, locked_until`
+
+	now := time.Now()
+	var attempts int
+	var lockedUntil *time.Time
+	err := r.db.QueryRowContext(ctx, query, id, maxAttempts, now.Add(lockFor), now).Scan(&attempts, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, ErrNotFound
+		}
+		return 0, nil, fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	return attempts, lockedUntil, nil
+}
+
+// ResetFailedLogins zera o contador de falhas e remove o bloqueio vencido.
+// Retorna ErrNotFound se a conta foi bloqueada depois de ter sido lida, por
+// falhas concorrentes: o bloqueio é mantido.
+func (r *PostgresUserRepository) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE users SET failed_login_attempts = 0, locked_until = NULL, updated_at = $2
+		WHERE id = $1 AND (locked_until IS NULL OR locked_until <= $2)`
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// UpdatePasswordHash grava o hash da senha se o hash atual ainda for
+// previous (nil para contas sem senha). Retorna ErrNotFound se a senha
+// mudou depois de ter sido lida, para que a troca mais recente prevaleça.
+func (r *PostgresUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, previous *string, hash string) error {
+	query := `
+		UPDATE users SET password_hash = $3, updated_at = $4
+		WHERE id = $1 AND password_hash IS NOT DISTINCT FROM $2 AND status != 'deleted'`
+
+	result, err := r.db.ExecContext(ctx, query, id, previous, hash, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// UseTOTPStep registra o período TOTP aceito. Retorna ErrAlreadyUsed se um
+// código do mesmo período ou de um posterior já tiver sido aceito.
+func (r *PostgresUserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	query := `
+		UPDATE users SET totp_last_step = $2, updated_at = $3
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+
+	result, err := r.db.ExecContext(ctx, query, id, step, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record totp step: %w", err)
+	}
+
+	if err := expectRow(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateTOTPSecret grava o segredo TOTP pendente de confirmação. Retorna
+// ErrNotFound se o 2FA já estiver ativo, para não trocar o segredo em uso.
+func (r *PostgresUserRepository) UpdateTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	query := `
+		UPDATE users SET two_factor_secret = $2, updated_at = $3
+		WHERE id = $1 AND NOT two_factor_enabled AND status != 'deleted'`
+
+	result, err := r.db.ExecContext(ctx, query, id, secret, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to store totp secret: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// EnableTOTP ativa o 2FA se o segredo pendente ainda for o confirmado.
+// Retorna ErrNotFound se outra inscrição o substituiu nesse meio tempo.
+func (r *PostgresUserRepository) EnableTOTP(ctx context.Context, id uuid.UUID, secret string) error {
+	query := `
+		UPDATE users SET two_factor_enabled = true, updated_at = $3
+		WHERE id = $1 AND two_factor_secret = $2 AND NOT two_factor_enabled`
+
+	result, err := r.db.ExecContext(ctx, query, id, secret, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return expectRow(result)
+}
+
+// DisableTOTP desativa o 2FA e descarta o segredo
+func (r *PostgresUserRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE users SET two_factor_enabled = false, two_factor_secret = NULL, updated_at = $2
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return expectRow(result)
+}
+
 // UpdateLastLogin atualiza o último login do usuário
 func (r *PostgresUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE users SET last_login_at = $2, updated_at = $2 WHERE id = $1`
@@ -259,7 +633,7 @@ func (r *PostgresUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UU
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrNotFound
 	}
 
 	return nil
@@ -267,22 +641,38 @@ func (r *PostgresUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UU
 
 // PostgresMagicLinkRepository implementação PostgreSQL do MagicLinkRepository
 type PostgresMagicLinkRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewPostgresMagicLinkRepository cria uma nova instância do repositório
-func NewPostgresMagicLinkRepository(db *sql.DB) *PostgresMagicLinkRepository {
+func NewPostgresMagicLinkRepository(db DBTX) *PostgresMagicLinkRepository {
 	return &PostgresMagicLinkRepository{db: db}
 }
 
+const magicLinkColumns = `
+	id, email, token, code_hash, device_nonce, attempts, used, expires_at, created_at`
+
+func scanMagicLink(row rowScanner) (*models.MagicLink, error) {
+	link := &models.MagicLink{}
+	err := row.Scan(
+		&link.ID, &link.Email, &link.Token, &link.CodeHash, &link.DeviceNonce, &link.Attempts,
+		&link.Used, &link.ExpiresAt, &link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
 // Create cria um novo magic link
 func (r *PostgresMagicLinkRepository) Create(ctx context.Context, link *models.MagicLink) error {
 	query := `
-		INSERT INTO magic_links (id, email, token, used, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		INSERT INTO magic_links (` + magicLinkColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	_, err := r.db.ExecContext(ctx, query,
-		link.ID, link.Email, link.Token, link.Used, link.ExpiresAt, link.CreatedAt,
+		link.ID, link.Email, link.Token, link.CodeHash, link.DeviceNonce, link.Attempts,
+		link.Used, link.ExpiresAt, link.CreatedAt,
 	)
 
 	if err != nil {
@@ -294,18 +684,21 @@ func (r *PostgresMagicLinkRepository) Create(ctx context.Context, link *models.M
 
 // GetByToken busca magic link por token
 func (r *PostgresMagicLinkRepository) GetByToken(ctx context.Context, token string) (*models.MagicLink, error) {
-	query := `
-		SELECT id, email, token, used, expires_at, created_at
-		FROM magic_links WHERE token = $1`
+	return r.getBy(ctx, "token", token)
+}
 
-	link := &models.MagicLink{}
-	err := r.db.QueryRowContext(ctx, query, token).Scan(
-		&link.ID, &link.Email, &link.Token, &link.Used, &link.ExpiresAt, &link.CreatedAt,
-	)
+// GetByDeviceNonce busca magic link pelo hash do nonce do dispositivo
+func (r *PostgresMagicLinkRepository) GetByDeviceNonce(ctx context.Context, nonce string) (*models.MagicLink, error) {
+	return r.getBy(ctx, "device_nonce", nonce)
+}
+
+func (r *PostgresMagicLinkRepository) getBy(ctx context.Context, column, value string) (*models.MagicLink, error) {
+	query := `SELECT` + magicLinkColumns + ` FROM magic_links WHERE ` + column + ` = $1`
 
+	link, err := scanMagicLink(r.db.QueryRowContext(ctx, query, value))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("magic link not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get magic link: %w", err)
 	}
@@ -313,9 +706,31 @@ func (r *PostgresMagicLinkRepository) GetByToken(ctx context.Context, token stri
 	return link, nil
 }
 
-// MarkAsUsed marca o magic link como usado
+// ClaimAttempt reserva uma tentativa de código antes da comparação e retorna
+// o total. Retorna ErrNotFound se o link já foi usado ou se as maxAttempts
+// tentativas se esgotaram, inclusive por requisições concorrentes.
+func (r *PostgresMagicLinkRepository) ClaimAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (int, error) {
+	query := `
+		UPDATE magic_links SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2 AND used = false
+		RETURNING attempts`
+
+	var attempts int
+	err := r.db.QueryRowContext(ctx, query, id, maxAttempts).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record magic link attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// MarkAsUsed consome o magic link de forma atômica; retorna ErrAlreadyUsed se
+// ele já tiver sido usado por outra requisição, pelo link ou pelo código
 func (r *PostgresMagicLinkRepository) MarkAsUsed(ctx context.Context, token string) error {
-	query := `UPDATE magic_links SET used = true WHERE token = $1`
+	query := `UPDATE magic_links SET used = true WHERE token = $1 AND used = false`
 
 	result, err := r.db.ExecContext(ctx, query, token)
 	if err != nil {
@@ -328,22 +743,22 @@ func (r *PostgresMagicLinkRepository) MarkAsUsed(ctx context.Context, token stri
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("magic link not found")
+		return ErrAlreadyUsed
 	}
 
 	return nil
 }
 
-// DeleteExpired remove magic links expirados
-func (r *PostgresMagicLinkRepository) DeleteExpired(ctx context.Context) error {
+// DeleteExpired remove magic links expirados e retorna quantos foram removidos
+func (r *PostgresMagicLinkRepository) DeleteExpired(ctx context.Context) (int64, error) {
 	query := `DELETE FROM magic_links WHERE expires_at < $1`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now())
+	result, err := r.db.ExecContext(ctx, query, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to delete expired magic links: %w", err)
+		return 0, fmt.Errorf("failed to delete expired magic links: %w", err)
 	}
 
-	return nil
+	return result.RowsAffected()
 }
 
 // DeleteByEmail remove magic links por email
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresRoleRepository implementação PostgreSQL do RoleRepository
+type PostgresRoleRepository struct {
+	db DBTX
+}
+
+// NewPostgresRoleRepository cria uma nova instância do repositório
+func NewPostgresRoleRepository(db DBTX) *PostgresRoleRepository {
+	return &PostgresRoleRepository{db: db}
+}
+
+// roleSelect papéis com os nomes das permissões agregados
+const roleSelect = `
+	SELECT r.id, r.name, r.description, r.created_at, r.updated_at,
+		COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+	LEFT JOIN permissions p ON p.id = rp.permission_id`
+
+func scanRole(row rowScanner) (*models.Role, error) {
+	role := &models.Role{}
+	err := row.Scan(
+		&role.ID, &role.Name, &role.Description, &role.CreatedAt, &role.UpdatedAt,
+		pq.Array(&role.Permissions),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// EnsureDefaults cria as permissões e papéis informados caso ainda não
+// existam e sincroniza as permissões de cada papel. Idempotente; executado
+// na inicialização do serviço.
+func (r *PostgresRoleRepository) EnsureDefaults(ctx context.Context, permissions []models.Permission, roles []models.Role) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	permissionQuery := `
+		INSERT INTO permissions (id, name, resource, action, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (name) DO UPDATE SET
+			description = EXCLUDED.description,
+			updated_at = EXCLUDED.updated_at`
+
+	for _, permission := range permissions {
+		_, err := tx.ExecContext(ctx, permissionQuery,
+			uuid.New(), permission.Name, permission.Resource, permission.Action, permission.Description, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to ensure permission %s: %w", permission.Name, err)
+		}
+	}
+
+	roleQuery := `
+		INSERT INTO roles (id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			description = EXCLUDED.description,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id`
+
+	for _, role := range roles {
+		var roleID uuid.UUID
+		err := tx.QueryRowContext(ctx, roleQuery, uuid.New(), role.Name, role.Description, now).Scan(&roleID)
+		if err != nil {
+			return fmt.Errorf("failed to ensure role %s: %w", role.Name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+			return fmt.Errorf("failed to reset role permissions: %w", err)
+		}
+
+		grantQuery := `
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT $1, id FROM permissions WHERE name = ANY($2)`
+
+		if _, err := tx.ExecContext(ctx, grantQuery, roleID, pq.Array(role.Permissions)); err != nil {
+			return fmt.Errorf("failed to grant permissions to role %s: %w", role.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit roles: %w", err)
+	}
+
+	return nil
+}
+
+// GetByName busca um papel pelo nome
+func (r *PostgresRoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	query := roleSelect + ` WHERE r.name = $1 GROUP BY r.id`
+
+	role, err := scanRole(r.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return role, nil
+}
+
+// List lista todos os papéis
+func (r *PostgresRoleRepository) List(ctx context.Context) ([]*models.Role, error) {
+	return r.query(ctx, roleSelect+` GROUP BY r.id ORDER BY r.name`)
+}
+
+// ListByUserID lista os papéis atribuídos ao usuário
+func (r *PostgresRoleRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Role, error) {
+	query := roleSelect + `
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = $1
+		GROUP BY r.id
+		ORDER BY r.name`
+
+	return r.query(ctx, query, userID)
+}
+
+func (r *PostgresRoleRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.Role, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// AssignToUser atribui o papel ao usuário; retorna ErrNotFound se o papel
+// não existir. Atribuir um papel já atribuído não tem efeito.
+func (r *PostgresRoleRepository) AssignToUser(ctx context.Context, userID uuid.UUID, roleName string) error {
+	query := `
+		INSERT INTO user_roles (user_id, role_id, created_at)
+		SELECT $1, id, NOW() FROM roles WHERE name = $2
+		ON CONFLICT (user_id, role_id) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, userID, roleName)
+	if err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		// Distinguir papel inexistente de papel já atribuído
+		if _, err := r.GetByName(ctx, roleName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveFromUser remove o papel do usuário
+func (r *PostgresRoleRepository) RemoveFromUser(ctx context.Context, userID uuid.UUID, roleName string) error {
+	query := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)`
+
+	result, err := r.db.ExecContext(ctx, query, userID, roleName)
+	if err != nil {
+		return fmt.Errorf("failed to remove role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PostgresSessionRepository implementação PostgreSQL do SessionRepository
+type PostgresSessionRepository struct {
+	db DBTX
+}
+
+// NewPostgresSessionRepository cria uma nova instância do repositório
+func NewPostgresSessionRepository(db DBTX) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+const sessionColumns = `
+	id, user_id, device_id, active_org_id, ip_address, user_agent, expires_at,
+	last_seen_at, created_at, revoked_at`
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+	err := row.Scan(
+		&session.ID, &session.UserID, &session.DeviceID, &session.ActiveOrgID,
+		&session.IPAddress, &session.UserAgent, &session.ExpiresAt,
+		&session.LastSeenAt, &session.CreatedAt, &session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Create cria uma nova sessão
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO sessions (` + sessionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.UserID, session.DeviceID, session.ActiveOrgID,
+		session.IPAddress, session.UserAgent, session.ExpiresAt,
+		session.LastSeenAt, session.CreatedAt, session.RevokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID busca sessão por ID
+func (r *PostgresSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	query := `SELECT` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListActiveByUserID lista as sessões não revogadas e não expiradas do usuário
+func (r *PostgresSessionRepository) ListActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	query := `
+		SELECT` + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// ListByUserID lista todas as sessões do usuário, inclusive revogadas e expiradas
+func (r *PostgresSessionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	query := `
+		SELECT` + sessionColumns + ` FROM sessions
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Touch registra atividade na sessão (refresh) e estende sua validade
+func (r *PostgresSessionRepository) Touch(ctx context.Context, id uuid.UUID, ipAddress *string, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions
+		SET last_seen_at = NOW(), ip_address = COALESCE($2, ip_address), expires_at = $3
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, ipAddress, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
+// SetActiveOrganization define a organização ativa da sessão (nil para
+// nenhuma); retorna ErrNotFound se a sessão não estiver ativa
+func (r *PostgresSessionRepository) SetActiveOrganization(ctx context.Context, userID, id uuid.UUID, orgID *uuid.UUID) error {
+	query := `UPDATE sessions SET active_org_id = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to set active organization: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Revoke revoga uma sessão ativa do usuário
+func (r *PostgresSessionRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllByUserID revoga todas as sessões ativas do usuário
+func (r *PostgresSessionRepository) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired remove sessões expiradas e retorna quantas foram removidas
+func (r *PostgresSessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM sessions WHERE expires_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
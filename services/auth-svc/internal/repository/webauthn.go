@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresWebAuthnCredentialRepository implementação PostgreSQL do WebAuthnCredentialRepository
+type PostgresWebAuthnCredentialRepository struct {
+	db DBTX
+}
+
+// NewPostgresWebAuthnCredentialRepository cria uma nova instância do repositório
+func NewPostgresWebAuthnCredentialRepository(db DBTX) *PostgresWebAuthnCredentialRepository {
+	return &PostgresWebAuthnCredentialRepository{db: db}
+}
+
+const webAuthnColumns = `
+	id, user_id, name, credential_id, public_key, attestation_type, transports,
+	aaguid, sign_count, backup_eligible, backup_state, last_used_at, created_at`
+
+func scanWebAuthnCredential(row rowScanner) (*models.WebAuthnCredential, error) {
+	credential := &models.WebAuthnCredential{}
+	var signCount int64
+	err := row.Scan(
+		&credential.ID, &credential.UserID, &credential.Name, &credential.CredentialID,
+		&credential.PublicKey, &credential.AttestationType, pq.Array(&credential.Transports),
+		&credential.AAGUID, &signCount, &credential.BackupEligible, &credential.BackupState,
+		&credential.LastUsedAt, &credential.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	credential.SignCount = uint32(signCount)
+	return credential, nil
+}
+
+// Create registra uma nova credencial
+func (r *PostgresWebAuthnCredentialRepository) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (` + webAuthnColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		credential.ID, credential.UserID, credential.Name, credential.CredentialID,
+		credential.PublicKey, credential.AttestationType, pq.Array(credential.Transports),
+		credential.AAGUID, int64(credential.SignCount), credential.BackupEligible,
+		credential.BackupState, credential.LastUsedAt, credential.CreatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("webauthn credential already registered")
+		}
+		return fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+
+	return nil
+}
+
+// GetByCredentialID busca credencial pelo ID gerado pelo autenticador
+func (r *PostgresWebAuthnCredentialRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	query := `SELECT` + webAuthnColumns + ` FROM webauthn_credentials WHERE credential_id = $1`
+
+	credential, err := scanWebAuthnCredential(r.db.QueryRowContext(ctx, query, credentialID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+// ListByUserID lista as credenciais do usuário
+func (r *PostgresWebAuthnCredentialRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	query := `SELECT` + webAuthnColumns + ` FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []*models.WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webauthn credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// UpdateUsage registra o uso da credencial (contador de assinaturas e backup)
+func (r *PostgresWebAuthnCredentialRepository) UpdateUsage(ctx context.Context, id uuid.UUID, signCount uint32, backupState bool) error {
+	query := `
+		UPDATE webauthn_credentials
+		SET sign_count = $2, backup_state = $3, last_used_at = NOW()
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, int64(signCount), backupState)
+	if err != nil {
+		return fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+
+	return nil
+}
+
+// Delete remove uma credencial do usuário
+func (r *PostgresWebAuthnCredentialRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	query := `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
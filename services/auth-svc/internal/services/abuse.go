@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/captcha"
+	"pagemagic/auth-svc/internal/ratelimit"
+)
+
+// AbuseAction endpoint público protegido contra abuso
+type AbuseAction string
+
+const (
+	AbuseActionMagicLink     AbuseAction = "magic_link"
+	AbuseActionVerify        AbuseAction = "verify"
+	AbuseActionLogin         AbuseAction = "login"
+	AbuseActionRegister      AbuseAction = "register"
+	AbuseActionPasswordReset AbuseAction = "password_reset"
+)
+
+const (
+	abuseCooldownBase  = time.Minute
+	abuseCooldownMax   = time.Hour
+	abuseStrikesWindow = 24 * time.Hour
+)
+
+// abuseRule limite de uma dimensão (email, IP ou sub-rede). Acima de
+// challengeAfter eventos na janela o cliente precisa resolver um CAPTCHA;
+// acima de limit a requisição é recusada e a chave entra em cool-down.
+type abuseRule struct {
+	dimension      string
+	window         time.Duration
+	challengeAfter int
+	limit          int
+}
+
+var abuseRules = map[AbuseAction][]abuseRule{
+	AbuseActionMagicLink: {
+		{dimension: "email", window: time.Hour, challengeAfter: 3, limit: 5},
+		{dimension: "ip", window: time.Hour, challengeAfter: 10, limit: 20},
+		{dimension: "subnet", window: time.Hour, challengeAfter: 30, limit: 60},
+	},
+	AbuseActionVerify: {
+		{dimension: "ip", window: 15 * time.Minute, challengeAfter: 10, limit: 30},
+		{dimension: "subnet", window: 15 * time.Minute, challengeAfter: 50, limit: 100},
+	},
+	// O bloqueio por falhas protege uma conta; estes limites contêm o
+	// password spraying, que tenta poucas senhas em muitas contas
+	AbuseActionLogin: {
+		{dimension: "email", window: 15 * time.Minute, challengeAfter: 5, limit: 20},
+		{dimension: "ip", window: 15 * time.Minute, challengeAfter: 20, limit: 100},
+		{dimension: "subnet", window: 15 * time.Minute, challengeAfter: 100, limit: 300},
+	},
+	AbuseActionPasswordReset: {
+		{dimension: "email", window: time.Hour, challengeAfter: 3, limit: 5},
+		{dimension: "ip", window: time.Hour, challengeAfter: 10, limit: 20},
+		{dimension: "subnet", window: time.Hour, challengeAfter: 30, limit: 60},
+	},
+	AbuseActionRegister: {
+		{dimension: "email", window: time.Hour, challengeAfter: 3, limit: 10},
+		{dimension: "ip", window: time.Hour, challengeAfter: 10, limit: 30},
+		{dimension: "subnet", window: time.Hour, challengeAfter: 30, limit: 100},
+	},
+}
+
+// ErrChallengeRequired o cliente precisa enviar uma resposta de CAPTCHA válida
+var ErrChallengeRequired = errors.New("challenge required")
+
+// RateLimitError limite ultrapassado; RetryAfter indica quando tentar de novo
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+}
+
+// AbuseSubject origem da requisição avaliada pelos limites
+type AbuseSubject struct {
+	Email string
+	IP    string
+}
+
+// AbuseGuard aplica limites de janela deslizante por email, IP e sub-rede
+// (/24 no IPv4, /48 no IPv6), com cool-downs exponenciais e desafio CAPTCHA
+// opcional. Sem verificador configurado, apenas os limites rígidos valem.
+type AbuseGuard struct {
+	limiter  *ratelimit.Limiter
+	verifier captcha.Verifier
+}
+
+func NewAbuseGuard(limiter *ratelimit.Limiter, verifier captcha.Verifier) *AbuseGuard {
+	return &AbuseGuard{
+		limiter:  limiter,
+		verifier: verifier,
+	}
+}
+
+// Check registra a tentativa e decide se ela pode prosseguir. Retorna
+// *RateLimitError ou ErrChallengeRequired. Falhas do Redis não bloqueiam o
+// login: são registradas e a requisição é liberada. Já uma falha do
+// verificador de CAPTCHA mantém o desafio, pois os limites já foram cruzados.
+func (g *AbuseGuard) Check(ctx context.Context, action AbuseAction, subject AbuseSubject, captchaResponse string) error {
+	err := g.check(ctx, action, subject, captchaResponse)
+
+	var limited *RateLimitError
+	if err != nil && !errors.Is(err, ErrChallengeRequired) && !errors.As(err, &limited) {
+		log.Printf("Abuse guard unavailable for %s: %v", action, err)
+		return nil
+	}
+
+	return err
+}
+
+func (g *AbuseGuard) check(ctx context.Context, action AbuseAction, subject AbuseSubject, captchaResponse string) error {
+	type target struct {
+		rule abuseRule
+		key  string
+	}
+
+	var targets []target
+	for _, rule := range abuseRules[action] {
+		id := abuseKey(rule.dimension, subject)
+		if id == "" {
+			continue
+		}
+		targets = append(targets, target{rule: rule, key: fmt.Sprintf("%s:%s:%s", action, rule.dimension, id)})
+	}
+
+	// Durante o cool-down as tentativas não são contabilizadas
+	for _, t := range targets {
+		remaining, err := g.limiter.Cooldown(ctx, t.key)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return &RateLimitError{RetryAfter: remaining}
+		}
+	}
+
+	challenge := false
+	for _, t := range targets {
+		count, _, err := g.limiter.Hit(ctx, t.key, t.rule.window)
+		if err != nil {
+			return err
+		}
+
+		if count > t.rule.limit {
+			cooldown, err := g.limiter.Strike(ctx, t.key, abuseCooldownBase, abuseCooldownMax, abuseStrikesWindow)
+			if err != nil {
+				return err
+			}
+			return &RateLimitError{RetryAfter: cooldown}
+		}
+
+		if count > t.rule.challengeAfter {
+			challenge = true
+		}
+	}
+
+	if !challenge || g.verifier == nil {
+		return nil
+	}
+
+	ok, err := g.verifier.Verify(ctx, captchaResponse, subject.IP)
+	if err != nil {
+		log.Printf("Captcha verification failed for %s: %v", action, err)
+		return ErrChallengeRequired
+	}
+	if !ok {
+		return ErrChallengeRequired
+	}
+
+	return nil
+}
+
+// abuseKey identificador da dimensão; emails são armazenados como hash
+func abuseKey(dimension string, subject AbuseSubject) string {
+	switch dimension {
+	case "email":
+		email := strings.ToLower(strings.TrimSpace(subject.Email))
+		if email == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(email))
+		return hex.EncodeToString(sum[:16])
+	case "ip":
+		return subject.IP
+	case "subnet":
+		return subnetOf(subject.IP)
+	default:
+		return ""
+	}
+}
+
+// subnetOf retorna a sub-rede /24 (IPv4) ou /48 (IPv6) do endereço
+func subnetOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/captcha"
+	"pagemagic/auth-svc/internal/ratelimit"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAbuseGuard(t *testing.T, verifier captcha.Verifier) (*AbuseGuard, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewAbuseGuard(ratelimit.New(client, "test:"), verifier), mr
+}
+
+// requireRateLimited verifica o erro e retorna o tempo de espera informado
+func requireRateLimited(t *testing.T, err error) time.Duration {
+	t.Helper()
+
+	var limited *RateLimitError
+	require.ErrorAs(t, err, &limited)
+	return limited.RetryAfter
+}
+
+func TestAbuseGuardChallengeThenLimit(t *testing.T) {
+	guard, _ := newTestAbuseGuard(t, captcha.Fake{})
+	ctx := context.Background()
+	subject := AbuseSubject{Email: "ana@example.com", IP: "203.0.113.7"}
+
+	// Magic link por email: desafio acima de 3, recusa acima de 5 na hora
+	for i := 0; i < 3; i++ {
+		require.NoError(t, guard.Check(ctx, AbuseActionMagicLink, subject, ""))
+	}
+
+	assert.ErrorIs(t, guard.Check(ctx, AbuseActionMagicLink, subject, "wrong"), ErrChallengeRequired)
+
+	// As tentativas recusadas também contam: esta é a quinta
+	assert.NoError(t, guard.Check(ctx, AbuseActionMagicLink, subject, captcha.FakePassToken))
+
+	// Acima do limite nem o CAPTCHA libera
+	retryAfter := requireRateLimited(t, guard.Check(ctx, AbuseActionMagicLink, subject, captcha.FakePassToken))
+	assert.Equal(t, abuseCooldownBase, retryAfter)
+
+	// Durante o cool-down a resposta informa o tempo restante
+	retryAfter = requireRateLimited(t, guard.Check(ctx, AbuseActionMagicLink, subject, captcha.FakePassToken))
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, abuseCooldownBase)
+}
+
+func TestAbuseGuardCooldownDoubles(t *testing.T) {
+	guard, mr := newTestAbuseGuard(t, nil)
+	ctx := context.Background()
+	subject := AbuseSubject{Email: "ana@example.com", IP: "203.0.113.7"}
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, guard.Check(ctx, AbuseActionMagicLink, subject, ""))
+	}
+	assert.Equal(t, abuseCooldownBase, requireRateLimited(t, guard.Check(ctx, AbuseActionMagicLink, subject, "")))
+
+	mr.FastForward(abuseCooldownBase)
+	assert.Equal(t, 2*abuseCooldownBase, requireRateLimited(t, guard.Check(ctx, AbuseActionMagicLink, subject, "")))
+}
+
+func TestAbuseGuardWithoutVerifierSkipsChallenge(t *testing.T) {
+	guard, _ := newTestAbuseGuard(t, nil)
+	ctx := context.Background()
+	subject := AbuseSubject{Email: "ana@example.com", IP: "203.0.113.7"}
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, guard.Check(ctx, AbuseActionMagicLink, subject, ""))
+	}
+	requireRateLimited(t, guard.Check(ctx, AbuseActionMagicLink, subject, ""))
+}
+
+func TestAbuseGuardSeparatesEmails(t *testing.T) {
+	guard, _ := newTestAbuseGuard(t, captcha.Fake{})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, guard.Check(ctx, AbuseActionMagicLink, AbuseSubject{Email: "ana@example.com", IP: "203.0.113.7"}, ""))
+	}
+
+	// O email é normalizado antes de compor a chave
+	assert.ErrorIs(t, guard.Check(ctx, AbuseActionMagicLink, AbuseSubject{Email: " ANA@example.com", IP: "203.0.113.7"}, ""), ErrChallengeRequired)
+	assert.NoError(t, guard.Check(ctx, AbuseActionMagicLink, AbuseSubject{Email: "bia@example.com", IP: "203.0.113.7"}, ""))
+}
+
+func TestAbuseGuardLimitsSubnet(t *testing.T) {
+	guard, _ := newTestAbuseGuard(t, nil)
+	ctx := context.Background()
+
+	// Verify: 30 por IP e 100 por sub-rede; 100 IPs da mesma /24 esgotam a sub-rede
+	for i := 0; i < 100; i++ {
+		require.NoError(t, guard.Check(ctx, AbuseActionVerify, AbuseSubject{IP: fmt.Sprintf("203.0.113.%d", i)}, ""))
+	}
+
+	requireRateLimited(t, guard.Check(ctx, AbuseActionVerify, AbuseSubject{IP: "203.0.113.200"}, ""))
+	assert.NoError(t, guard.Check(ctx, AbuseActionVerify, AbuseSubject{IP: "203.0.114.1"}, ""))
+}
+
+func TestAbuseGuardFailsOpen(t *testing.T) {
+	guard, mr := newTestAbuseGuard(t, captcha.Fake{})
+	mr.Close()
+
+	assert.NoError(t, guard.Check(context.Background(), AbuseActionLogin, AbuseSubject{Email: "ana@example.com", IP: "203.0.113.7"}, ""))
+}
+
+// failingVerifier simula o provedor de CAPTCHA fora do ar
+type failingVerifier struct{}
+
+func (failingVerifier) Verify(ctx context.Context, response, remoteIP string) (bool, error) {
+	return false, errors.New("captcha provider timeout")
+}
+
+func TestAbuseGuardKeepsChallengeWhenVerifierFails(t *testing.T) {
+	guard, _ := newTestAbuseGuard(t, failingVerifier{})
+	ctx := context.Background()
+	subject := AbuseSubject{Email: "ana@example.com", IP: "203.0.113.7"}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, guard.Check(ctx, AbuseActionMagicLink, subject, ""))
+	}
+
+	assert.ErrorIs(t, guard.Check(ctx, AbuseActionMagicLink, subject, captcha.FakePassToken), ErrChallengeRequired)
+}
+
+func TestSubnetOf(t *testing.T) {
+	assert.Equal(t, "203.0.113.0/24", subnetOf("203.0.113.7"))
+	assert.Equal(t, "2001:db8:1::/48", subnetOf("2001:db8:1:2::1"))
+	assert.Equal(t, "", subnetOf("not-an-ip"))
+}
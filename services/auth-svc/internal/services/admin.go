@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+var (
+	// ErrAccountSuspended conta suspensa ou desativada; login, refresh e
+	// validação de tokens são recusados
+	ErrAccountSuspended = errors.New("account suspended")
+	// ErrCannotSuspendSelf um administrador não pode suspender a própria conta
+	ErrCannotSuspendSelf = errors.New("cannot suspend own account")
+	// ErrUserAlreadySuspended a conta já está suspensa
+	ErrUserAlreadySuspended = errors.New("user already suspended")
+	// ErrUserNotSuspended apenas contas suspensas podem ser reativadas
+	ErrUserNotSuspended = errors.New("user not suspended")
+)
+
+// UserPage página da busca de usuários; NextCursor vazio indica o fim
+type UserPage struct {
+	Users      []*models.User
+	NextCursor string
+}
+
+// UserDetails usuário com as identidades vinculadas e as sessões ativas
+type UserDetails struct {
+	User      *models.User
+	Providers []*models.UserAuthProvider
+	Sessions  []*models.Session
+}
+
+// SearchUsers retorna uma página de usuários, do mais recente para o mais
+// antigo
+func (s *AuthService) SearchUsers(ctx context.Context, filter models.UserFilter, cursor string) (*UserPage, error) {
+	if cursor != "" {
+		beforeTime, beforeID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.BeforeTime = &beforeTime
+		filter.BeforeID = &beforeID
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+
+	// Um item extra indica se há próxima página
+	pageSize := filter.Limit
+	filter.Limit++
+
+	users, err := s.userRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &UserPage{Users: users}
+	if len(users) > pageSize {
+		page.Users = users[:pageSize]
+		last := page.Users[pageSize-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+// GetUserDetails retorna o usuário com identidades e sessões ativas
+func (s *AuthService) GetUserDetails(ctx context.Context, userID uuid.UUID) (*UserDetails, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := s.providerRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDetails{
+		User:      user,
+		Providers: providers,
+		Sessions:  sessions,
+	}, nil
+}
+
+// SuspendUser suspende a conta e encerra todas as sessões. Access tokens
+// ainda válidos e tokens de acesso pessoal passam a ser recusados na
+// validação, que confere o status do usuário.
+func (s *AuthService) SuspendUser(ctx context.Context, actorID, userID uuid.UUID, reason string) (user *models.User, err error) {
+	defer func() {
+		var metadata map[string]interface{}
+		if reason != "" {
+			metadata = map[string]interface{}{"reason": reason}
+		}
+		s.audit(ctx, models.AuditUserSuspended, &userID, err, metadata)
+	}()
+
+	if actorID == userID {
+		return nil, ErrCannotSuspendSelf
+	}
+
+	user, err = s.setUserStatus(ctx, userID, models.UserStatusSuspended, func(current models.UserStatus) error {
+		if current == models.UserStatusSuspended {
+			return ErrUserAlreadySuspended
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.LogoutAll(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ReactivateUser reativa uma conta suspensa; o usuário precisa entrar de novo
+func (s *AuthService) ReactivateUser(ctx context.Context, userID uuid.UUID) (user *models.User, err error) {
+	defer func() {
+		s.audit(ctx, models.AuditUserReactivated, &userID, err, nil)
+	}()
+
+	return s.setUserStatus(ctx, userID, models.UserStatusActive, func(current models.UserStatus) error {
+		if current != models.UserStatusSuspended {
+			return ErrUserNotSuspended
+		}
+		return nil
+	})
+}
+
+// ForceLogout encerra todas as sessões do usuário sem alterar a conta
+func (s *AuthService) ForceLogout(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.LogoutAll(ctx, userID)
+}
+
+// setUserStatus altera o status após check aprovar o status atual e
+// publica user.updated. A linha fica bloqueada durante a transação para que
+// exclusões concorrentes não se percam; contas excluídas não são alteradas.
+func (s *AuthService) setUserStatus(ctx context.Context, userID uuid.UUID, status models.UserStatus, check func(current models.UserStatus) error) (*models.User, error) {
+	var user *models.User
+	err := s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		var err error
+		user, err = tx.User.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.Status == models.UserStatusDeleted {
+			return repository.ErrNotFound
+		}
+		if err := check(user.Status); err != nil {
+			return err
+		}
+
+		if err := tx.User.UpdateStatus(ctx, userID, status); err != nil {
+			return err
+		}
+		user.Status = status
+
+		return enqueueEvent(ctx, tx, events.UserUpdated, events.NewUserData(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/password"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/sms"
+	"pagemagic/auth-svc/pkg/authz"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// ErrInvalidCursor cursor de paginação malformado
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// auditReasons erros esperados cuja mensagem é registrada como motivo da
+// falha; os demais aparecem como "internal error" para não expor detalhes
+// de infraestrutura no histórico do usuário
+var auditReasons = []error{
+	ErrInvalidCredentials, ErrAccountLocked, ErrEmailAlreadyRegistered, ErrRecentLoginRequired,
+	ErrInvalidMagicLink, ErrInvalidRefreshToken, ErrRefreshTokenReused, ErrSessionRevoked,
+	ErrInvalidMFACode, ErrInvalidMFAToken, ErrMFAAlreadyEnabled, ErrMFANotEnabled,
+	ErrInvalidOAuthState, ErrOAuthEmailRequired, ErrOAuthEmailNotVerified, ErrOAuthSignupEmailNotVerified,
+	ErrIdentityLinkedToOtherUser, ErrLastLoginMethod, ErrEmailIdentityManaged,
+	ErrInvalidWebAuthnSession, ErrInvalidPasskey, ErrInvalidResetToken,
+	ErrInvalidScope, ErrInvalidExpiry, ErrTooManyTokens, ErrRoleNotFound,
+	ErrInvalidAvatarURL, ErrInvalidTimezone,
+	ErrAccountSuspended, ErrCannotSuspendSelf, ErrUserAlreadySuspended, ErrUserNotSuspended,
+	ErrExportInProgress, ErrExportNotReady, ErrDeletionAlreadyScheduled, ErrDeletionNotScheduled,
+	ErrSoleOrganizationOwner, ErrEmailUnchanged, ErrInvalidEmailChange,
+	ErrSMSUnavailable, ErrInvalidSMSCode, ErrMobileNotVerified, ErrSMSMFAAlreadyEnabled, ErrSMSMFANotEnabled,
+	sms.ErrInvalidPhoneNumber,
+	password.ErrTooShort, password.ErrTooLong, password.ErrTooCommon, password.ErrContainsUser,
+	repository.ErrNotFound,
+}
+
+// AuditLogPage página do log de auditoria; NextCursor vazio indica o fim
+type AuditLogPage struct {
+	Entries    []*models.AuditLog
+	NextCursor string
+}
+
+// audit registra um evento no log de auditoria com IP e user agent da
+// requisição. O ator é o usuário autenticado na requisição ou, na sua
+// ausência, o próprio dono da conta. Falhas de gravação são apenas
+// registradas em log para não interromper o fluxo de autenticação.
+func (s *AuthService) audit(ctx context.Context, action models.AuditAction, userID *uuid.UUID, err error, metadata map[string]interface{}) {
+	client := ClientInfoFromContext(ctx)
+
+	entry := &models.AuditLog{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ActorID:   userID,
+		Action:    action,
+		Result:    models.AuditResultSuccess,
+		IPAddress: optionalString(client.IPAddress),
+		UserAgent: optionalString(client.UserAgent),
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+	}
+
+	if claims, ok := authz.ClaimsFromContext(ctx); ok {
+		if actorID, parseErr := uuid.Parse(claims.UserID); parseErr == nil {
+			entry.ActorID = &actorID
+		}
+		if sessionID, parseErr := uuid.Parse(claims.SessionID); parseErr == nil {
+			entry.SessionID = &sessionID
+		}
+	}
+
+	if err != nil {
+		entry.Result = models.AuditResultFailure
+		if entry.Metadata == nil {
+			entry.Metadata = map[string]interface{}{}
+		}
+		entry.Metadata["reason"] = auditReason(err)
+	}
+
+	// A gravação não deve ser perdida se o cliente encerrar a requisição
+	if err := s.auditRepo.Create(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("Failed to record audit event %s: %v", action, err)
+	}
+}
+
+// auditAuth registra um evento de login. Sem userID (falhas antes de
+// identificar a conta ou contas criadas no login) o usuário vem da resposta.
+func (s *AuthService) auditAuth(ctx context.Context, action models.AuditAction, userID *uuid.UUID, auth *models.AuthResponse, err error, metadata map[string]interface{}) {
+	if auth != nil && auth.User != nil {
+		if userID == nil {
+			userID = &auth.User.ID
+		}
+		if auth.MFAToken != "" {
+			if metadata == nil {
+				metadata = map[string]interface{}{}
+			}
+			metadata["mfa_required"] = true
+		}
+	}
+
+	s.audit(ctx, action, userID, err, metadata)
+}
+
+func auditReason(err error) string {
+	for _, known := range auditReasons {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return "internal error"
+}
+
+// ListAuditLogs retorna uma página do log de auditoria, da entrada mais
+// recente para a mais antiga
+func (s *AuthService) ListAuditLogs(ctx context.Context, filter models.AuditLogFilter, cursor string) (*AuditLogPage, error) {
+	if cursor != "" {
+		beforeTime, beforeID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.BeforeTime = &beforeTime
+		filter.BeforeID = &beforeID
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+
+	// Um item extra indica se há próxima página
+	pageSize := filter.Limit
+	filter.Limit++
+
+	entries, err := s.auditRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AuditLogPage{Entries: entries}
+	if len(entries) > pageSize {
+		page.Entries = entries[:pageSize]
+		last := page.Entries[pageSize-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+// ExportAuditLogs percorre todas as entradas do filtro, sem paginação
+func (s *AuthService) ExportAuditLogs(ctx context.Context, filter models.AuditLogFilter, fn func(*models.AuditLog) error) error {
+	filter.Limit = 0
+	return s.auditRepo.Stream(ctx, filter, fn)
+}
+
+// encodeCursor cursor opaco com a posição (created_at, id) do último item
+// da página; usado no log de auditoria e na busca de usuários
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	return parsedTime, parsedID, nil
+}
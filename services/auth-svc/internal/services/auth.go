@@ -3,202 +3,550 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strings"
 	"time"
 
 	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/mailer"
 	"pagemagic/auth-svc/internal/models"
 	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/signing"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type AuthService struct {
-	userRepo    repository.UserRepository
-	magicRepo   repository.MagicLinkRepository
-	refreshRepo repository.RefreshTokenRepository
-	config      *config.Config
+	repo            *repository.Repository
+	userRepo        repository.UserRepository
+	magicRepo       repository.MagicLinkRepository
+	refreshRepo     repository.RefreshTokenRepository
+	providerRepo    repository.AuthProviderRepository
+	resetRepo       repository.PasswordResetRepository
+	recoveryRepo    repository.RecoveryCodeRepository
+	webauthnRepo    repository.WebAuthnCredentialRepository
+	sessionRepo     repository.SessionRepository
+	deviceRepo      repository.DeviceRepository
+	roleRepo        repository.RoleRepository
+	orgRepo         repository.OrganizationRepository
+	patRepo         repository.PersonalAccessTokenRepository
+	auditRepo       repository.AuditLogRepository
+	emailChangeRepo repository.EmailChangeRepository
+	keys            *signing.KeySet
+	denylist        TokenDenylist
+	mailer          mailer.Mailer
+	config          *config.Config
 }
 
-func NewAuthService(repo *repository.Repository, config *config.Config) *AuthService {
+const (
+	magicLinkTTL    = 15 * time.Minute
+	magicCodeDigits = 6
+	// magicCodeMaxAttempts códigos errados tolerados por link
+	magicCodeMaxAttempts = 5
+)
+
+// ErrRefreshTokenReused indica que um refresh token já rotacionado foi
+// reapresentado; a família inteira é revogada
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// ErrInvalidAccessToken access token inválido, expirado ou revogado
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+// ErrInvalidRefreshToken refresh token inválido, expirado ou revogado
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrInvalidMagicLink magic link inexistente, expirado ou já usado
+var ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+
+func NewAuthService(repo *repository.Repository, keys *signing.KeySet, denylist TokenDenylist, mail mailer.Mailer, config *config.Config) *AuthService {
 	return &AuthService{
-		userRepo:    repo.User,
-		magicRepo:   repo.MagicLink,
-		refreshRepo: repo.RefreshToken,
-		config:      config,
+		repo:            repo,
+		userRepo:        repo.User,
+		magicRepo:       repo.MagicLink,
+		refreshRepo:     repo.RefreshToken,
+		providerRepo:    repo.AuthProvider,
+		resetRepo:       repo.PasswordReset,
+		recoveryRepo:    repo.RecoveryCode,
+		webauthnRepo:    repo.WebAuthn,
+		sessionRepo:     repo.Session,
+		deviceRepo:      repo.Device,
+		roleRepo:        repo.Role,
+		orgRepo:         repo.Organization,
+		patRepo:         repo.AccessToken,
+		auditRepo:       repo.Audit,
+		emailChangeRepo: repo.EmailChange,
+		keys:            keys,
+		denylist:        denylist,
+		mailer:          mail,
+		config:          config,
 	}
 }
 
-func (s *AuthService) SendMagicLink(ctx context.Context, email string) (*models.MagicLink, error) {
+// SendMagicLink envia o link de login e um código numérico para o app
+// móvel. O nonce retornado deve ser apresentado junto com o código, o que
+// restringe o código ao dispositivo que fez o pedido.
+func (s *AuthService) SendMagicLink(ctx context.Context, email, locale string) (deviceNonce string, err error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	var userID *uuid.UUID
+	defer func() {
+		s.audit(ctx, models.AuditMagicLinkSent, userID, err, map[string]interface{}{"email": email})
+	}()
+
 	// Gerar token único
 	token, err := s.generateSecureToken()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	code, err := generateNumericCode(magicCodeDigits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	deviceNonce, err = s.generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate device nonce: %w", err)
 	}
 
 	// Criar magic link
 	magicLink := &models.MagicLink{
-		ID:        uuid.New(),
-		Email:     email,
-		Token:     token,
-		ExpiresAt: time.Now().Add(15 * time.Minute), // 15 minutos
-		Used:      false,
-		CreatedAt: time.Now(),
+		ID:          uuid.New(),
+		Email:       email,
+		Token:       token,
+		DeviceNonce: hashToken(deviceNonce),
+		ExpiresAt:   time.Now().Add(magicLinkTTL),
+		Used:        false,
+		CreatedAt:   time.Now(),
 	}
+	magicLink.CodeHash = hashCode(magicLink.ID, code)
 
 	// Salvar no banco
 	if err := s.magicRepo.Create(ctx, magicLink); err != nil {
-		return nil, fmt.Errorf("failed to create magic link: %w", err)
+		return "", fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	// Usuários existentes recebem o email no idioma do perfil
+	name := email
+	if user, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		userID = &user.ID
+		name = user.FullName()
+		if user.Locale != "" {
+			locale = user.Locale
+		}
+	}
+
+	link := fmt.Sprintf("%s/auth/verify?token=%s", s.config.Server.AppURL, url.QueryEscape(token))
+	if err := s.sendEmail(ctx, email, "magic_link", locale, map[string]interface{}{
+		"Name":             name,
+		"Link":             link,
+		"Code":             code,
+		"ExpiresInMinutes": int(magicLinkTTL.Minutes()),
+	}); err != nil {
+		return "", fmt.Errorf("failed to send magic link email: %w", err)
 	}
 
-	// TODO: Enviar email com o link
-	// Por enquanto apenas retorna o magic link
-	return magicLink, nil
+	return deviceNonce, nil
 }
 
-func (s *AuthService) VerifyMagicLink(ctx context.Context, token string) (*models.User, string, string, error) {
+func (s *AuthService) VerifyMagicLink(ctx context.Context, token string) (auth *models.AuthResponse, err error) {
+	defer func() {
+		s.auditAuth(ctx, models.AuditMagicLinkVerified, nil, auth, err, nil)
+	}()
+
 	// Buscar magic link
 	magicLink, err := s.magicRepo.GetByToken(ctx, token)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("invalid token: %w", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, fmt.Errorf("failed to get magic link: %w", err)
+	}
+
+	return s.consumeMagicLink(ctx, magicLink)
+}
+
+// VerifyMagicCode conclui o login com o código do email e o nonce devolvido
+// por SendMagicLink. Após magicCodeMaxAttempts códigos errados o link inteiro
+// é invalidado.
+func (s *AuthService) VerifyMagicCode(ctx context.Context, deviceNonce, code string) (auth *models.AuthResponse, err error) {
+	defer func() {
+		s.auditAuth(ctx, models.AuditMagicLinkVerified, nil, auth, err, map[string]interface{}{"method": "code"})
+	}()
+
+	magicLink, err := s.magicRepo.GetByDeviceNonce(ctx, hashToken(deviceNonce))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, fmt.Errorf("failed to get magic link: %w", err)
+	}
+
+	// A tentativa é reservada antes da comparação: requisições paralelas não
+	// conseguem testar mais de magicCodeMaxAttempts códigos
+	attempts, err := s.magicRepo.ClaimAttempt(ctx, magicLink.ID, magicCodeMaxAttempts)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: too many attempts", ErrInvalidMagicLink)
+		}
+		return nil, err
+	}
+
+	expected := hashCode(magicLink.ID, strings.TrimSpace(code))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(magicLink.CodeHash)) != 1 {
+		if attempts >= magicCodeMaxAttempts {
+			if err := s.magicRepo.MarkAsUsed(ctx, magicLink.Token); err != nil && !errors.Is(err, repository.ErrAlreadyUsed) {
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("%w: wrong code", ErrInvalidMagicLink)
 	}
 
+	return s.consumeMagicLink(ctx, magicLink)
+}
+
+// consumeMagicLink marca o link como usado e inicia a sessão do dono do
+// email, criando a conta no primeiro acesso
+func (s *AuthService) consumeMagicLink(ctx context.Context, magicLink *models.MagicLink) (*models.AuthResponse, error) {
 	// Verificar se não expirou
 	if time.Now().After(magicLink.ExpiresAt) {
-		return nil, "", "", fmt.Errorf("token expired")
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidMagicLink)
 	}
 
 	// Verificar se não foi usado
 	if magicLink.Used {
-		return nil, "", "", fmt.Errorf("token already used")
+		return nil, fmt.Errorf("%w: token already used", ErrInvalidMagicLink)
 	}
 
-	// Marcar como usado
-	if err := s.magicRepo.MarkAsUsed(ctx, token); err != nil {
-		return nil, "", "", fmt.Errorf("failed to mark token as used: %w", err)
+	// Marcar como usado; o link e o código não podem ser usados juntos
+	if err := s.magicRepo.MarkAsUsed(ctx, magicLink.Token); err != nil {
+		if errors.Is(err, repository.ErrAlreadyUsed) {
+			return nil, fmt.Errorf("%w: token already used", ErrInvalidMagicLink)
+		}
+		return nil, fmt.Errorf("failed to mark token as used: %w", err)
 	}
 
-	// Buscar ou criar usuário
+	// Buscar ou criar usuário; o link comprova a posse do email
 	user, err := s.userRepo.GetByEmail(ctx, magicLink.Email)
 	if err != nil {
 		// Usuário não existe, criar novo
-		user = &models.User{
-			ID:        uuid.New(),
-			Email:     magicLink.Email,
-			Status:    models.UserStatusActive,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-		if err := s.userRepo.Create(ctx, user); err != nil {
-			return nil, "", "", fmt.Errorf("failed to create user: %w", err)
+		user = newUser(magicLink.Email)
+		user.EmailVerified = true
+		if err := s.createUser(ctx, user); err != nil {
+			return nil, err
 		}
+	} else if err := s.markEmailVerified(ctx, user); err != nil {
+		return nil, err
 	}
 
-	// Gerar tokens JWT
-	accessToken, err := s.generateAccessToken(user)
-	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to generate access token: %w", err)
+	if err := s.ensureEmailIdentity(ctx, user); err != nil {
+		return nil, err
 	}
 
-	refreshToken, err := s.generateRefreshToken(user)
-	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to generate refresh token: %w", err)
-	}
-
-	return user, accessToken, refreshToken, nil
+	return s.startSession(ctx, user)
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (string, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (auth *models.AuthResponse, err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.audit(ctx, models.AuditTokenRefreshed, userID, err, nil)
+	}()
+
 	// Verificar refresh token
 	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(s.config.JWT.Secret), nil
+		return []byte(s.config.JWT.RefreshTokenSecret), nil
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("invalid refresh token: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRefreshToken, err)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", fmt.Errorf("invalid token claims")
+		return nil, fmt.Errorf("%w: invalid token claims", ErrInvalidRefreshToken)
 	}
 
 	// Verificar se é refresh token
 	tokenType, ok := claims["type"].(string)
 	if !ok || tokenType != "refresh" {
-		return "", fmt.Errorf("not a refresh token")
+		return nil, fmt.Errorf("%w: not a refresh token", ErrInvalidRefreshToken)
 	}
 
-	// Buscar usuário
-	userIDStr, ok := claims["user_id"].(string)
-	if !ok {
-		return "", fmt.Errorf("invalid user ID in token")
+	// Buscar token armazenado
+	tokenHash := hashToken(refreshToken)
+	stored, err := s.refreshRepo.GetByToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	userID = &stored.UserID
+
+	if stored.IsRevoked() {
+		return nil, fmt.Errorf("%w: refresh token revoked", ErrInvalidRefreshToken)
+	}
+
+	// Um token já usado sendo reapresentado indica roubo: revogar a família inteira
+	if stored.Used {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	if stored.IsExpired() {
+		return nil, fmt.Errorf("%w: refresh token expired", ErrInvalidRefreshToken)
+	}
+
+	if err := s.touchSession(ctx, stored.FamilyID); err != nil {
+		return nil, err
+	}
+
+	// Rotacionar: consumir o token atual antes de emitir o próximo
+	if err := s.refreshRepo.MarkAsUsed(ctx, tokenHash); err != nil {
+		if errors.Is(err, repository.ErrAlreadyUsed) {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, fmt.Errorf("failed to mark refresh token as used: %w", err)
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
 	if err != nil {
-		return "", fmt.Errorf("invalid user ID format: %w", err)
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountSuspended
 	}
 
-	user, err := s.userRepo.GetByID(ctx, userID)
+	return s.issueTokens(ctx, user, stored.FamilyID)
+}
+
+func (s *AuthService) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) error {
+	if err := s.sessionRepo.Revoke(ctx, stored.UserID, stored.FamilyID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	if err := s.revokeSessionTokens(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+
+	log.Printf("Refresh token reuse detected for user %s, family %s revoked", stored.UserID, stored.FamilyID)
+	return ErrRefreshTokenReused
+}
+
+// ValidateAccessToken valida assinatura, expiração e revogações do access
+// token. Tokens de acesso pessoal (pm_pat_) também são aceitos e retornam
+// claims do tipo "pat". Tokens recusados retornam um erro que satisfaz
+// errors.Is(err, ErrInvalidAccessToken); demais erros são de infraestrutura.
+func (s *AuthService) ValidateAccessToken(ctx context.Context, tokenString string) (*models.User, *models.JWTClaims, error) {
+	if strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+		return s.validatePersonalAccessToken(ctx, tokenString)
+	}
+
+	claims, err := s.parseAccessToken(tokenString)
 	if err != nil {
-		return "", fmt.Errorf("user not found: %w", err)
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidAccessToken, err)
 	}
 
-	// Gerar novo access token
-	accessToken, err := s.generateAccessToken(user)
+	// Verificar revogação (logout)
+	revoked, err := s.denylist.IsRevoked(ctx, claims.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate access token: %w", err)
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, fmt.Errorf("%w: token revoked", ErrInvalidAccessToken)
 	}
 
-	return accessToken, nil
-}
+	// Verificar revogação da sessão
+	sessionRevoked, err := s.denylist.IsSessionRevoked(ctx, claims.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sessionRevoked {
+		return nil, nil, fmt.Errorf("%w: session revoked", ErrInvalidAccessToken)
+	}
 
-func (s *AuthService) ValidateAccessToken(tokenString string) (*models.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	revokedBefore, err := s.denylist.RevokedBefore(ctx, claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// iat tem resolução de segundos: tokens emitidos no mesmo segundo do
+	// corte continuam valendo, para não recusar o login feito logo depois
+	// de um LogoutAll ou de uma redefinição de senha
+	if revokedBefore != nil && claims.Iat < revokedBefore.Unix() {
+		return nil, nil, fmt.Errorf("%w: token revoked", ErrInvalidAccessToken)
+	}
+
+	// Buscar usuário
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: user not found", ErrInvalidAccessToken)
 		}
-		return []byte(s.config.JWT.Secret), nil
-	})
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Suspensão vale imediatamente, sem esperar o token expirar
+	if !user.IsActive() {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidAccessToken, ErrAccountSuspended)
+	}
+
+	return user, claims, nil
+}
+
+// Logout encerra a sessão atual, revogando seus refresh e access tokens
+func (s *AuthService) Logout(ctx context.Context, claims *models.JWTClaims) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditLogout, &claims.UserID, err, nil)
+	}()
+
+	err = s.sessionRepo.Revoke(ctx, claims.UserID, claims.SessionID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	return s.revokeSessionTokens(ctx, claims.SessionID)
+}
+
+// LogoutAll encerra todas as sessões do usuário
+func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditLogoutAll, &userID, err, nil)
+	}()
 
+	if err := s.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.refreshRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+
+	if err := s.denylist.RevokeUser(ctx, userID, time.Now(), s.config.JWT.AccessTokenTTL); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *AuthService) parseAccessToken(tokenString string) (*models.JWTClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
 	// Verificar se é access token
-	tokenType, ok := claims["type"].(string)
+	tokenType, ok := mapClaims["type"].(string)
 	if !ok || tokenType != "access" {
 		return nil, fmt.Errorf("not an access token")
 	}
 
-	// Buscar usuário
-	userIDStr, ok := claims["user_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("invalid user ID in token")
+	claims := &models.JWTClaims{Type: tokenType}
+	claims.ID, _ = mapClaims["jti"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	if claims.ID == "" {
+		return nil, fmt.Errorf("missing token ID")
 	}
 
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
+	userIDStr, _ := mapClaims["user_id"].(string)
+	if claims.UserID, err = uuid.Parse(userIDStr); err != nil {
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	user, err := s.userRepo.GetByID(context.Background(), userID)
+	sessionIDStr, _ := mapClaims["sid"].(string)
+	if claims.SessionID, err = uuid.Parse(sessionIDStr); err != nil {
+		return nil, fmt.Errorf("invalid session ID format: %w", err)
+	}
+
+	if exp, err := mapClaims.GetExpirationTime(); err == nil && exp != nil {
+		claims.Exp = exp.Unix()
+	}
+	if iat, err := mapClaims.GetIssuedAt(); err == nil && iat != nil {
+		claims.Iat = iat.Unix()
+	}
+
+	if roles, ok := mapClaims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				claims.Roles = append(claims.Roles, name)
+			}
+		}
+	}
+	if scope, ok := mapClaims["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	}
+
+	if orgIDStr, ok := mapClaims["org_id"].(string); ok {
+		orgID, err := uuid.Parse(orgIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid organization ID format: %w", err)
+		}
+		claims.OrgID = &orgID
+		orgRole, _ := mapClaims["org_role"].(string)
+		claims.OrgRole = models.OrgRole(orgRole)
+	}
+
+	return claims, nil
+}
+
+// ensureEmailIdentity registra o email (magic link) como forma de login do usuário
+func (s *AuthService) ensureEmailIdentity(ctx context.Context, user *models.User) error {
+	_, err := s.providerRepo.GetByUserIDAndProvider(ctx, user.ID, models.AuthProviderEmail)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to get email identity: %w", err)
+	}
+
+	identity := newAuthProvider(user.ID, &models.OAuthUserInfo{
+		ID:            user.Email,
+		Email:         user.Email,
+		EmailVerified: true,
+		Provider:      string(models.AuthProviderEmail),
+	})
+	if err := s.providerRepo.Create(ctx, identity); err != nil {
+		return fmt.Errorf("failed to create email identity: %w", err)
+	}
+	return nil
+}
+
+// newUser cria um usuário ativo com as preferências padrão
+func newUser(email string) *models.User {
+	now := time.Now()
+	return &models.User{
+		ID:        uuid.New(),
+		Email:     email,
+		Status:    models.UserStatusActive,
+		Locale:    "en",
+		Timezone:  "UTC",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (s *AuthService) sendEmail(ctx context.Context, to, template, locale string, data interface{}) error {
+	msg, err := mailer.Render(template, locale, data)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return err
 	}
+	msg.To = to
 
-	return user, nil
+	return s.mailer.Send(ctx, msg)
 }
 
 func (s *AuthService) generateSecureToken() (string, error) {
@@ -209,27 +557,112 @@ func (s *AuthService) generateSecureToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
+// generateNumericCode gera um código numérico uniforme com a quantidade de
+// dígitos informada, para ser digitado pelo usuário
+func generateNumericCode(digits int) (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < digits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// hashCode associa o hash de um código curto ao ID do registro, de modo que
+// o mesmo código em outro registro produza um hash diferente
+func hashCode(id uuid.UUID, code string) string {
+	return hashToken(id.String() + ":" + code)
+}
+
+// generateAccessToken emite um access token para a sessão com os papéis,
+// permissões e organização ativa atuais do usuário, assinado com a chave
+// ativa (RS256 ou EdDSA) para validação offline via JWKS
+func (s *AuthService) generateAccessToken(ctx context.Context, user *models.User, sessionID uuid.UUID) (string, error) {
+	roles, scopes, err := s.userAuthorization(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load user roles: %w", err)
+	}
+
 	claims := jwt.MapClaims{
+		"jti":     uuid.New().String(),
 		"user_id": user.ID.String(),
+		"sid":     sessionID.String(),
 		"email":   user.Email,
+		"roles":   roles,
+		"scope":   strings.Join(scopes, " "),
 		"type":    "access",
 		"exp":     time.Now().Add(s.config.JWT.AccessTokenTTL).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWT.Secret))
+	member, err := s.activeMembership(ctx, user.ID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if member != nil {
+		claims["org_id"] = member.OrganizationID.String()
+		claims["org_role"] = string(member.Role)
+	}
+
+	return s.keys.Sign(claims)
 }
 
-func (s *AuthService) generateRefreshToken(user *models.User) (string, error) {
+// issueTokens emite um par access/refresh token. O refresh token é
+// persistido (apenas o hash) na família informada. Papéis, permissões e
+// organização ativa são recarregados a cada emissão.
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, familyID uuid.UUID) (*models.AuthResponse, error) {
+	accessToken, err := s.generateAccessToken(ctx, user, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.config.JWT.RefreshTokenTTL),
+		CreatedAt: time.Now(),
+	}
+
+	refreshToken, err := s.generateRefreshToken(user, stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	stored.Token = hashToken(refreshToken)
+	if err := s.refreshRepo.Create(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &models.AuthResponse{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.config.JWT.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *AuthService) generateRefreshToken(user *models.User, stored *models.RefreshToken) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.ID.String(),
 		"type":    "refresh",
-		"exp":     time.Now().Add(s.config.JWT.RefreshTokenTTL).Unix(),
-		"iat":     time.Now().Unix(),
+		"jti":     stored.ID.String(),
+		"fid":     stored.FamilyID.String(),
+		"exp":     stored.ExpiresAt.Unix(),
+		"iat":     stored.CreatedAt.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.config.JWT.RefreshTokenSecret))
 }
+
+// hashToken retorna o hash SHA-256 (hex) usado para armazenar tokens opacos
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
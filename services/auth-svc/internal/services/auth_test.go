@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var magicCodePattern = regexp.MustCompile(`(?m)^\s*(\d{6})\s*$`)
+
+// requestMagicCode envia o magic link e retorna o nonce do dispositivo e o
+// código extraído do email
+func requestMagicCode(t *testing.T, env *testEnv, email string) (string, string) {
+	t.Helper()
+
+	nonce, err := env.auth.SendMagicLink(context.Background(), email, "en")
+	require.NoError(t, err)
+
+	message, ok := env.mail.last(email)
+	require.True(t, ok, "no email sent to %s", email)
+	match := magicCodePattern.FindStringSubmatch(message.Text)
+	require.NotNil(t, match, "no code in email")
+	return nonce, match[1]
+}
+
+// signAccessToken assina um access token da sessão com o iat informado
+func signAccessToken(t *testing.T, env *testEnv, user *models.User, sessionID uuid.UUID, issuedAt time.Time) (string, string) {
+	t.Helper()
+
+	jti := uuid.NewString()
+	token, err := env.auth.keys.Sign(jwt.MapClaims{
+		"jti":     jti,
+		"user_id": user.ID.String(),
+		"sid":     sessionID.String(),
+		"type":    "access",
+		"iat":     issuedAt.Unix(),
+		"exp":     issuedAt.Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	return token, jti
+}
+
+func TestAccessTokenDenylistByJTI(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+	sessionID := uuid.New()
+
+	revoked, jti := signAccessToken(t, env, user, sessionID, time.Now())
+	other, _ := signAccessToken(t, env, user, sessionID, time.Now())
+	require.NoError(t, env.auth.denylist.Revoke(ctx, jti, time.Minute))
+
+	_, _, err := env.auth.ValidateAccessToken(ctx, revoked)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	_, _, err = env.auth.ValidateAccessToken(ctx, other)
+	assert.NoError(t, err)
+}
+
+func TestLogoutRevokesSessionAccessTokens(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	current, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	other, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+
+	_, claims, err := env.auth.ValidateAccessToken(ctx, current.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, env.auth.Logout(ctx, claims))
+
+	// Todos os tokens com o sid da sessão são recusados, não só o usado
+	sameSession, _ := signAccessToken(t, env, user, claims.SessionID, time.Now())
+	for _, token := range []string{current.AccessToken, sameSession} {
+		_, _, err = env.auth.ValidateAccessToken(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	}
+
+	_, _, err = env.auth.ValidateAccessToken(ctx, other.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestLogoutAllRevokesEarlierAccessTokens(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	earlier, _ := signAccessToken(t, env, user, uuid.New(), time.Now().Add(-time.Minute))
+	_, _, err := env.auth.ValidateAccessToken(ctx, earlier)
+	require.NoError(t, err)
+
+	require.NoError(t, env.auth.LogoutAll(ctx, user.ID))
+
+	_, _, err = env.auth.ValidateAccessToken(ctx, earlier)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	// Um login depois do corte continua valendo
+	later, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	_, _, err = env.auth.ValidateAccessToken(ctx, later.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestRevokeUserCutOffKeepsSameSecondTokens(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	auth, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	_, claims, err := env.auth.ValidateAccessToken(ctx, auth.AccessToken)
+	require.NoError(t, err)
+
+	// O corte no mesmo segundo do iat não recusa o token emitido logo depois
+	issuedAt := time.Unix(claims.Iat, 0)
+	require.NoError(t, env.auth.denylist.RevokeUser(ctx, user.ID, issuedAt.Add(900*time.Millisecond), time.Minute))
+	_, _, err = env.auth.ValidateAccessToken(ctx, auth.AccessToken)
+	assert.NoError(t, err)
+
+	require.NoError(t, env.auth.denylist.RevokeUser(ctx, user.ID, issuedAt.Add(time.Second), time.Minute))
+	_, _, err = env.auth.ValidateAccessToken(ctx, auth.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestMagicCodeSignsIn(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	nonce, code := requestMagicCode(t, env, user.Email)
+	auth, err := env.auth.VerifyMagicCode(ctx, nonce, code)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, auth.User.ID)
+
+	// O código e o link são de uso único
+	_, err = env.auth.VerifyMagicCode(ctx, nonce, code)
+	assert.ErrorIs(t, err, ErrInvalidMagicLink)
+}
+
+func TestMagicCodeConcurrentAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	nonce, code := requestMagicCode(t, env, user.Email)
+
+	// Requisições paralelas não conseguem testar mais que o limite de códigos
+	const requests = 4 * magicCodeMaxAttempts
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := env.auth.VerifyMagicCode(ctx, nonce, wrongCode(code))
+			assert.ErrorIs(t, err, ErrInvalidMagicLink)
+		}()
+	}
+	wg.Wait()
+
+	link, err := env.magic.GetByDeviceNonce(ctx, hashToken(nonce))
+	require.NoError(t, err)
+	assert.Equal(t, magicCodeMaxAttempts, link.Attempts)
+	assert.True(t, link.Used)
+
+	// Esgotadas as tentativas, nem o código certo é aceito
+	_, err = env.auth.VerifyMagicCode(ctx, nonce, code)
+	assert.ErrorIs(t, err, ErrInvalidMagicLink)
+}
@@ -0,0 +1,25 @@
+package services
+
+import "context"
+
+// ClientInfo dados do cliente da requisição, usados para registrar sessões
+// e dispositivos
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	// DeviceID identificador estável gerado pelo app cliente (header X-Device-ID)
+	DeviceID string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo associa os dados do cliente ao contexto
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext retorna os dados do cliente do contexto, se houver
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
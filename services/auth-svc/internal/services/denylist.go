@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenDenylist lista de access tokens revogados antes de expirarem
+type TokenDenylist interface {
+	// Revoke revoga um único access token (jti) até ele expirar
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	// IsRevoked verifica se o access token (jti) foi revogado
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// Consume revoga um token de uso único (jti) e informa se esta chamada
+	// foi a primeira a fazê-lo
+	Consume(ctx context.Context, jti string, ttl time.Duration) (bool, error)
+	// RevokeUser revoga os access tokens do usuário emitidos antes de at,
+	// truncado para segundos como o iat
+	RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time, ttl time.Duration) error
+	// RevokedBefore retorna o instante até o qual os tokens do usuário foram revogados
+	RevokedBefore(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	// RevokeSession revoga todos os access tokens de uma sessão (sid)
+	RevokeSession(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error
+	// IsSessionRevoked verifica se a sessão (sid) foi revogada
+	IsSessionRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// RedisDenylist implementação Redis do TokenDenylist
+type RedisDenylist struct {
+	client *redis.Client
+}
+
+// NewRedisDenylist cria uma nova instância do denylist
+func NewRedisDenylist(client *redis.Client) *RedisDenylist {
+	return &RedisDenylist{client: client}
+}
+
+func (d *RedisDenylist) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	if err := d.client.Set(ctx, "auth:denylist:jti:"+jti, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (d *RedisDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := d.client.Exists(ctx, "auth:denylist:jti:"+jti).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token denylist: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (d *RedisDenylist) Consume(ctx context.Context, jti string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, nil
+	}
+	ok, err := d.client.SetNX(ctx, "auth:denylist:jti:"+jti, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume token: %w", err)
+	}
+	return ok, nil
+}
+
+func (d *RedisDenylist) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time, ttl time.Duration) error {
+	if err := d.client.Set(ctx, "auth:denylist:user:"+userID.String(), at.Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+func (d *RedisDenylist) RevokedBefore(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	value, err := d.client.Get(ctx, "auth:denylist:user:"+userID.String()).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user denylist: %w", err)
+	}
+
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denylist entry: %w", err)
+	}
+	at := time.Unix(unix, 0)
+	return &at, nil
+}
+
+func (d *RedisDenylist) RevokeSession(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error {
+	if err := d.client.Set(ctx, "auth:denylist:sid:"+sessionID.String(), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+func (d *RedisDenylist) IsSessionRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	n, err := d.client.Exists(ctx, "auth:denylist:sid:"+sessionID.String()).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check session denylist: %w", err)
+	}
+	return n > 0, nil
+}
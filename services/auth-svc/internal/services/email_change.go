@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+var (
+	// ErrEmailUnchanged o novo email é igual ao atual
+	ErrEmailUnchanged = errors.New("new email is the same as the current one")
+	// ErrInvalidEmailChange link de confirmação ou cancelamento inexistente, expirado ou já usado
+	ErrInvalidEmailChange = errors.New("invalid or expired email change link")
+)
+
+// RequestEmailChange inicia a troca de email: o novo endereço recebe o link
+// de confirmação e o atual recebe um aviso com o link de cancelamento. O
+// email só muda após a confirmação.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail, locale string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditEmailChangeRequested, &userID, err, nil)
+	}()
+
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if newEmail == user.Email {
+		return ErrEmailUnchanged
+	}
+
+	_, err = s.userRepo.GetByEmail(ctx, newEmail)
+	if err == nil {
+		return ErrEmailAlreadyRegistered
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	confirmToken, err := s.generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	cancelToken, err := s.generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	now := time.Now()
+	change := &models.EmailChangeRequest{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		NewEmail:     newEmail,
+		ConfirmToken: hashToken(confirmToken),
+		CancelToken:  hashToken(cancelToken),
+		ExpiresAt:    now.Add(emailChangeTTL),
+		CreatedAt:    now,
+	}
+	if err := s.emailChangeRepo.Create(ctx, change); err != nil {
+		return err
+	}
+
+	if user.Locale != "" {
+		locale = user.Locale
+	}
+
+	confirmLink := fmt.Sprintf("%s/auth/email/confirm?token=%s", s.config.Server.AppURL, url.QueryEscape(confirmToken))
+	if err := s.sendEmail(ctx, newEmail, "email_change_confirm", locale, map[string]interface{}{
+		"Name":           user.FullName(),
+		"Link":           confirmLink,
+		"ExpiresInHours": int(emailChangeTTL.Hours()),
+	}); err != nil {
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+
+	cancelLink := fmt.Sprintf("%s/auth/email/cancel?token=%s", s.config.Server.AppURL, url.QueryEscape(cancelToken))
+	if err := s.sendEmail(ctx, user.Email, "email_change_notice", locale, map[string]interface{}{
+		"Name":     user.FullName(),
+		"NewEmail": newEmail,
+		"Link":     cancelLink,
+	}); err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange aplica a troca de email com o token enviado ao novo
+// endereço, que passa a constar como verificado
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) (user *models.User, err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.audit(ctx, models.AuditEmailChanged, userID, err, nil)
+	}()
+
+	change, err := s.emailChangeRepo.GetByConfirmToken(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidEmailChange
+	}
+	if err != nil {
+		return nil, err
+	}
+	userID = &change.UserID
+
+	if time.Now().After(change.ExpiresAt) {
+		return nil, fmt.Errorf("%w: link expired", ErrInvalidEmailChange)
+	}
+
+	user, err = s.userRepo.GetByID(ctx, change.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// O endereço pode ter sido cadastrado por outra conta depois do pedido
+	existing, err := s.userRepo.GetByEmail(ctx, change.NewEmail)
+	if err == nil && existing.ID != user.ID {
+		return nil, ErrEmailAlreadyRegistered
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	oldEmail := user.Email
+	user.Email = change.NewEmail
+	user.EmailVerified = true
+
+	err = s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		// Consumir o pedido primeiro impede que o link seja usado duas vezes
+		if err := tx.EmailChange.Delete(ctx, change.ID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidEmailChange
+			}
+			return err
+		}
+
+		if err := tx.User.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update email: %w", err)
+		}
+
+		if err := replaceEmailIdentity(ctx, tx, user); err != nil {
+			return err
+		}
+
+		return enqueueEvent(ctx, tx, events.UserUpdated, events.NewUserData(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Links de login e redefinição de senha enviados ao endereço antigo deixam de valer
+	if err := s.magicRepo.DeleteByEmail(ctx, oldEmail); err != nil {
+		return nil, err
+	}
+	if err := s.resetRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CancelEmailChange descarta a troca pendente com o token enviado ao
+// endereço atual
+func (s *AuthService) CancelEmailChange(ctx context.Context, token string) (err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.audit(ctx, models.AuditEmailChangeCanceled, userID, err, nil)
+	}()
+
+	change, err := s.emailChangeRepo.GetByCancelToken(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidEmailChange
+	}
+	if err != nil {
+		return err
+	}
+	userID = &change.UserID
+
+	if err := s.emailChangeRepo.Delete(ctx, change.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidEmailChange
+		}
+		return err
+	}
+
+	return nil
+}
+
+// replaceEmailIdentity atualiza a identidade de login por email, cujo ID é o
+// próprio endereço; contas sem essa identidade não são alteradas
+func replaceEmailIdentity(ctx context.Context, tx *repository.Repository, user *models.User) error {
+	identity, err := tx.AuthProvider.GetByUserIDAndProvider(ctx, user.ID, models.AuthProviderEmail)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get email identity: %w", err)
+	}
+
+	if err := tx.AuthProvider.Delete(ctx, identity.ID); err != nil {
+		return fmt.Errorf("failed to delete email identity: %w", err)
+	}
+
+	replacement := newAuthProvider(user.ID, &models.OAuthUserInfo{
+		ID:            user.Email,
+		Email:         user.Email,
+		EmailVerified: true,
+		Provider:      string(models.AuthProviderEmail),
+	})
+	if err := tx.AuthProvider.Create(ctx, replacement); err != nil {
+		return fmt.Errorf("failed to create email identity: %w", err)
+	}
+
+	return nil
+}
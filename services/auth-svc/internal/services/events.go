@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+)
+
+// enqueueEvent grava o evento na outbox da transação; ele só é publicado
+// se a transação for confirmada
+func enqueueEvent(ctx context.Context, tx *repository.Repository, eventType string, data interface{}) error {
+	event, err := events.NewOutboxEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Outbox.Create(ctx, event); err != nil {
+		return fmt.Errorf("failed to enqueue %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// markEmailVerified marca o email como verificado e publica user.verified;
+// não faz nada se ele já estiver verificado. Quem comprova o email assume a
+// conta: senha, segundo fator, celular, passkeys e identidades vinculadas
+// antes da verificação são descartados e as sessões abertas encerradas, para
+// que um cadastro prévio com o email de outra pessoa não mantenha o acesso.
+func (s *AuthService) markEmailVerified(ctx context.Context, user *models.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+
+	verified := *user
+	err := s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		return verifyEmail(ctx, tx, &verified)
+	})
+	if err != nil {
+		return err
+	}
+	*user = verified
+
+	// Sessões e tokens de acesso pessoal criados antes da verificação podem
+	// ser de quem usou o email. As sessões são revogadas pelo sid: LogoutAll
+	// recusaria também o token que o login em curso emite no mesmo segundo.
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.sessionRepo.Revoke(ctx, user.ID, session.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if err := s.revokeSessionTokens(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.patRepo.RevokeAllByUserID(ctx, user.ID)
+}
+
+// verifyEmail é a parte transacional de markEmailVerified: marca o email,
+// descarta as credenciais criadas antes da verificação e publica
+// user.verified. Quem chama encerra as sessões depois do commit.
+func verifyEmail(ctx context.Context, tx *repository.Repository, user *models.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+
+	user.EmailVerified = true
+	user.PasswordHash = nil
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = nil
+	user.SMSMFAEnabled = false
+	user.MobileNumber = nil
+	user.MobileVerified = false
+
+	if err := tx.User.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to mark email as verified: %w", err)
+	}
+
+	identities, err := tx.AuthProvider.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == models.AuthProviderEmail {
+			continue
+		}
+		if err := tx.AuthProvider.Delete(ctx, identity.ID); err != nil {
+			return fmt.Errorf("failed to delete unverified identity: %w", err)
+		}
+	}
+
+	credentials, err := tx.WebAuthn.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		if err := tx.WebAuthn.Delete(ctx, user.ID, credential.ID); err != nil {
+			return fmt.Errorf("failed to delete unverified passkey: %w", err)
+		}
+	}
+
+	if err := tx.RecoveryCode.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return enqueueEvent(ctx, tx, events.UserVerified, events.NewUserData(user))
+}
@@ -0,0 +1,1092 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/mailer"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/signing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// testEnv AuthService sobre repositórios em memória e um Redis miniredis.
+// Os fakes embutem a interface do repositório: métodos não implementados
+// causam panic, o que denuncia chamadas inesperadas no teste.
+type testEnv struct {
+	t          *testing.T
+	redis      *redis.Client
+	mr         *miniredis.Miniredis
+	repo       *repository.Repository
+	users      *fakeUserRepo
+	identities *fakeAuthProviderRepo
+	passkeys   *fakeWebAuthnRepo
+	phones     *fakePhoneRepo
+	recovery   *fakeRecoveryCodeRepo
+	resets     *fakePasswordResetRepo
+	magic      *fakeMagicLinkRepo
+	sessions   *fakeSessionRepo
+	orgs       *fakeOrganizationRepo
+	invites    *fakeInvitationRepo
+	deletions  *fakeDeletionRepo
+	audit      *fakeAuditRepo
+	mail       *fakeMailer
+	auth       *AuthService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	keys, err := signing.NewEphemeral()
+	require.NoError(t, err)
+
+	env := &testEnv{
+		t:          t,
+		redis:      client,
+		mr:         mr,
+		users:      &fakeUserRepo{users: map[uuid.UUID]*models.User{}},
+		identities: &fakeAuthProviderRepo{},
+		passkeys:   &fakeWebAuthnRepo{},
+		phones:     &fakePhoneRepo{},
+		recovery:   &fakeRecoveryCodeRepo{},
+		resets:     &fakePasswordResetRepo{},
+		magic:      &fakeMagicLinkRepo{},
+		sessions:   &fakeSessionRepo{sessions: map[uuid.UUID]*models.Session{}},
+		orgs:       &fakeOrganizationRepo{},
+		invites:    &fakeInvitationRepo{},
+		deletions:  &fakeDeletionRepo{pending: map[uuid.UUID]*models.AccountDeletion{}},
+		audit:      &fakeAuditRepo{},
+		mail:       &fakeMailer{},
+	}
+	env.repo = &repository.Repository{
+		User:          env.users,
+		AuthProvider:  env.identities,
+		WebAuthn:      env.passkeys,
+		Session:       env.sessions,
+		RefreshToken:  &fakeRefreshTokenRepo{tokens: map[string]*models.RefreshToken{}},
+		Role:          &fakeRoleRepo{},
+		Audit:         env.audit,
+		Outbox:        &fakeOutboxRepo{},
+		Phone:         env.phones,
+		RecoveryCode:  env.recovery,
+		MagicLink:     env.magic,
+		PasswordReset: env.resets,
+		AccessToken:   &fakeAccessTokenRepo{},
+		Organization:  env.orgs,
+		Invitation:    env.invites,
+		Deletion:      env.deletions,
+	}
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "test-secret",
+			RefreshTokenSecret: "test-refresh-secret",
+			AccessTokenTTL:     15 * time.Minute,
+			RefreshTokenTTL:    time.Hour,
+		},
+	}
+	env.auth = NewAuthService(env.repo, keys, NewRedisDenylist(client), env.mail, cfg)
+
+	return env
+}
+
+// addUser cria um usuário ativo com o email verificado
+func (e *testEnv) addUser(email string) *models.User {
+	e.t.Helper()
+
+	user := newUser(email)
+	user.EmailVerified = true
+	require.NoError(e.t, e.users.Create(context.Background(), user))
+	return user
+}
+
+type fakeUserRepo struct {
+	repository.UserRepository
+
+	mu        sync.Mutex
+	users     map[uuid.UUID]*models.User
+	totpSteps map[uuid.UUID]int64
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// GetByIDForUpdate não há lock a tomar: o fake serializa pelo mutex
+func (r *fakeUserRepo) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepo) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	user.LastLoginAt = &now
+	return nil
+}
+
+func (r *fakeUserRepo) RecordFailedLogin(ctx context.Context, id uuid.UUID, maxAttempts int, lockFor time.Duration) (int, *time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return 0, nil, repository.ErrNotFound
+	}
+	user.FailedLoginAttempts++
+	if user.FailedLoginAttempts >= maxAttempts {
+		lockedUntil := time.Now().Add(lockFor)
+		user.LockedUntil = &lockedUntil
+		user.FailedLoginAttempts = 0
+	}
+	return user.FailedLoginAttempts, user.LockedUntil, nil
+}
+
+func (r *fakeUserRepo) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.IsLocked() {
+		return repository.ErrNotFound
+	}
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	return nil
+}
+
+func (r *fakeUserRepo) UpdatePasswordHash(ctx context.Context, id uuid.UUID, previous *string, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || (user.PasswordHash == nil) != (previous == nil) ||
+		(previous != nil && *user.PasswordHash != *previous) {
+		return repository.ErrNotFound
+	}
+	user.PasswordHash = &hash
+	return nil
+}
+
+func (r *fakeUserRepo) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return repository.ErrNotFound
+	}
+	if last, ok := r.totpSteps[id]; ok && last >= step {
+		return repository.ErrAlreadyUsed
+	}
+	if r.totpSteps == nil {
+		r.totpSteps = map[uuid.UUID]int64{}
+	}
+	r.totpSteps[id] = step
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TwoFactorEnabled {
+		return repository.ErrNotFound
+	}
+	user.TwoFactorSecret = &secret
+	return nil
+}
+
+func (r *fakeUserRepo) EnableTOTP(ctx context.Context, id uuid.UUID, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TwoFactorEnabled || user.TwoFactorSecret == nil || *user.TwoFactorSecret != secret {
+		return repository.ErrNotFound
+	}
+	user.TwoFactorEnabled = true
+	return nil
+}
+
+func (r *fakeUserRepo) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = nil
+	return nil
+}
+
+type fakeAuthProviderRepo struct {
+	repository.AuthProviderRepository
+
+	mu         sync.Mutex
+	identities []*models.UserAuthProvider
+}
+
+func (r *fakeAuthProviderRepo) Create(ctx context.Context, provider *models.UserAuthProvider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *provider
+	r.identities = append(r.identities, &copied)
+	return nil
+}
+
+func (r *fakeAuthProviderRepo) GetByProviderAndUserID(ctx context.Context, provider models.AuthProvider, providerUserID string) (*models.UserAuthProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.ProviderUserID == providerUserID {
+			copied := *identity
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeAuthProviderRepo) GetByUserIDAndProvider(ctx context.Context, userID uuid.UUID, provider models.AuthProvider) (*models.UserAuthProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			copied := *identity
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeAuthProviderRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserAuthProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var identities []*models.UserAuthProvider
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			copied := *identity
+			identities = append(identities, &copied)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeAuthProviderRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, identity := range r.identities {
+		if identity.ID == id {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+type fakeWebAuthnRepo struct {
+	repository.WebAuthnCredentialRepository
+
+	mu          sync.Mutex
+	credentials []*models.WebAuthnCredential
+}
+
+func (r *fakeWebAuthnRepo) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *credential
+	r.credentials = append(r.credentials, &copied)
+	return nil
+}
+
+func (r *fakeWebAuthnRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var credentials []*models.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			copied := *credential
+			credentials = append(credentials, &copied)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakeWebAuthnRepo) UpdateUsage(ctx context.Context, id uuid.UUID, signCount uint32, backupState bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, credential := range r.credentials {
+		if credential.ID == id {
+			now := time.Now()
+			credential.SignCount = signCount
+			credential.BackupState = backupState
+			credential.LastUsedAt = &now
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakeWebAuthnRepo) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, credential := range r.credentials {
+		if credential.ID == id && credential.UserID == userID {
+			r.credentials = append(r.credentials[:i], r.credentials[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+type fakePhoneRepo struct {
+	repository.PhoneVerificationRepository
+
+	mu            sync.Mutex
+	verifications []*models.PhoneVerification
+}
+
+// Upsert substitui o código pendente do usuário para a mesma finalidade
+func (r *fakePhoneRepo) Upsert(ctx context.Context, verification *models.PhoneVerification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *verification
+	for i, existing := range r.verifications {
+		if existing.UserID == verification.UserID && existing.Purpose == verification.Purpose {
+			r.verifications[i] = &copied
+			return nil
+		}
+	}
+	r.verifications = append(r.verifications, &copied)
+	return nil
+}
+
+func (r *fakePhoneRepo) Get(ctx context.Context, userID uuid.UUID, purpose models.PhoneVerificationPurpose) (*models.PhoneVerification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, verification := range r.verifications {
+		if verification.UserID == userID && verification.Purpose == purpose {
+			copied := *verification
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePhoneRepo) IncrementAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, verification := range r.verifications {
+		if verification.ID == id {
+			verification.Attempts++
+			return verification.Attempts, nil
+		}
+	}
+	return 0, repository.ErrNotFound
+}
+
+func (r *fakePhoneRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, verification := range r.verifications {
+		if verification.ID == id {
+			r.verifications = append(r.verifications[:i], r.verifications[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// update altera o código pendente, para simular a passagem do tempo
+func (r *fakePhoneRepo) update(userID uuid.UUID, purpose models.PhoneVerificationPurpose, fn func(*models.PhoneVerification)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, verification := range r.verifications {
+		if verification.UserID == userID && verification.Purpose == purpose {
+			fn(verification)
+		}
+	}
+}
+
+type fakeRecoveryCodeRepo struct {
+	repository.RecoveryCodeRepository
+
+	mu    sync.Mutex
+	codes []*models.RecoveryCode
+}
+
+func (r *fakeRecoveryCodeRepo) Replace(ctx context.Context, userID uuid.UUID, codes []*models.RecoveryCode) error {
+	r.DeleteByUserID(ctx, userID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range codes {
+		copied := *code
+		r.codes = append(r.codes, &copied)
+	}
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepo) Consume(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakeRecoveryCodeRepo) CountUnused(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unused := 0
+	for _, code := range r.codes {
+		if code.UserID == userID && code.UsedAt == nil {
+			unused++
+		}
+	}
+	return unused, nil
+}
+
+func (r *fakeRecoveryCodeRepo) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := r.codes[:0]
+	for _, code := range r.codes {
+		if code.UserID != userID {
+			codes = append(codes, code)
+		}
+	}
+	r.codes = codes
+	return nil
+}
+
+type fakePasswordResetRepo struct {
+	repository.PasswordResetRepository
+
+	mu     sync.Mutex
+	tokens []*models.PasswordResetToken
+}
+
+func (r *fakePasswordResetRepo) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *token
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *fakePasswordResetRepo) GetByToken(ctx context.Context, token string) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.tokens {
+		if stored.Token == token {
+			copied := *stored
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePasswordResetRepo) MarkAsUsed(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.tokens {
+		if stored.Token == token && !stored.Used {
+			now := time.Now()
+			stored.Used = true
+			stored.UsedAt = &now
+			return nil
+		}
+	}
+	return repository.ErrAlreadyUsed
+}
+
+func (r *fakePasswordResetRepo) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens := r.tokens[:0]
+	for _, stored := range r.tokens {
+		if stored.UserID != userID {
+			tokens = append(tokens, stored)
+		}
+	}
+	r.tokens = tokens
+	return nil
+}
+
+// update altera os tokens do usuário, para simular a passagem do tempo
+func (r *fakePasswordResetRepo) update(userID uuid.UUID, fn func(*models.PasswordResetToken)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.tokens {
+		if stored.UserID == userID {
+			fn(stored)
+		}
+	}
+}
+
+type fakeMagicLinkRepo struct {
+	repository.MagicLinkRepository
+
+	mu    sync.Mutex
+	links []*models.MagicLink
+}
+
+func (r *fakeMagicLinkRepo) Create(ctx context.Context, link *models.MagicLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *link
+	r.links = append(r.links, &copied)
+	return nil
+}
+
+func (r *fakeMagicLinkRepo) GetByToken(ctx context.Context, token string) (*models.MagicLink, error) {
+	return r.getBy(func(link *models.MagicLink) bool { return link.Token == token })
+}
+
+func (r *fakeMagicLinkRepo) GetByDeviceNonce(ctx context.Context, nonce string) (*models.MagicLink, error) {
+	return r.getBy(func(link *models.MagicLink) bool { return link.DeviceNonce == nonce })
+}
+
+func (r *fakeMagicLinkRepo) getBy(match func(*models.MagicLink) bool) (*models.MagicLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.links {
+		if match(stored) {
+			copied := *stored
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeMagicLinkRepo) ClaimAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.links {
+		if stored.ID == id && stored.Attempts < maxAttempts && !stored.Used {
+			stored.Attempts++
+			return stored.Attempts, nil
+		}
+	}
+	return 0, repository.ErrNotFound
+}
+
+func (r *fakeMagicLinkRepo) MarkAsUsed(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.links {
+		if stored.Token == token && !stored.Used {
+			stored.Used = true
+			return nil
+		}
+	}
+	return repository.ErrAlreadyUsed
+}
+
+type fakeAccessTokenRepo struct {
+	repository.PersonalAccessTokenRepository
+}
+
+func (r *fakeAccessTokenRepo) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+type fakeOrganizationRepo struct {
+	repository.OrganizationRepository
+
+	mu      sync.Mutex
+	orgs    []*models.Organization
+	members []*models.OrganizationMember
+}
+
+func (r *fakeOrganizationRepo) Create(ctx context.Context, org *models.Organization, owner *models.OrganizationMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copiedOrg, copiedOwner := *org, *owner
+	r.orgs = append(r.orgs, &copiedOrg)
+	r.members = append(r.members, &copiedOwner)
+	return nil
+}
+
+func (r *fakeOrganizationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, org := range r.orgs {
+		if org.ID == id {
+			copied := *org
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeOrganizationRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMembership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var memberships []*models.OrganizationMembership
+	for _, member := range r.members {
+		if member.UserID != userID {
+			continue
+		}
+		for _, org := range r.orgs {
+			if org.ID == member.OrganizationID {
+				memberships = append(memberships, &models.OrganizationMembership{Organization: *org, Role: member.Role})
+			}
+		}
+	}
+	return memberships, nil
+}
+
+func (r *fakeOrganizationRepo) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, member := range r.members {
+		if member.OrganizationID == orgID && member.UserID == userID {
+			copied := *member
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeOrganizationRepo) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*models.OrganizationMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var members []*models.OrganizationMember
+	for _, member := range r.members {
+		if member.OrganizationID == orgID {
+			copied := *member
+			members = append(members, &copied)
+		}
+	}
+	return members, nil
+}
+
+// AddMember recusa membros repetidos, como a chave primária da tabela
+func (r *fakeOrganizationRepo) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.members {
+		if existing.OrganizationID == member.OrganizationID && existing.UserID == member.UserID {
+			return errors.New("duplicate organization member")
+		}
+	}
+	copied := *member
+	r.members = append(r.members, &copied)
+	return nil
+}
+
+func (r *fakeOrganizationRepo) CountOwners(ctx context.Context, orgID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	owners := 0
+	for _, member := range r.members {
+		if member.OrganizationID == orgID && member.Role == models.OrgRoleOwner {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+type fakeInvitationRepo struct {
+	repository.OrganizationInvitationRepository
+
+	mu          sync.Mutex
+	invitations []*models.OrganizationInvitation
+}
+
+func (r *fakeInvitationRepo) Create(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *invitation
+	r.invitations = append(r.invitations, &copied)
+	return nil
+}
+
+func (r *fakeInvitationRepo) GetByToken(ctx context.Context, token string) (*models.OrganizationInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.invitations {
+		if stored.Token == token {
+			copied := *stored
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeInvitationRepo) MarkAsAccepted(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.invitations {
+		if stored.ID == id && stored.AcceptedAt == nil {
+			now := time.Now()
+			stored.AcceptedAt = &now
+			return nil
+		}
+	}
+	return repository.ErrAlreadyUsed
+}
+
+type fakeDeletionRepo struct {
+	repository.AccountDeletionRepository
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]*models.AccountDeletion
+	erased  []uuid.UUID
+}
+
+func (r *fakeDeletionRepo) Schedule(ctx context.Context, deletion *models.AccountDeletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[deletion.UserID]; !ok {
+		copied := *deletion
+		r.pending[deletion.UserID] = &copied
+	}
+	return nil
+}
+
+func (r *fakeDeletionRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deletion, ok := r.pending[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *deletion
+	return &copied, nil
+}
+
+func (r *fakeDeletionRepo) Cancel(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[userID]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.pending, userID)
+	return nil
+}
+
+// Erase registra a exclusão; o fake não tem transação para desfazer o
+// Cancel que a precede
+func (r *fakeDeletionRepo) Erase(ctx context.Context, userID uuid.UUID, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.erased = append(r.erased, userID)
+	return nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionRepository
+
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*models.Session
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// update altera a sessão, para simular a passagem do tempo
+func (r *fakeSessionRepo) update(id uuid.UUID, fn func(*models.Session)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[id]; ok {
+		fn(session)
+	}
+}
+
+func (r *fakeSessionRepo) Touch(ctx context.Context, id uuid.UUID, ipAddress *string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	session.LastSeenAt = time.Now()
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *fakeSessionRepo) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	return nil
+}
+
+func (r *fakeSessionRepo) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+
+	mu     sync.Mutex
+	tokens map[string]*models.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *token
+	r.tokens[token.Token] = &copied
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByToken(ctx context.Context, token string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tokens[token]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *stored
+	return &copied, nil
+}
+
+func (r *fakeRefreshTokenRepo) MarkAsUsed(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tokens[token]
+	if !ok || stored.Used {
+		return repository.ErrAlreadyUsed
+	}
+	now := time.Now()
+	stored.Used = true
+	stored.UsedAt = &now
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+type fakeRoleRepo struct {
+	repository.RoleRepository
+}
+
+func (r *fakeRoleRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Role, error) {
+	return nil, nil
+}
+
+func (r *fakeRoleRepo) AssignToUser(ctx context.Context, userID uuid.UUID, roleName string) error {
+	return nil
+}
+
+type fakeOutboxRepo struct {
+	repository.OutboxRepository
+}
+
+func (r *fakeOutboxRepo) Create(ctx context.Context, event *models.OutboxEvent) error {
+	return nil
+}
+
+// fakeMailer guarda as mensagens em vez de enviá-las
+type fakeMailer struct {
+	mu       sync.Mutex
+	messages []*mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last retorna a última mensagem enviada ao endereço
+func (m *fakeMailer) last(to string) (*mailer.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return nil, false
+}
+
+type fakeAuditRepo struct {
+	repository.AuditLogRepository
+
+	mu      sync.Mutex
+	entries []*models.AuditLog
+}
+
+func (r *fakeAuditRepo) Create(ctx context.Context, entry *models.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+	return nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+)
+
+// maxIntrospectionCacheEntries limite a partir do qual entradas vencidas são
+// removidas antes de inserir novas
+const maxIntrospectionCacheEntries = 10000
+
+// IntrospectionService responde a introspecção de tokens (RFC 7662) para
+// serviços internos autenticados por client_id/client_secret.
+//
+// As respostas ficam em cache em memória por até CacheTTL (nunca além da
+// expiração do token); uma revogação pode levar esse tempo para refletir.
+type IntrospectionService struct {
+	auth     *AuthService
+	clients  map[string][32]byte
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[[32]byte]introspectionEntry
+}
+
+type introspectionEntry struct {
+	response  *models.IntrospectionResponse
+	expiresAt time.Time
+}
+
+func NewIntrospectionService(auth *AuthService, cfg config.IntrospectionConfig) *IntrospectionService {
+	clients := make(map[string][32]byte, len(cfg.Clients))
+	for id, secret := range cfg.Clients {
+		clients[id] = sha256.Sum256([]byte(secret))
+	}
+
+	return &IntrospectionService{
+		auth:     auth,
+		clients:  clients,
+		cacheTTL: cfg.CacheTTL,
+		cache:    make(map[[32]byte]introspectionEntry),
+	}
+}
+
+// AuthenticateClient verifica as credenciais do serviço em tempo constante
+func (s *IntrospectionService) AuthenticateClient(clientID, clientSecret string) bool {
+	expected, ok := s.clients[clientID]
+	if !ok {
+		// Mesmo custo de comparação para client_id desconhecido
+		expected = [32]byte{}
+	}
+
+	given := sha256.Sum256([]byte(clientSecret))
+	return subtle.ConstantTimeCompare(expected[:], given[:]) == 1 && ok
+}
+
+// Introspect valida o token com as mesmas regras do AuthMiddleware
+// (assinatura, expiração, logout e revogação de sessão)
+func (s *IntrospectionService) Introspect(ctx context.Context, token string) (*models.IntrospectionResponse, error) {
+	key := sha256.Sum256([]byte(token))
+	if response, ok := s.cached(key); ok {
+		return response, nil
+	}
+
+	user, claims, err := s.auth.ValidateAccessToken(ctx, token)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidAccessToken) {
+			return nil, err
+		}
+		inactive := &models.IntrospectionResponse{Active: false}
+		s.store(key, inactive, time.Now().Add(s.cacheTTL))
+		return inactive, nil
+	}
+
+	response := &models.IntrospectionResponse{
+		Active:    true,
+		TokenType: "access_token",
+		Subject:   user.ID.String(),
+		Username:  user.Email,
+		Scope:     strings.Join(claims.Scopes, " "),
+		Roles:     claims.Roles,
+		OrgRole:   string(claims.OrgRole),
+		JTI:       claims.ID,
+		Exp:       claims.Exp,
+		Iat:       claims.Iat,
+	}
+	if claims.Type == "pat" {
+		response.TokenType = "personal_access_token"
+	} else {
+		response.SessionID = claims.SessionID.String()
+	}
+	if claims.OrgID != nil {
+		response.OrgID = claims.OrgID.String()
+	}
+
+	// Tokens de acesso pessoal podem não expirar (Exp zero)
+	expiresAt := time.Now().Add(s.cacheTTL)
+	if claims.Exp > 0 {
+		if exp := time.Unix(claims.Exp, 0); exp.Before(expiresAt) {
+			expiresAt = exp
+		}
+	}
+	s.store(key, response, expiresAt)
+
+	return response, nil
+}
+
+func (s *IntrospectionService) cached(key [32]byte) (*models.IntrospectionResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.cache, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+func (s *IntrospectionService) store(key [32]byte, response *models.IntrospectionResponse, expiresAt time.Time) {
+	if s.cacheTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cache) >= maxIntrospectionCacheEntries {
+		now := time.Now()
+		for k, entry := range s.cache {
+			if now.After(entry.expiresAt) {
+				delete(s.cache, k)
+			}
+		}
+		// Ainda cheio: descartar tudo em vez de crescer sem limite
+		if len(s.cache) >= maxIntrospectionCacheEntries {
+			s.cache = make(map[[32]byte]introspectionEntry)
+		}
+	}
+
+	s.cache[key] = introspectionEntry{response: response, expiresAt: expiresAt}
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpIssuer        = "Page Magic"
+	totpPeriod        = 30
+	mfaPendingTTL     = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrMFAAlreadyEnabled o usuário já tem 2FA ativo
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrMFANotEnabled o usuário não tem 2FA ativo
+	ErrMFANotEnabled = errors.New("two-factor authentication not enabled")
+	// ErrMFAEnrollmentNotStarted confirmação sem inscrição prévia
+	ErrMFAEnrollmentNotStarted = errors.New("two-factor enrollment not started")
+	// ErrInvalidMFACode código TOTP ou de recuperação inválido
+	ErrInvalidMFACode = errors.New("invalid two-factor code")
+	// ErrInvalidMFAToken token mfa_pending inválido, expirado ou já usado
+	ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+)
+
+// TOTPEnrollment segredo e URI otpauth:// para configurar o autenticador
+type TOTPEnrollment struct {
+	Secret string
+	URL    string
+}
+
+// EnrollTOTP gera um novo segredo TOTP pendente de confirmação
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	secret := key.Secret()
+	if err := s.userRepo.UpdateTOTPSecret(ctx, user.ID, secret); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	return &TOTPEnrollment{Secret: secret, URL: key.URL()}, nil
+}
+
+// ConfirmTOTP ativa o 2FA após validar o primeiro código do autenticador e
+// retorna os códigos de recuperação, exibidos uma única vez
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (codes []string, err error) {
+	defer func() {
+		s.audit(ctx, models.AuditTOTPEnabled, &userID, err, nil)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TwoFactorSecret == nil {
+		return nil, ErrMFAEnrollmentNotStarted
+	}
+
+	if err := s.validateTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, err = s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Uma nova inscrição em paralelo troca o segredo: o código validado
+	// acima deixa de valer para ela
+	if err := s.userRepo.EnableTOTP(ctx, user.ID, *user.TwoFactorSecret); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMFAEnrollmentNotStarted
+		}
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP desativa o 2FA mediante um código TOTP ou de recuperação
+func (s *AuthService) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditTOTPDisabled, &userID, err, nil)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if !user.TwoFactorEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.DisableTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return s.recoveryRepo.DeleteByUserID(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes invalida os códigos atuais e gera um novo conjunto
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := s.validateTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// VerifyMFA troca um token mfa_pending e um código TOTP ou de recuperação
+// por um par de tokens. Códigos errados contam para o bloqueio da conta.
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (auth *models.AuthResponse, err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.auditAuth(ctx, models.AuditMFAVerified, userID, auth, err, map[string]interface{}{"method": "totp"})
+	}()
+
+	challenge, err := s.loadMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	user := challenge.user
+	userID = &user.ID
+
+	if !user.TwoFactorEnabled {
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := s.recordFailedLogin(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	return s.completeMFA(ctx, challenge)
+}
+
+// mfaChallenge token mfa_pending validado e o usuário correspondente
+type mfaChallenge struct {
+	jti     string
+	expires time.Time
+	user    *models.User
+}
+
+// loadMFAChallenge valida um token mfa_pending ainda não consumido
+func (s *AuthService) loadMFAChallenge(ctx context.Context, mfaToken string) (*mfaChallenge, error) {
+	jti, userID, exp, err := s.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	revoked, err := s.denylist.IsRevoked(ctx, jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if user.IsLocked() {
+		return nil, ErrAccountLocked
+	}
+
+	return &mfaChallenge{jti: jti, expires: exp, user: user}, nil
+}
+
+// completeMFA consome o token mfa_pending e emite o par de tokens
+func (s *AuthService) completeMFA(ctx context.Context, challenge *mfaChallenge) (*models.AuthResponse, error) {
+	// O token mfa_pending é de uso único: entre loadMFAChallenge e aqui outra
+	// requisição com o mesmo token pode ter sido concluída, então só a
+	// primeira a consumi-lo recebe a sessão
+	consumed, err := s.denylist.Consume(ctx, challenge.jti, time.Until(challenge.expires))
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user := challenge.user
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.resetFailedLogins(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.issueSession(ctx, user)
+}
+
+// startSession conclui o primeiro fator de login: emite os tokens ou, se o
+// usuário tiver TOTP ativo, passkeys registradas ou SMS ativo, um token
+// mfa_pending
+func (s *AuthService) startSession(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	// Contas suspensas não recebem nem o desafio do segundo fator
+	if !user.IsActive() {
+		return nil, ErrAccountSuspended
+	}
+
+	methods, err := s.mfaMethods(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(methods) == 0 {
+		return s.issueSession(ctx, user)
+	}
+
+	mfaToken, err := s.generateMFAToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa token: %w", err)
+	}
+
+	return &models.AuthResponse{
+		User:       user,
+		MFAToken:   mfaToken,
+		MFAMethods: methods,
+		ExpiresIn:  int64(mfaPendingTTL.Seconds()),
+	}, nil
+}
+
+// mfaMethods lista os segundos fatores disponíveis para o usuário
+func (s *AuthService) mfaMethods(ctx context.Context, user *models.User) ([]string, error) {
+	var methods []string
+	if user.TwoFactorEnabled {
+		methods = append(methods, "totp", "recovery_code")
+	}
+
+	credentials, err := s.webauthnRepo.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) > 0 {
+		methods = append(methods, "webauthn")
+	}
+
+	if user.SMSMFAEnabled && user.MobileVerified && user.MobileNumber != nil {
+		methods = append(methods, "sms")
+	}
+
+	return methods, nil
+}
+
+// checkSecondFactor aceita um código TOTP ou um código de recuperação não usado
+func (s *AuthService) checkSecondFactor(ctx context.Context, user *models.User, code string) error {
+	err := s.validateTOTP(ctx, user, code)
+	if !errors.Is(err, ErrInvalidMFACode) {
+		return err
+	}
+
+	err = s.recoveryRepo.Consume(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+func (s *AuthService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	codes := make([]*models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		plain = append(plain, code)
+		codes = append(codes, &models.RecoveryCode{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CodeHash:  hashToken(normalizeRecoveryCode(code)),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if err := s.recoveryRepo.Replace(ctx, userID, codes); err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+func (s *AuthService) generateMFAToken(user *models.User) (string, error) {
+	claims := jwt.MapClaims{
+		"jti":     uuid.New().String(),
+		"user_id": user.ID.String(),
+		"type":    "mfa_pending",
+		"exp":     time.Now().Add(mfaPendingTTL).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.config.JWT.Secret))
+}
+
+func (s *AuthService) parseMFAToken(tokenString string) (string, uuid.UUID, time.Time, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.config.JWT.Secret), nil
+	})
+	if err != nil {
+		return "", uuid.Nil, time.Time{}, fmt.Errorf("invalid token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return "", uuid.Nil, time.Time{}, fmt.Errorf("invalid token claims")
+	}
+
+	if tokenType, _ := claims["type"].(string); tokenType != "mfa_pending" {
+		return "", uuid.Nil, time.Time{}, fmt.Errorf("not an mfa token")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return "", uuid.Nil, time.Time{}, fmt.Errorf("missing token ID")
+	}
+
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return "", uuid.Nil, time.Time{}, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return "", uuid.Nil, time.Time{}, fmt.Errorf("missing expiration")
+	}
+
+	return jti, userID, exp.Time, nil
+}
+
+// validateTOTP aceita o código do período atual ou de um período adjacente,
+// uma única vez: o período aceito é gravado e códigos do mesmo período ou
+// de um anterior passam a ser recusados
+func (s *AuthService) validateTOTP(ctx context.Context, user *models.User, code string) error {
+	if user.TwoFactorSecret == nil {
+		return ErrInvalidMFACode
+	}
+
+	step, ok := matchTOTP(code, *user.TwoFactorSecret, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	if err := s.userRepo.UseTOTPStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, repository.ErrAlreadyUsed) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	return nil
+}
+
+// matchTOTP retorna o período (time-step) ao qual o código corresponde,
+// considerando o período atual e os adjacentes
+func matchTOTP(code, secret string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	current := now.Unix() / totpPeriod
+
+	for _, step := range []int64{current - 1, current, current + 1} {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0).UTC(), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// generateRecoveryCode gera um código no formato XXXXX-XXXXX (base32, 50 bits)
+func generateRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(bytes)[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode ignora hífens, espaços e maiúsculas/minúsculas
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTOTP ativa o 2FA do usuário e retorna o segredo e os códigos de
+// recuperação
+func enableTOTP(t *testing.T, env *testEnv, user *models.User) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := env.auth.EnrollTOTP(ctx, user.ID)
+	require.NoError(t, err)
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	codes, err := env.auth.ConfirmTOTP(ctx, user.ID, code)
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	return enrollment.Secret, codes
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	secret, codes := enableTOTP(t, env, user)
+
+	// Com o 2FA ativo uma nova inscrição não troca o segredo em uso
+	_, err := env.auth.EnrollTOTP(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.TwoFactorEnabled)
+	require.NotNil(t, stored.TwoFactorSecret)
+	assert.Equal(t, secret, *stored.TwoFactorSecret)
+
+	require.NoError(t, env.auth.DisableTOTP(ctx, user.ID, codes[0]))
+
+	stored, err = env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.TwoFactorEnabled)
+	assert.Nil(t, stored.TwoFactorSecret)
+
+	unused, err := env.recovery.CountUnused(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, unused)
+}
+
+func TestTOTPConfirmRequiresCurrentEnrollment(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	first, err := env.auth.EnrollTOTP(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = env.auth.EnrollTOTP(ctx, user.ID)
+	require.NoError(t, err)
+
+	// O código do primeiro segredo não ativa a inscrição que o substituiu
+	code, err := totp.GenerateCode(first.Secret, time.Now())
+	require.NoError(t, err)
+	_, err = env.auth.ConfirmTOTP(ctx, user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.TwoFactorEnabled)
+}
+
+func TestCompleteMFAKeepsConcurrentLock(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+	enableTOTP(t, env, user)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	pending, err := env.auth.startSession(ctx, stored)
+	require.NoError(t, err)
+
+	challenge, err := env.auth.loadMFAChallenge(ctx, pending.MFAToken)
+	require.NoError(t, err)
+	challenge.user.FailedLoginAttempts = 1
+
+	// Falhas em paralelo bloquearam a conta depois do desafio carregado
+	for i := 0; i < maxFailedLoginAttempts; i++ {
+		_, _, err := env.users.RecordFailedLogin(ctx, user.ID, maxFailedLoginAttempts, accountLockDuration)
+		require.NoError(t, err)
+	}
+
+	_, err = env.auth.completeMFA(ctx, challenge)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	stored, err = env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsLocked())
+}
+
+// startMFA inicia um login que aguarda o segundo fator
+func startMFA(t *testing.T, env *testEnv, user *models.User) string {
+	t.Helper()
+
+	stored, err := env.users.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	pending, err := env.auth.startSession(context.Background(), stored)
+	require.NoError(t, err)
+	require.NotEmpty(t, pending.MFAToken)
+	return pending.MFAToken
+}
+
+func TestVerifyMFARejectsReplayedTOTPStep(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+	secret, _ := enableTOTP(t, env, user)
+
+	// O período atual foi usado na confirmação; o seguinte ainda é aceito
+	code, err := totp.GenerateCode(secret, time.Now().Add(totpPeriod*time.Second))
+	require.NoError(t, err)
+
+	mfaToken := startMFA(t, env, user)
+	auth, err := env.auth.VerifyMFA(ctx, mfaToken, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, auth.AccessToken)
+
+	// O token mfa_pending é de uso único
+	_, err = env.auth.VerifyMFA(ctx, mfaToken, code)
+	assert.ErrorIs(t, err, ErrInvalidMFAToken)
+
+	// O mesmo código, num novo login, é recusado e conta como falha
+	_, err = env.auth.VerifyMFA(ctx, startMFA(t, env, user), code)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.FailedLoginAttempts)
+}
+
+func TestRecoveryCodeIsSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+	_, codes := enableTOTP(t, env, user)
+
+	_, err := env.auth.VerifyMFA(ctx, startMFA(t, env, user), codes[0])
+	require.NoError(t, err)
+
+	_, err = env.auth.VerifyMFA(ctx, startMFA(t, env, user), codes[0])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	_, err = env.auth.VerifyMFA(ctx, startMFA(t, env, user), codes[1])
+	require.NoError(t, err)
+
+	unused, err := env.recovery.CountUnused(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recoveryCodeCount-2, unused)
+}
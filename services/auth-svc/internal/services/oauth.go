@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/oauth"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/oauth2"
+)
+
+// OAuthStateTTL validade do state e do cookie do fluxo de autorização
+const OAuthStateTTL = 10 * time.Minute
+
+var (
+	// ErrUnknownProvider provedor inexistente ou não configurado
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	// ErrInvalidOAuthState state ausente, expirado ou de outro provedor
+	ErrInvalidOAuthState = errors.New("invalid oauth state")
+	// ErrOAuthEmailRequired o provedor não informou um email
+	ErrOAuthEmailRequired = errors.New("oauth provider did not return an email")
+	// ErrOAuthEmailNotVerified o email já pertence a uma conta e não foi verificado pelo provedor
+	ErrOAuthEmailNotVerified = errors.New("oauth email not verified")
+	// ErrOAuthSignupEmailNotVerified contas novas exigem email verificado pelo provedor
+	ErrOAuthSignupEmailNotVerified = errors.New("oauth email must be verified to create an account")
+	// ErrIdentityLinkedToOtherUser a identidade já está vinculada a outra conta
+	ErrIdentityLinkedToOtherUser = errors.New("identity already linked to another account")
+	// ErrLastLoginMethod desvincular deixaria a conta sem forma de login
+	ErrLastLoginMethod = errors.New("cannot unlink the last login method")
+	// ErrEmailIdentityManaged a identidade de email acompanha o email da conta
+	ErrEmailIdentityManaged = errors.New("email identity cannot be unlinked")
+)
+
+// oauthState dados do fluxo de autorização guardados no Redis. LinkUserID
+// é preenchido quando o fluxo vincula uma identidade a uma conta existente.
+// NonceHash amarra o state ao navegador que iniciou o fluxo: o nonce vai
+// num cookie e precisa voltar no callback.
+type oauthState struct {
+	Provider   models.AuthProvider `json:"provider"`
+	Verifier   string              `json:"verifier"`
+	NonceHash  string              `json:"nonce_hash"`
+	LinkUserID *uuid.UUID          `json:"link_user_id,omitempty"`
+}
+
+// boundTo verifica se o nonce do cookie é o do navegador que iniciou o fluxo
+func (o *oauthState) boundTo(browserNonce string) bool {
+	return browserNonce != "" && subtle.ConstantTimeCompare([]byte(hashToken(browserNonce)), []byte(o.NonceHash)) == 1
+}
+
+// OAuthResult resultado do callback: tokens (login) ou a identidade vinculada (link)
+type OAuthResult struct {
+	Auth   *models.AuthResponse
+	Linked *models.UserAuthProvider
+}
+
+// OAuthService login social com state e PKCE
+type OAuthService struct {
+	auth         *AuthService
+	repo         *repository.Repository
+	userRepo     repository.UserRepository
+	providerRepo repository.AuthProviderRepository
+	providers    map[models.AuthProvider]oauth.Provider
+	redis        *redis.Client
+}
+
+func NewOAuthService(auth *AuthService, repo *repository.Repository, providers map[models.AuthProvider]oauth.Provider, redisClient *redis.Client) *OAuthService {
+	return &OAuthService{
+		auth:         auth,
+		repo:         repo,
+		userRepo:     repo.User,
+		providerRepo: repo.AuthProvider,
+		providers:    providers,
+		redis:        redisClient,
+	}
+}
+
+// Start inicia o fluxo de login e retorna a URL de autorização do provedor
+// e o nonce que deve ser gravado em cookie no navegador do usuário
+func (s *OAuthService) Start(ctx context.Context, providerName string) (authURL, browserNonce string, err error) {
+	return s.start(ctx, providerName, nil)
+}
+
+// StartLink inicia o fluxo para vincular uma nova identidade ao usuário; o
+// nonce retornado segue a mesma regra do login
+func (s *OAuthService) StartLink(ctx context.Context, providerName string, userID uuid.UUID) (authURL, browserNonce string, err error) {
+	return s.start(ctx, providerName, &userID)
+}
+
+func (s *OAuthService) start(ctx context.Context, providerName string, linkUserID *uuid.UUID) (string, string, error) {
+	provider, ok := s.providers[models.AuthProvider(providerName)]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	browserNonce, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	data, err := json.Marshal(oauthState{
+		Provider:   provider.Name(),
+		Verifier:   verifier,
+		NonceHash:  hashToken(browserNonce),
+		LinkUserID: linkUserID,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.redis.Set(ctx, oauthStateKey(state), data, OAuthStateTTL).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store oauth state: %w", err)
+	}
+
+	return provider.AuthCodeURL(state, verifier), browserNonce, nil
+}
+
+// Callback conclui o fluxo: valida o state e o nonce do navegador, troca o
+// código e autentica o usuário ou vincula a identidade, conforme o fluxo
+// iniciado
+func (s *OAuthService) Callback(ctx context.Context, providerName, state, code, browserNonce string) (result *OAuthResult, err error) {
+	action := models.AuditOAuthLogin
+	var userID *uuid.UUID
+	defer func() {
+		var auth *models.AuthResponse
+		if result != nil {
+			auth = result.Auth
+		}
+		s.auth.auditAuth(ctx, action, userID, auth, err, map[string]interface{}{"provider": providerName})
+	}()
+
+	provider, ok := s.providers[models.AuthProvider(providerName)]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	// O state é de uso único
+	data, err := s.redis.GetDel(ctx, oauthStateKey(state)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load oauth state: %w", err)
+	}
+
+	var stored oauthState
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Provider != provider.Name() {
+		return nil, ErrInvalidOAuthState
+	}
+
+	// Sem o cookie de quem iniciou o fluxo, um atacante poderia entrar com a
+	// própria conta no navegador da vítima (login CSRF) ou fazer a vítima
+	// concluir o vínculo iniciado por ele, ligando a identidade à conta errada
+	if !stored.boundTo(browserNonce) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	info, err := provider.Exchange(ctx, code, stored.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.LinkUserID != nil {
+		action = models.AuditProviderLinked
+		userID = stored.LinkUserID
+
+		linked, err := s.linkIdentity(ctx, *stored.LinkUserID, info)
+		if err != nil {
+			return nil, err
+		}
+		return &OAuthResult{Linked: linked}, nil
+	}
+
+	user, err := s.findOrCreateUser(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+	userID = &user.ID
+
+	auth, err := s.auth.startSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthResult{Auth: auth}, nil
+}
+
+// ListIdentities lista as identidades vinculadas ao usuário
+func (s *OAuthService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*models.UserAuthProvider, error) {
+	identities, err := s.providerRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity remove uma identidade externa, desde que reste outra forma
+// de login: senha, passkey ou outra identidade externa. A identidade de
+// email acompanha o email da conta e não pode ser desvinculada.
+func (s *OAuthService) UnlinkIdentity(ctx context.Context, userID, identityID uuid.UUID) (err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditProviderUnlinked, &userID, err, map[string]interface{}{"identity_id": identityID.String()})
+	}()
+
+	return s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		// O lock na linha do usuário serializa desvinculações concorrentes
+		user, err := tx.User.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		identities, err := tx.AuthProvider.ListByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		var target *models.UserAuthProvider
+		external := 0
+		for _, identity := range identities {
+			if identity.ID == identityID {
+				target = identity
+			}
+			if identity.Provider != models.AuthProviderEmail {
+				external++
+			}
+		}
+		if target == nil {
+			return repository.ErrNotFound
+		}
+		if target.Provider == models.AuthProviderEmail {
+			return ErrEmailIdentityManaged
+		}
+
+		passkeys, err := tx.WebAuthn.ListByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		methods := external + len(passkeys)
+		if user.PasswordHash != nil {
+			methods++
+		}
+		if methods <= 1 {
+			return ErrLastLoginMethod
+		}
+
+		if err := tx.AuthProvider.Delete(ctx, identityID); err != nil {
+			return fmt.Errorf("failed to unlink identity: %w", err)
+		}
+		return nil
+	})
+}
+
+// linkIdentity vincula a identidade do provedor a um usuário autenticado
+func (s *OAuthService) linkIdentity(ctx context.Context, userID uuid.UUID, info *models.OAuthUserInfo) (*models.UserAuthProvider, error) {
+	existing, err := s.providerRepo.GetByProviderAndUserID(ctx, models.AuthProvider(info.Provider), info.ID)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityLinkedToOtherUser
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get auth provider: %w", err)
+	}
+
+	identity := newAuthProvider(userID, info)
+	if err := s.providerRepo.Create(ctx, identity); err != nil {
+		return nil, fmt.Errorf("failed to link auth provider: %w", err)
+	}
+	return identity, nil
+}
+
+// findOrCreateUser encontra o usuário pela identidade do provedor ou pelo
+// email verificado; caso não exista, cria a conta (apenas com email
+// verificado pelo provedor)
+func (s *OAuthService) findOrCreateUser(ctx context.Context, info *models.OAuthUserInfo) (*models.User, error) {
+	provider := models.AuthProvider(info.Provider)
+
+	// Identidade já vinculada
+	linked, err := s.providerRepo.GetByProviderAndUserID(ctx, provider, info.ID)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, linked.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get auth provider: %w", err)
+	}
+
+	// O índice único de email diferencia maiúsculas; normalizar como nos
+	// demais métodos de login
+	info.Email = strings.ToLower(strings.TrimSpace(info.Email))
+	if info.Email == "" {
+		return nil, ErrOAuthEmailRequired
+	}
+
+	// Conta existente com o mesmo email: só vincular se o provedor verificou o email
+	user, err := s.userRepo.GetByEmail(ctx, info.Email)
+	switch {
+	case err == nil:
+		if !info.EmailVerified {
+			return nil, ErrOAuthEmailNotVerified
+		}
+		// O provedor confirmou a posse do mesmo email
+		if err := s.auth.markEmailVerified(ctx, user); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		// Sem verificação, qualquer um criaria a conta com o email de outra
+		// pessoa antes dela se cadastrar e manteria o acesso pelo provedor
+		if !info.EmailVerified {
+			return nil, ErrOAuthSignupEmailNotVerified
+		}
+		user = newUser(info.Email)
+		user.EmailVerified = true
+		user.FirstName = info.FirstName
+		user.LastName = info.LastName
+		user.AvatarURL = info.AvatarURL
+		if err := s.auth.createUser(ctx, user); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.providerRepo.Create(ctx, newAuthProvider(user.ID, info)); err != nil {
+		return nil, fmt.Errorf("failed to link auth provider: %w", err)
+	}
+
+	return user, nil
+}
+
+// newAuthProvider cria o vínculo entre o usuário e a identidade do provedor,
+// guardando o perfil retornado pelo provedor em ProviderData
+func newAuthProvider(userID uuid.UUID, info *models.OAuthUserInfo) *models.UserAuthProvider {
+	now := time.Now()
+	provider := &models.UserAuthProvider{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Provider:       models.AuthProvider(info.Provider),
+		ProviderUserID: info.ID,
+		ProviderEmail:  optionalString(info.Email),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if data, err := json.Marshal(info); err == nil {
+		profile := string(data)
+		provider.ProviderData = &profile
+	}
+
+	return provider
+}
+
+func oauthStateKey(state string) string {
+	return "auth:oauth:state:" + state
+}
+
+// optionalString retorna nil para strings vazias
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
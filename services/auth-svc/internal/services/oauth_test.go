@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/oauth"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOAuthTestService(t *testing.T) (*testEnv, *OAuthService, *oauth.FakeIdP) {
+	t.Helper()
+
+	env := newTestEnv(t)
+	idp := oauth.NewFakeIdP()
+	t.Cleanup(idp.Close)
+
+	apple, err := oauth.NewAppleProvider(idp.AppleConfig())
+	require.NoError(t, err)
+
+	service := NewOAuthService(env.auth, env.repo, map[models.AuthProvider]oauth.Provider{
+		models.AuthProviderGoogle: oauth.NewGoogleProvider(idp.GoogleConfig()),
+		models.AuthProviderGitHub: oauth.NewGitHubProvider(idp.GitHubConfig()),
+		models.AuthProviderApple:  apple,
+	}, env.redis)
+
+	return env, service, idp
+}
+
+// oauthLogin percorre o fluxo completo no navegador que o iniciou
+func oauthLogin(t *testing.T, service *OAuthService, idp *oauth.FakeIdP, provider string, profile oauth.FakeProfile) (*OAuthResult, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	authURL, nonce, err := service.Start(ctx, provider)
+	require.NoError(t, err)
+
+	state, code, err := idp.Authorize(authURL, profile)
+	require.NoError(t, err)
+
+	return service.Callback(ctx, provider, state, code, nonce)
+}
+
+var oauthTestProviders = []string{"google", "github", "apple"}
+
+func TestOAuthSignup(t *testing.T) {
+	for _, provider := range oauthTestProviders {
+		t.Run(provider, func(t *testing.T) {
+			env, service, idp := newOAuthTestService(t)
+
+			result, err := oauthLogin(t, service, idp, provider, oauth.FakeProfile{
+				ID:            "1001",
+				Email:         " Ana@Example.COM ",
+				EmailVerified: true,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, result.Auth)
+			assert.NotEmpty(t, result.Auth.AccessToken)
+
+			user := result.Auth.User
+			assert.Equal(t, "ana@example.com", user.Email)
+			assert.True(t, user.EmailVerified)
+
+			identities, err := env.identities.ListByUserID(context.Background(), user.ID)
+			require.NoError(t, err)
+			require.Len(t, identities, 1)
+			assert.Equal(t, models.AuthProvider(provider), identities[0].Provider)
+			assert.Equal(t, "1001", identities[0].ProviderUserID)
+
+			// O segundo login encontra a conta pela identidade vinculada
+			result, err = oauthLogin(t, service, idp, provider, oauth.FakeProfile{
+				ID:            "1001",
+				Email:         "outro@example.com",
+				EmailVerified: true,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, result.Auth.User.ID)
+		})
+	}
+}
+
+func TestOAuthSignupRequiresVerifiedEmail(t *testing.T) {
+	for _, provider := range oauthTestProviders {
+		t.Run(provider, func(t *testing.T) {
+			env, service, idp := newOAuthTestService(t)
+
+			_, err := oauthLogin(t, service, idp, provider, oauth.FakeProfile{
+				ID:    "1001",
+				Email: "ana@example.com",
+			})
+			assert.ErrorIs(t, err, ErrOAuthSignupEmailNotVerified)
+
+			_, err = env.users.GetByEmail(context.Background(), "ana@example.com")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOAuthExistingAccountRequiresVerifiedEmail(t *testing.T) {
+	for _, provider := range oauthTestProviders {
+		t.Run(provider, func(t *testing.T) {
+			env, service, idp := newOAuthTestService(t)
+			user := env.addUser("ana@example.com")
+
+			_, err := oauthLogin(t, service, idp, provider, oauth.FakeProfile{
+				ID:    "1001",
+				Email: "Ana@Example.com",
+			})
+			assert.ErrorIs(t, err, ErrOAuthEmailNotVerified)
+
+			identities, err := env.identities.ListByUserID(context.Background(), user.ID)
+			require.NoError(t, err)
+			assert.Empty(t, identities)
+		})
+	}
+}
+
+func TestOAuthLinksExistingAccountByVerifiedEmail(t *testing.T) {
+	env, service, idp := newOAuthTestService(t)
+	user := env.addUser("ana@example.com")
+
+	result, err := oauthLogin(t, service, idp, "google", oauth.FakeProfile{
+		ID:            "1001",
+		Email:         "ANA@example.com",
+		EmailVerified: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, result.Auth.User.ID)
+
+	identities, err := env.identities.ListByUserID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 1)
+}
+
+func TestOAuthStateIsSingleUse(t *testing.T) {
+	_, service, idp := newOAuthTestService(t)
+	ctx := context.Background()
+	profile := oauth.FakeProfile{ID: "1001", Email: "ana@example.com", EmailVerified: true}
+
+	authURL, nonce, err := service.Start(ctx, "google")
+	require.NoError(t, err)
+	state, code, err := idp.Authorize(authURL, profile)
+	require.NoError(t, err)
+
+	_, err = service.Callback(ctx, "google", state, code, nonce)
+	require.NoError(t, err)
+
+	_, code, err = idp.Authorize(authURL, profile)
+	require.NoError(t, err)
+	_, err = service.Callback(ctx, "google", state, code, nonce)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+}
+
+func TestOAuthStateIsBoundToProvider(t *testing.T) {
+	_, service, idp := newOAuthTestService(t)
+	ctx := context.Background()
+
+	authURL, nonce, err := service.Start(ctx, "google")
+	require.NoError(t, err)
+	state, code, err := idp.Authorize(authURL, oauth.FakeProfile{ID: "1001", Email: "ana@example.com", EmailVerified: true})
+	require.NoError(t, err)
+
+	_, err = service.Callback(ctx, "github", state, code, nonce)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+}
+
+func TestOAuthStateIsBoundToBrowser(t *testing.T) {
+	for name, nonce := range map[string]string{"missing nonce": "", "other browser": "nonce-from-another-browser"} {
+		t.Run(name, func(t *testing.T) {
+			env, service, idp := newOAuthTestService(t)
+			ctx := context.Background()
+
+			authURL, _, err := service.Start(ctx, "google")
+			require.NoError(t, err)
+			state, code, err := idp.Authorize(authURL, oauth.FakeProfile{ID: "1001", Email: "ana@example.com", EmailVerified: true})
+			require.NoError(t, err)
+
+			_, err = service.Callback(ctx, "google", state, code, nonce)
+			assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+			_, err = env.users.GetByEmail(ctx, "ana@example.com")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOAuthLinkIdentity(t *testing.T) {
+	env, service, idp := newOAuthTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	authURL, nonce, err := service.StartLink(ctx, "github", user.ID)
+	require.NoError(t, err)
+	state, code, err := idp.Authorize(authURL, oauth.FakeProfile{ID: "2002", Email: "ana.dev@example.com"})
+	require.NoError(t, err)
+
+	result, err := service.Callback(ctx, "github", state, code, nonce)
+	require.NoError(t, err)
+	require.NotNil(t, result.Linked)
+	assert.Nil(t, result.Auth)
+	assert.Equal(t, user.ID, result.Linked.UserID)
+	assert.Equal(t, "2002", result.Linked.ProviderUserID)
+}
+
+func TestOAuthLinkRejectsIdentityOfOtherUser(t *testing.T) {
+	env, service, idp := newOAuthTestService(t)
+	ctx := context.Background()
+	profile := oauth.FakeProfile{ID: "1001", Email: "ana@example.com", EmailVerified: true}
+
+	// A identidade já pertence à conta criada pelo primeiro login
+	owner, err := oauthLogin(t, service, idp, "google", profile)
+	require.NoError(t, err)
+
+	other := env.addUser("bia@example.com")
+	authURL, nonce, err := service.StartLink(ctx, "google", other.ID)
+	require.NoError(t, err)
+	state, code, err := idp.Authorize(authURL, profile)
+	require.NoError(t, err)
+
+	_, err = service.Callback(ctx, "google", state, code, nonce)
+	assert.ErrorIs(t, err, ErrIdentityLinkedToOtherUser)
+
+	identities, err := env.identities.ListByUserID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, identities)
+
+	identities, err = env.identities.ListByUserID(ctx, owner.Auth.User.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 1)
+}
+
+// linkGitHub vincula uma identidade do GitHub à conta pelo fluxo completo
+func linkGitHub(t *testing.T, service *OAuthService, idp *oauth.FakeIdP, userID uuid.UUID) *models.UserAuthProvider {
+	t.Helper()
+	ctx := context.Background()
+
+	authURL, nonce, err := service.StartLink(ctx, "github", userID)
+	require.NoError(t, err)
+	state, code, err := idp.Authorize(authURL, oauth.FakeProfile{ID: "2002", Email: "ana.dev@example.com"})
+	require.NoError(t, err)
+
+	result, err := service.Callback(ctx, "github", state, code, nonce)
+	require.NoError(t, err)
+	require.NotNil(t, result.Linked)
+	return result.Linked
+}
+
+func TestOAuthUnlinkKeepsLastLoginMethod(t *testing.T) {
+	env, service, idp := newOAuthTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	email := &models.UserAuthProvider{ID: uuid.New(), UserID: user.ID, Provider: models.AuthProviderEmail, ProviderUserID: user.Email}
+	require.NoError(t, env.identities.Create(ctx, email))
+	github := linkGitHub(t, service, idp, user.ID)
+
+	// A identidade de email não conta como forma de login desvinculável
+	assert.ErrorIs(t, service.UnlinkIdentity(ctx, user.ID, email.ID), ErrEmailIdentityManaged)
+	assert.ErrorIs(t, service.UnlinkIdentity(ctx, user.ID, github.ID), ErrLastLoginMethod)
+
+	// Com uma passkey a conta continua acessível sem o GitHub
+	require.NoError(t, env.passkeys.Create(ctx, &models.WebAuthnCredential{ID: uuid.New(), UserID: user.ID}))
+	require.NoError(t, service.UnlinkIdentity(ctx, user.ID, github.ID))
+
+	identities, err := env.identities.ListByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, models.AuthProviderEmail, identities[0].Provider)
+}
+
+func TestOAuthUnlinkCountsPassword(t *testing.T) {
+	env, service, idp := newOAuthTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	hash := "hash"
+	user.PasswordHash = &hash
+	require.NoError(t, env.users.Update(ctx, user))
+
+	github := linkGitHub(t, service, idp, user.ID)
+	assert.NoError(t, service.UnlinkIdentity(ctx, user.ID, github.ID))
+	assert.ErrorIs(t, service.UnlinkIdentity(ctx, user.ID, github.ID), repository.ErrNotFound)
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrOrganizationNotFound organização inexistente ou da qual o usuário não é membro
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrMemberNotFound usuário não é membro da organização
+	ErrMemberNotFound = errors.New("organization member not found")
+	// ErrInsufficientOrgRole papel do usuário na organização não permite a operação
+	ErrInsufficientOrgRole = errors.New("insufficient organization role")
+	// ErrInvalidOrgRole papel de organização desconhecido
+	ErrInvalidOrgRole = errors.New("invalid organization role")
+	// ErrAlreadyMember o convidado já é membro da organização
+	ErrAlreadyMember = errors.New("user is already a member")
+	// ErrLastOwner a operação deixaria a organização sem proprietário
+	ErrLastOwner = errors.New("organization must keep at least one owner")
+	// ErrInvalidInvitation convite inexistente, expirado, revogado ou já aceito
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	// ErrInvitationEmailMismatch o convite foi enviado para outro email
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email")
+	// ErrInvitationEmailNotVerified a conta precisa comprovar o email antes de aceitar o convite
+	ErrInvitationEmailNotVerified = errors.New("email must be verified to accept the invitation")
+)
+
+// OrganizationService gerencia organizações, membros e convites
+type OrganizationService struct {
+	auth           *AuthService
+	repo           *repository.Repository
+	orgRepo        repository.OrganizationRepository
+	invitationRepo repository.OrganizationInvitationRepository
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+}
+
+func NewOrganizationService(auth *AuthService, repo *repository.Repository) *OrganizationService {
+	return &OrganizationService{
+		auth:           auth,
+		repo:           repo,
+		orgRepo:        repo.Organization,
+		invitationRepo: repo.Invitation,
+		userRepo:       repo.User,
+		sessionRepo:    repo.Session,
+	}
+}
+
+// Create cria uma organização tendo o usuário como proprietário
+func (s *OrganizationService) Create(ctx context.Context, userID uuid.UUID, name string) (*models.OrganizationMembership, error) {
+	now := time.Now()
+	org := &models.Organization{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(name),
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	owner := &models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           models.OrgRoleOwner,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := s.orgRepo.Create(ctx, org, owner); err != nil {
+		return nil, err
+	}
+
+	return &models.OrganizationMembership{Organization: *org, Role: models.OrgRoleOwner}, nil
+}
+
+// List lista as organizações do usuário
+func (s *OrganizationService) List(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMembership, error) {
+	return s.orgRepo.ListByUserID(ctx, userID)
+}
+
+// Get retorna a organização se o usuário for membro
+func (s *OrganizationService) Get(ctx context.Context, userID, orgID uuid.UUID) (*models.OrganizationMembership, error) {
+	member, err := s.requireRole(ctx, orgID, userID, models.OrgRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	return &models.OrganizationMembership{Organization: *org, Role: member.Role}, nil
+}
+
+// Update renomeia a organização (admin ou proprietário)
+func (s *OrganizationService) Update(ctx context.Context, userID, orgID uuid.UUID, name string) (*models.OrganizationMembership, error) {
+	membership, err := s.Get(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !membership.Role.AtLeast(models.OrgRoleAdmin) {
+		return nil, ErrInsufficientOrgRole
+	}
+
+	membership.Name = strings.TrimSpace(name)
+	membership.UpdatedAt = time.Now()
+	if err := s.orgRepo.Update(ctx, &membership.Organization); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// Delete remove a organização (apenas proprietários)
+func (s *OrganizationService) Delete(ctx context.Context, userID, orgID uuid.UUID) error {
+	if _, err := s.requireRole(ctx, orgID, userID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	return s.orgRepo.Delete(ctx, orgID)
+}
+
+// ListMembers lista os membros da organização
+func (s *OrganizationService) ListMembers(ctx context.Context, userID, orgID uuid.UUID) ([]*models.OrganizationMember, error) {
+	if _, err := s.requireRole(ctx, orgID, userID, models.OrgRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.ListMembers(ctx, orgID)
+}
+
+// UpdateMemberRole altera o papel de um membro. Admins gerenciam editores e
+// viewers; apenas proprietários concedem ou retiram o papel de proprietário.
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, actorID, orgID, memberID uuid.UUID, role models.OrgRole) error {
+	if !role.IsValid() {
+		return ErrInvalidOrgRole
+	}
+
+	actor, target, err := s.memberPair(ctx, orgID, actorID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if !actor.Role.AtLeast(models.OrgRoleAdmin) {
+		return ErrInsufficientOrgRole
+	}
+	if (role == models.OrgRoleOwner || target.Role == models.OrgRoleOwner) && actor.Role != models.OrgRoleOwner {
+		return ErrInsufficientOrgRole
+	}
+
+	if target.Role == models.OrgRoleOwner && role != models.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(ctx, orgID); err != nil {
+			return err
+		}
+	}
+
+	return s.orgRepo.UpdateMemberRole(ctx, orgID, memberID, role)
+}
+
+// RemoveMember remove um membro. Qualquer membro pode sair da organização;
+// remover outros exige ser admin (ou proprietário, se o alvo for proprietário).
+func (s *OrganizationService) RemoveMember(ctx context.Context, actorID, orgID, memberID uuid.UUID) error {
+	actor, target, err := s.memberPair(ctx, orgID, actorID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if actorID != memberID {
+		if !actor.Role.AtLeast(models.OrgRoleAdmin) {
+			return ErrInsufficientOrgRole
+		}
+		if target.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner {
+			return ErrInsufficientOrgRole
+		}
+	}
+
+	if target.Role == models.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(ctx, orgID); err != nil {
+			return err
+		}
+	}
+
+	// Tokens já emitidos deixam de carregar a organização na próxima renovação
+	return s.orgRepo.RemoveMember(ctx, orgID, memberID)
+}
+
+// Invite envia um convite por email. O link leva um token de uso único,
+// guardado apenas como hash; reenviar para o mesmo email invalida o anterior.
+func (s *OrganizationService) Invite(ctx context.Context, inviterID, orgID uuid.UUID, email string, role models.OrgRole, locale string) (*models.OrganizationInvitation, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidOrgRole
+	}
+
+	inviter, err := s.requireRole(ctx, orgID, inviterID, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.OrgRoleOwner && inviter.Role != models.OrgRoleOwner {
+		return nil, ErrInsufficientOrgRole
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if invitee, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		if _, err := s.orgRepo.GetMember(ctx, orgID, invitee.ID); err == nil {
+			return nil, ErrAlreadyMember
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.auth.generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if err := s.invitationRepo.DeletePendingByEmail(ctx, orgID, email); err != nil {
+		return nil, err
+	}
+
+	invitation := &models.OrganizationInvitation{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		Token:          hashToken(token),
+		InvitedBy:      inviterID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+		CreatedAt:      time.Now(),
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	inviterName := inviter.Email
+	if inviter.Name != nil && *inviter.Name != "" {
+		inviterName = *inviter.Name
+	}
+
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", s.auth.config.Server.AppURL, url.QueryEscape(token))
+	if err := s.auth.sendEmail(ctx, email, "organization_invitation", locale, map[string]interface{}{
+		"OrganizationName": org.Name,
+		"InviterName":      inviterName,
+		"Role":             string(role),
+		"Link":             link,
+		"ExpiresInDays":    int(invitationTTL.Hours() / 24),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations lista os convites pendentes (admin ou proprietário)
+func (s *OrganizationService) ListInvitations(ctx context.Context, userID, orgID uuid.UUID) ([]*models.OrganizationInvitation, error) {
+	if _, err := s.requireRole(ctx, orgID, userID, models.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return s.invitationRepo.ListPendingByOrganization(ctx, orgID)
+}
+
+// RevokeInvitation cancela um convite pendente
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, userID, orgID, invitationID uuid.UUID) error {
+	if _, err := s.requireRole(ctx, orgID, userID, models.OrgRoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.invitationRepo.Delete(ctx, orgID, invitationID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidInvitation
+		}
+		return err
+	}
+
+	return nil
+}
+
+// AcceptInvitation adiciona o usuário autenticado à organização do convite.
+// O convite só pode ser aceito pela conta do email convidado, depois que ela
+// comprovar a posse desse email.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*models.OrganizationMembership, error) {
+	invitation, err := s.invitationRepo.GetByToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	if !invitation.IsPending() {
+		return nil, ErrInvalidInvitation
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.EmailVerified {
+		return nil, ErrInvitationEmailNotVerified
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	// O convite só é consumido se o membro for de fato adicionado
+	err = s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.Invitation.MarkAsAccepted(ctx, invitation.ID); err != nil {
+			if errors.Is(err, repository.ErrAlreadyUsed) {
+				return ErrInvalidInvitation
+			}
+			return err
+		}
+
+		now := time.Now()
+		return tx.Organization.AddMember(ctx, &models.OrganizationMember{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+			Role:           invitation.Role,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, userID, invitation.OrganizationID)
+}
+
+// SwitchOrganization define a organização ativa da sessão atual (nil para
+// nenhuma) e emite um access token que já a reflete. O refresh token da
+// sessão continua válido e passa a emitir tokens com a nova organização.
+func (s *OrganizationService) SwitchOrganization(ctx context.Context, claims *models.JWTClaims, orgID *uuid.UUID) (*models.AuthResponse, error) {
+	if orgID != nil {
+		if _, err := s.requireRole(ctx, *orgID, claims.UserID, models.OrgRoleViewer); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.sessionRepo.SetActiveOrganization(ctx, claims.UserID, claims.SessionID, orgID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	accessToken, err := s.auth.generateAccessToken(ctx, user, claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &models.AuthResponse{
+		User:        user,
+		AccessToken: accessToken,
+		ExpiresIn:   int64(s.auth.config.JWT.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// requireRole retorna o vínculo do usuário se ele tiver pelo menos o papel
+// informado. Não membros recebem ErrOrganizationNotFound, sem revelar se a
+// organização existe.
+func (s *OrganizationService) requireRole(ctx context.Context, orgID, userID uuid.UUID, min models.OrgRole) (*models.OrganizationMember, error) {
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	if !member.Role.AtLeast(min) {
+		return nil, ErrInsufficientOrgRole
+	}
+
+	return member, nil
+}
+
+// memberPair carrega o vínculo de quem executa a operação e do membro alvo
+func (s *OrganizationService) memberPair(ctx context.Context, orgID, actorID, memberID uuid.UUID) (*models.OrganizationMember, *models.OrganizationMember, error) {
+	actor, err := s.requireRole(ctx, orgID, actorID, models.OrgRoleViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if actorID == memberID {
+		return actor, actor, nil
+	}
+
+	target, err := s.orgRepo.GetMember(ctx, orgID, memberID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrMemberNotFound
+		}
+		return nil, nil, err
+	}
+
+	return actor, target, nil
+}
+
+func (s *OrganizationService) ensureAnotherOwner(ctx context.Context, orgID uuid.UUID) error {
+	owners, err := s.orgRepo.CountOwners(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// activeMembership retorna o vínculo com a organização ativa da sessão, ou
+// nil se não houver organização ativa ou o usuário não for mais membro
+func (s *AuthService) activeMembership(ctx context.Context, userID, sessionID uuid.UUID) (*models.OrganizationMember, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if session.ActiveOrgID == nil {
+		return nil, nil
+	}
+
+	member, err := s.orgRepo.GetMember(ctx, *session.ActiveOrgID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
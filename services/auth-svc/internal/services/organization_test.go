@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addInvitation registra um convite pendente e retorna o token enviado por email
+func addInvitation(t *testing.T, env *testEnv, org *models.Organization, inviter *models.User, email string) string {
+	t.Helper()
+
+	token := uuid.NewString()
+	require.NoError(t, env.invites.Create(context.Background(), &models.OrganizationInvitation{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           models.OrgRoleEditor,
+		Token:          hashToken(token),
+		InvitedBy:      inviter.ID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+		CreatedAt:      time.Now(),
+	}))
+	return token
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	env := newTestEnv(t)
+	service := NewOrganizationService(env.auth, env.repo)
+	owner := env.addUser("ana@example.com")
+	invitee := env.addUser("bia@example.com")
+	ctx := context.Background()
+
+	org := addOrganization(t, env, owner)
+	token := addInvitation(t, env, org, owner, "BIA@example.com")
+
+	membership, err := service.AcceptInvitation(ctx, invitee.ID, token)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, membership.Organization.ID)
+	assert.Equal(t, models.OrgRoleEditor, membership.Role)
+
+	// O convite é de uso único
+	_, err = service.AcceptInvitation(ctx, invitee.ID, token)
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+}
+
+func TestAcceptInvitationRequiresVerifiedEmail(t *testing.T) {
+	env := newTestEnv(t)
+	service := NewOrganizationService(env.auth, env.repo)
+	owner := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	// Qualquer um pode se cadastrar com o email convidado sem comprová-lo
+	invitee := newUser("bia@example.com")
+	require.NoError(t, env.users.Create(ctx, invitee))
+
+	org := addOrganization(t, env, owner)
+	token := addInvitation(t, env, org, owner, invitee.Email)
+
+	_, err := service.AcceptInvitation(ctx, invitee.ID, token)
+	assert.ErrorIs(t, err, ErrInvitationEmailNotVerified)
+
+	_, err = env.orgs.GetMember(ctx, org.ID, invitee.ID)
+	assert.Error(t, err)
+
+	// Depois da verificação o mesmo convite continua valendo
+	invitee.EmailVerified = true
+	require.NoError(t, env.users.Update(ctx, invitee))
+	_, err = service.AcceptInvitation(ctx, invitee.ID, token)
+	assert.NoError(t, err)
+}
+
+func TestAcceptInvitationRequiresInvitedEmail(t *testing.T) {
+	env := newTestEnv(t)
+	service := NewOrganizationService(env.auth, env.repo)
+	owner := env.addUser("ana@example.com")
+	other := env.addUser("carla@example.com")
+
+	org := addOrganization(t, env, owner)
+	token := addInvitation(t, env, org, owner, "bia@example.com")
+
+	_, err := service.AcceptInvitation(context.Background(), other.ID, token)
+	assert.ErrorIs(t, err, ErrInvitationEmailMismatch)
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	webauthnSessionTTL = 5 * time.Minute
+
+	ceremonyRegister = "register"
+	ceremonyLogin    = "login"
+	ceremonyMFA      = "mfa"
+)
+
+var (
+	// ErrInvalidWebAuthnSession sessão de cerimônia ausente, expirada ou de outro fluxo
+	ErrInvalidWebAuthnSession = errors.New("invalid or expired webauthn session")
+	// ErrInvalidPasskey resposta do autenticador rejeitada
+	ErrInvalidPasskey = errors.New("invalid passkey response")
+)
+
+// webauthnSession estado de uma cerimônia guardado no Redis entre begin e finish
+type webauthnSession struct {
+	Ceremony string               `json:"ceremony"`
+	UserID   *uuid.UUID           `json:"user_id,omitempty"`
+	Data     webauthn.SessionData `json:"data"`
+}
+
+// PasskeyService registro e autenticação com passkeys (WebAuthn), como
+// login principal ou como segundo fator
+type PasskeyService struct {
+	auth     *AuthService
+	userRepo repository.UserRepository
+	credRepo repository.WebAuthnCredentialRepository
+	webauthn *webauthn.WebAuthn
+	redis    *redis.Client
+}
+
+func NewPasskeyService(auth *AuthService, repo *repository.Repository, cfg config.WebAuthnConfig, redisClient *redis.Client) (*PasskeyService, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure webauthn: %w", err)
+	}
+
+	return &PasskeyService{
+		auth:     auth,
+		userRepo: repo.User,
+		credRepo: repo.WebAuthn,
+		webauthn: w,
+		redis:    redisClient,
+	}, nil
+}
+
+// BeginRegistration inicia o registro de um novo autenticador para o usuário
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID uuid.UUID) (*protocol.CredentialCreation, string, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Impede registrar o mesmo autenticador duas vezes
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, data, err := s.webauthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin registration: %w", err)
+	}
+
+	sessionID, err := s.saveSession(ctx, ceremonyRegister, &userID, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return creation, sessionID, nil
+}
+
+// FinishRegistration valida a resposta do autenticador e salva a credencial
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID uuid.UUID, sessionID, name string, response []byte) (stored *models.WebAuthnCredential, err error) {
+	defer func() {
+		var metadata map[string]interface{}
+		if stored != nil {
+			metadata = map[string]interface{}{"credential_id": stored.ID.String(), "name": stored.Name}
+		}
+		s.auth.audit(ctx, models.AuditPasskeyRegistered, &userID, err, metadata)
+	}()
+
+	session, err := s.takeSession(ctx, sessionID, ceremonyRegister)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID == nil || *session.UserID != userID {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	credential, err := s.webauthn.CreateCredential(user, session.Data, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	credentialRecord := &models.WebAuthnCredential{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.credRepo.Create(ctx, credentialRecord); err != nil {
+		return nil, err
+	}
+
+	return credentialRecord, nil
+}
+
+// ListCredentials lista as passkeys do usuário
+func (s *PasskeyService) ListCredentials(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	return s.credRepo.ListByUserID(ctx, userID)
+}
+
+// DeleteCredential remove uma passkey do usuário
+func (s *PasskeyService) DeleteCredential(ctx context.Context, userID, credentialID uuid.UUID) (err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditPasskeyDeleted, &userID, err, map[string]interface{}{"credential_id": credentialID.String()})
+	}()
+
+	return s.credRepo.Delete(ctx, userID, credentialID)
+}
+
+// BeginLogin inicia um login sem usuário informado (credencial descoberta
+// pelo autenticador). Exige verificação do usuário, pois substitui a senha.
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error) {
+	assertion, data, err := s.webauthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin login: %w", err)
+	}
+
+	sessionID, err := s.saveSession(ctx, ceremonyLogin, nil, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return assertion, sessionID, nil
+}
+
+// FinishLogin valida a asserção e emite o par de tokens
+func (s *PasskeyService) FinishLogin(ctx context.Context, sessionID string, response []byte) (auth *models.AuthResponse, err error) {
+	var owner *passkeyUser
+	defer func() {
+		var userID *uuid.UUID
+		if owner != nil {
+			userID = &owner.user.ID
+		}
+		s.auth.auditAuth(ctx, models.AuditPasskeyLogin, userID, auth, err, nil)
+	}()
+
+	session, err := s.takeSession(ctx, sessionID, ceremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		owner, err = s.loadUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return owner, nil
+	}
+
+	_, credential, err := s.webauthn.ValidatePasskeyLogin(handler, session.Data, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	if err := s.recordUsage(ctx, owner, credential); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateLastLogin(ctx, owner.user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.auth.issueSession(ctx, owner.user)
+}
+
+// BeginMFA inicia a asserção de segundo fator para um token mfa_pending
+func (s *PasskeyService) BeginMFA(ctx context.Context, mfaToken string) (*protocol.CredentialAssertion, string, error) {
+	challenge, err := s.auth.loadMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.loadUser(ctx, challenge.user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(user.credentials) == 0 {
+		return nil, "", ErrInvalidMFAToken
+	}
+
+	assertion, data, err := s.webauthn.BeginLogin(user)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin login: %w", err)
+	}
+
+	sessionID, err := s.saveSession(ctx, ceremonyMFA, &challenge.user.ID, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return assertion, sessionID, nil
+}
+
+// FinishMFA valida a asserção de segundo fator e troca o token mfa_pending
+// pelo par de tokens. Asserções rejeitadas contam para o bloqueio da conta.
+func (s *PasskeyService) FinishMFA(ctx context.Context, mfaToken, sessionID string, response []byte) (auth *models.AuthResponse, err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.auth.auditAuth(ctx, models.AuditMFAVerified, userID, auth, err, map[string]interface{}{"method": "passkey"})
+	}()
+
+	challenge, err := s.auth.loadMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	userID = &challenge.user.ID
+
+	session, err := s.takeSession(ctx, sessionID, ceremonyMFA)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID == nil || *session.UserID != challenge.user.ID {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	user, err := s.loadUser(ctx, challenge.user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	credential, err := s.webauthn.ValidateLogin(user, session.Data, parsed)
+	if err != nil {
+		if err := s.auth.recordFailedLogin(ctx, challenge.user); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	if err := s.recordUsage(ctx, user, credential); err != nil {
+		return nil, err
+	}
+
+	return s.auth.completeMFA(ctx, challenge)
+}
+
+// recordUsage atualiza o contador de assinaturas; um contador que não avança
+// indica um possível autenticador clonado e a asserção é recusada
+func (s *PasskeyService) recordUsage(ctx context.Context, user *passkeyUser, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		return fmt.Errorf("%w: signature counter did not increase", ErrInvalidPasskey)
+	}
+
+	stored := user.find(credential.ID)
+	if stored == nil {
+		return ErrInvalidPasskey
+	}
+
+	return s.credRepo.UpdateUsage(ctx, stored.ID, credential.Authenticator.SignCount, credential.Flags.BackupState)
+}
+
+func (s *PasskeyService) loadUser(ctx context.Context, userID uuid.UUID) (*passkeyUser, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	credentials, err := s.credRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+func (s *PasskeyService) saveSession(ctx context.Context, ceremony string, userID *uuid.UUID, data *webauthn.SessionData) (string, error) {
+	sessionID, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	payload, err := json.Marshal(webauthnSession{Ceremony: ceremony, UserID: userID, Data: *data})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.redis.Set(ctx, webauthnSessionKey(sessionID), payload, webauthnSessionTTL).Err(); err != nil {
+		return "", fmt.Errorf("failed to store webauthn session: %w", err)
+	}
+
+	return sessionID, nil
+}
+
+// takeSession carrega e remove a sessão da cerimônia (uso único)
+func (s *PasskeyService) takeSession(ctx context.Context, sessionID, ceremony string) (*webauthnSession, error) {
+	payload, err := s.redis.GetDel(ctx, webauthnSessionKey(sessionID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrInvalidWebAuthnSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webauthn session: %w", err)
+	}
+
+	var session webauthnSession
+	if err := json.Unmarshal(payload, &session); err != nil || session.Ceremony != ceremony {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	return &session, nil
+}
+
+func webauthnSessionKey(sessionID string) string {
+	return "auth:webauthn:session:" + sessionID
+}
+
+// passkeyUser adapta models.User e suas credenciais à interface webauthn.User.
+// O user handle é o próprio UUID do usuário.
+type passkeyUser struct {
+	user        *models.User
+	credentials []*models.WebAuthnCredential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.FullName()
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, transport := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return credentials
+}
+
+func (u *passkeyUser) find(credentialID []byte) *models.WebAuthnCredential {
+	for _, c := range u.credentials {
+		if string(c.CredentialID) == string(credentialID) {
+			return c
+		}
+	}
+	return nil
+}
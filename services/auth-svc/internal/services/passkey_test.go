@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:3000"
+)
+
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+)
+
+// softAuthenticator autenticador WebAuthn em software: uma chave P-256 com
+// atestação "none" e contador de assinaturas controlado pelo teste
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	// userVerified liga a flag UV nas respostas
+	userVerified bool
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &softAuthenticator{t: t, key: key, credentialID: credentialID, userVerified: true}
+}
+
+// create responde a um navigator.credentials.create()
+func (a *softAuthenticator) create(creation *protocol.CredentialCreation) []byte {
+	a.t.Helper()
+
+	a.userHandle = creation.Response.User.ID.(protocol.URLEncodedBase64)
+	clientData := a.clientData("webauthn.create", creation.Response.Challenge.String())
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(a.t, err)
+
+	authData := a.authData(flagAttestedData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	require.NoError(a.t, err)
+
+	return a.credential(map[string]string{
+		"clientDataJSON":    encode(clientData),
+		"attestationObject": encode(attestation),
+	})
+}
+
+// get responde a um navigator.credentials.get(), incrementando o contador
+func (a *softAuthenticator) get(assertion *protocol.CredentialAssertion) []byte {
+	a.t.Helper()
+
+	a.signCount++
+	clientData := a.clientData("webauthn.get", assertion.Response.Challenge.String())
+	authData := a.authData(0)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	return a.credential(map[string]string{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	flags |= flagUserPresent
+	if a.userVerified {
+		flags |= flagUserVerified
+	}
+
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) credential(response map[string]string) []byte {
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(a.t, err)
+	return payload
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newPasskeyTestService(t *testing.T) (*testEnv, *PasskeyService) {
+	t.Helper()
+
+	env := newTestEnv(t)
+	service, err := NewPasskeyService(env.auth, env.repo, config.WebAuthnConfig{
+		RPID:          testRPID,
+		RPDisplayName: "Page Magic",
+		RPOrigins:     []string{testOrigin},
+	}, env.redis)
+	require.NoError(t, err)
+
+	return env, service
+}
+
+// registerPasskey registra o autenticador para o usuário
+func registerPasskey(t *testing.T, service *PasskeyService, user *models.User, authenticator *softAuthenticator) *models.WebAuthnCredential {
+	t.Helper()
+	ctx := context.Background()
+
+	creation, sessionID, err := service.BeginRegistration(ctx, user.ID)
+	require.NoError(t, err)
+
+	credential, err := service.FinishRegistration(ctx, user.ID, sessionID, " Laptop ", authenticator.create(creation))
+	require.NoError(t, err)
+	return credential
+}
+
+func passkeyLogin(service *PasskeyService, authenticator *softAuthenticator) (*models.AuthResponse, error) {
+	ctx := context.Background()
+
+	assertion, sessionID, err := service.BeginLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return service.FinishLogin(ctx, sessionID, authenticator.get(assertion))
+}
+
+func TestPasskeyRegisterAndLogin(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	authenticator := newSoftAuthenticator(t)
+
+	credential := registerPasskey(t, service, user, authenticator)
+	assert.Equal(t, "Laptop", credential.Name)
+	assert.Equal(t, authenticator.credentialID, credential.CredentialID)
+
+	auth, err := passkeyLogin(service, authenticator)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, auth.User.ID)
+	assert.NotEmpty(t, auth.AccessToken)
+	assert.NotEmpty(t, auth.RefreshToken)
+
+	stored, err := env.passkeys.ListByUserID(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, authenticator.signCount, stored[0].SignCount)
+	assert.NotNil(t, stored[0].LastUsedAt)
+}
+
+func TestPasskeyRegistrationSessionIsSingleUse(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	authenticator := newSoftAuthenticator(t)
+	ctx := context.Background()
+
+	creation, sessionID, err := service.BeginRegistration(ctx, user.ID)
+	require.NoError(t, err)
+	response := authenticator.create(creation)
+
+	_, err = service.FinishRegistration(ctx, user.ID, sessionID, "Laptop", response)
+	require.NoError(t, err)
+
+	_, err = service.FinishRegistration(ctx, user.ID, sessionID, "Laptop", response)
+	assert.ErrorIs(t, err, ErrInvalidWebAuthnSession)
+}
+
+func TestPasskeyRegistrationRejectsSessionOfOtherUser(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	other := env.addUser("bia@example.com")
+	authenticator := newSoftAuthenticator(t)
+	ctx := context.Background()
+
+	creation, sessionID, err := service.BeginRegistration(ctx, user.ID)
+	require.NoError(t, err)
+
+	_, err = service.FinishRegistration(ctx, other.ID, sessionID, "Laptop", authenticator.create(creation))
+	assert.ErrorIs(t, err, ErrInvalidWebAuthnSession)
+}
+
+func TestPasskeyLoginRequiresUserVerification(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+
+	authenticator.userVerified = false
+	_, err := passkeyLogin(service, authenticator)
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+}
+
+func TestPasskeyLoginRejectsClonedAuthenticator(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+
+	_, err := passkeyLogin(service, authenticator)
+	require.NoError(t, err)
+
+	// Um clone responde com um contador que não avança
+	authenticator.signCount--
+	_, err = passkeyLogin(service, authenticator)
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+
+	stored, err := env.passkeys.ListByUserID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), stored[0].SignCount)
+}
+
+func TestPasskeyLoginRejectsUnknownCredential(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	registerPasskey(t, service, user, newSoftAuthenticator(t))
+
+	// Outra chave com o mesmo user handle, nunca registrada
+	impostor := newSoftAuthenticator(t)
+	impostor.userHandle = user.ID[:]
+	_, err := passkeyLogin(service, impostor)
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+}
+
+func TestPasskeyMFA(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+	ctx := context.Background()
+
+	pending, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	require.NotEmpty(t, pending.MFAToken)
+	assert.Empty(t, pending.AccessToken)
+	assert.Contains(t, pending.MFAMethods, "webauthn")
+
+	// O segundo fator não exige verificação do usuário
+	authenticator.userVerified = false
+	assertion, sessionID, err := service.BeginMFA(ctx, pending.MFAToken)
+	require.NoError(t, err)
+
+	auth, err := service.FinishMFA(ctx, pending.MFAToken, sessionID, authenticator.get(assertion))
+	require.NoError(t, err)
+	assert.NotEmpty(t, auth.AccessToken)
+
+	// O token mfa_pending é de uso único
+	assertion, sessionID, err = service.BeginMFA(ctx, pending.MFAToken)
+	if err == nil {
+		_, err = service.FinishMFA(ctx, pending.MFAToken, sessionID, authenticator.get(assertion))
+	}
+	assert.ErrorIs(t, err, ErrInvalidMFAToken)
+}
+
+func TestPasskeyMFARecordsFailedAttempt(t *testing.T) {
+	env, service := newPasskeyTestService(t)
+	user := env.addUser("ana@example.com")
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+	ctx := context.Background()
+
+	pending, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+
+	assertion, sessionID, err := service.BeginMFA(ctx, pending.MFAToken)
+	require.NoError(t, err)
+
+	// Assinatura de outra chave para a mesma credencial
+	forged := newSoftAuthenticator(t)
+	forged.credentialID = authenticator.credentialID
+	forged.userHandle = user.ID[:]
+	forged.signCount = authenticator.signCount
+
+	_, err = service.FinishMFA(ctx, pending.MFAToken, sessionID, forged.get(assertion))
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.FailedLoginAttempts)
+}
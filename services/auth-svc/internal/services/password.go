@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/password"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxFailedLoginAttempts = 5
+	accountLockDuration    = 15 * time.Minute
+	// recentLoginWindow idade máxima da sessão para definir a primeira senha
+	recentLoginWindow = 10 * time.Minute
+)
+
+var (
+	// ErrInvalidCredentials email ou senha incorretos
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrAccountLocked conta bloqueada temporariamente por tentativas falhas
+	ErrAccountLocked = errors.New("account temporarily locked")
+	// ErrEmailAlreadyRegistered já existe uma conta com o email
+	ErrEmailAlreadyRegistered = errors.New("email already registered")
+	// ErrRecentLoginRequired a operação exige uma sessão aberta há pouco
+	ErrRecentLoginRequired = errors.New("recent login required")
+)
+
+// Register cria uma conta com email e senha. O email continua não
+// verificado: se outra pessoa comprová-lo depois (magic link ou OAuth), a
+// senha e as sessões abertas aqui são descartadas (ver markEmailVerified).
+func (s *AuthService) Register(ctx context.Context, req *models.CreateUserRequest) (auth *models.AuthResponse, err error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	defer func() {
+		s.auditAuth(ctx, models.AuditRegister, nil, auth, err, map[string]interface{}{"email": email})
+	}()
+
+	if err := password.Check(req.Password, email); err != nil {
+		return nil, err
+	}
+
+	_, err = s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return nil, ErrEmailAlreadyRegistered
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	hash, err := password.Hash(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := newUser(email)
+	user.PasswordHash = &hash
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	if req.Locale != "" {
+		user.Locale = req.Locale
+	}
+	if req.Timezone != "" {
+		user.Timezone = req.Timezone
+	}
+
+	if err := s.createUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureEmailIdentity(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issueSession(ctx, user)
+}
+
+// Login autentica com email e senha. Após maxFailedLoginAttempts falhas
+// seguidas a conta fica bloqueada por accountLockDuration.
+func (s *AuthService) Login(ctx context.Context, email, plain string) (auth *models.AuthResponse, err error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	var userID *uuid.UUID
+	defer func() {
+		s.auditAuth(ctx, models.AuditLogin, userID, auth, err, map[string]interface{}{"email": email})
+	}()
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Mesmo custo de uma verificação real para não revelar se o email existe
+			password.VerifyDummy(plain)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	userID = &user.ID
+
+	if user.IsLocked() {
+		return nil, ErrAccountLocked
+	}
+
+	if user.PasswordHash == nil {
+		password.VerifyDummy(plain)
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, needsRehash, err := password.Verify(plain, *user.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if !ok {
+		if err := s.recordFailedLogin(ctx, user); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// Com 2FA ativo o contador só é zerado após o segundo fator, para que
+	// novos logins com a senha não renovem as tentativas de código
+	if !user.TwoFactorEnabled && (user.FailedLoginAttempts > 0 || user.LockedUntil != nil) {
+		if err := s.resetFailedLogins(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	// Regravar hashes legados (bcrypt) ou com parâmetros antigos. Só
+	// substitui o hash verificado: uma troca de senha concorrente prevalece.
+	if needsRehash {
+		s.rehashPassword(ctx, user, plain)
+	}
+
+	if err := s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// ChangePassword troca a senha após confirmar a senha atual. Senhas atuais
+// erradas contam para o bloqueio da conta, como no login. Contas criadas
+// sem senha (magic link, OAuth ou passkey) definem a primeira senha sem
+// current, desde que a sessão tenha sido aberta há menos de
+// recentLoginWindow.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID uuid.UUID, current, next string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditPasswordChanged, &userID, err, nil)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if user.IsLocked() {
+		return ErrAccountLocked
+	}
+
+	if user.PasswordHash == nil {
+		if err := s.requireRecentLogin(ctx, userID, sessionID); err != nil {
+			return err
+		}
+	} else {
+		ok, _, err := password.Verify(current, *user.PasswordHash)
+		if err != nil {
+			return fmt.Errorf("failed to verify password: %w", err)
+		}
+		if !ok {
+			if err := s.recordFailedLogin(ctx, user); err != nil {
+				return err
+			}
+			return ErrInvalidCredentials
+		}
+	}
+
+	if err := password.Check(next, user.Email); err != nil {
+		return err
+	}
+
+	hash, err := password.Hash(next)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// A senha confirmada acima precisa ser a que está sendo substituída
+	if err := s.userRepo.UpdatePasswordHash(ctx, user.ID, user.PasswordHash, hash); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	return nil
+}
+
+// requireRecentLogin exige que a sessão do token tenha sido aberta há menos
+// de recentLoginWindow, o que substitui a senha atual como confirmação
+func (s *AuthService) requireRecentLogin(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrRecentLoginRequired
+	}
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != userID || !session.IsActive() || time.Since(session.CreatedAt) > recentLoginWindow {
+		return ErrRecentLoginRequired
+	}
+	return nil
+}
+
+// resetFailedLogins zera o contador de falhas após um login concluído. Se
+// falhas concorrentes bloquearam a conta nesse meio tempo, o bloqueio
+// prevalece.
+func (s *AuthService) resetFailedLogins(ctx context.Context, user *models.User) error {
+	if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAccountLocked
+		}
+		return err
+	}
+
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	return nil
+}
+
+// rehashPassword regrava o hash da senha com os parâmetros atuais. Falhas
+// não impedem o login e ficam apenas no log.
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, plain string) {
+	hash, err := password.Hash(plain)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(ctx, user.ID, user.PasswordHash, hash)
+	}
+	if err != nil {
+		log.Printf("Failed to rehash password for user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = &hash
+}
+
+// recordFailedLogin contabiliza uma tentativa falha (senha ou segundo fator)
+// e bloqueia a conta ao atingir maxFailedLoginAttempts. O incremento é feito
+// no banco para que tentativas paralelas não escapem do limite.
+func (s *AuthService) recordFailedLogin(ctx context.Context, user *models.User) error {
+	attempts, lockedUntil, err := s.userRepo.RecordFailedLogin(ctx, user.ID, maxFailedLoginAttempts, accountLockDuration)
+	if err != nil {
+		return err
+	}
+
+	user.FailedLoginAttempts = attempts
+	user.LockedUntil = lockedUntil
+	return nil
+}
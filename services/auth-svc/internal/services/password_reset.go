@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/password"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	passwordResetTTL = 30 * time.Minute
+	// passwordResetSendTimeout limite do envio em segundo plano
+	passwordResetSendTimeout = time.Minute
+)
+
+// ErrInvalidResetToken token de redefinição inexistente, expirado ou já usado
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// RequestPasswordReset agenda o envio do link de redefinição de senha e
+// retorna imediatamente. A busca do usuário e o envio rodam em segundo
+// plano, para que nem o tempo nem o status da resposta revelem quais
+// endereços estão cadastrados.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email, locale string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetSendTimeout)
+	go func() {
+		defer cancel()
+		if err := s.sendPasswordReset(ctx, email, locale); err != nil {
+			log.Printf("Failed to send password reset: %v", err)
+		}
+	}()
+}
+
+// sendPasswordReset envia o link de redefinição; emails sem conta são
+// ignorados
+func (s *AuthService) sendPasswordReset(ctx context.Context, email, locale string) error {
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, err := s.generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	// Apenas o link mais recente permanece válido
+	if err := s.resetRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	reset := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Token:     hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := s.resetRepo.Create(ctx, reset); err != nil {
+		return err
+	}
+
+	if user.Locale != "" {
+		locale = user.Locale
+	}
+
+	link := fmt.Sprintf("%s/auth/reset-password?token=%s", s.config.Server.AppURL, url.QueryEscape(token))
+	if err := s.sendEmail(ctx, user.Email, "password_reset", locale, map[string]interface{}{
+		"Name":             user.FullName(),
+		"Link":             link,
+		"ExpiresInMinutes": int(passwordResetTTL.Minutes()),
+	}); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword define uma nova senha a partir de um token de redefinição,
+// encerra todas as sessões do usuário e envia um aviso de troca de senha
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.audit(ctx, models.AuditPasswordReset, userID, err, nil)
+	}()
+
+	tokenHash := hashToken(token)
+
+	reset, err := s.resetRepo.GetByToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	userID = &reset.UserID
+
+	if !reset.IsValid() {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, reset.UserID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	// Validar a política antes de consumir o token, para que o usuário possa
+	// tentar outra senha com o mesmo link
+	if err := password.Check(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	hash, err := password.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.PasswordReset.MarkAsUsed(ctx, tokenHash); err != nil {
+			if errors.Is(err, repository.ErrAlreadyUsed) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		locked, err := tx.User.GetByIDForUpdate(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("user not found: %w", err)
+		}
+
+		// Concluir a redefinição comprova a posse do email, como o magic
+		// link: o que um cadastro prévio com o email deixou na conta é
+		// descartado antes de gravar a nova senha
+		if err := verifyEmail(ctx, tx, locked); err != nil {
+			return err
+		}
+
+		locked.PasswordHash = &hash
+		locked.FailedLoginAttempts = 0
+		locked.LockedUntil = nil
+		if err := tx.User.Update(ctx, locked); err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+
+		*user = *locked
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Encerra também as sessões de quem usou o email antes da verificação
+	if err := s.LogoutAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// A redefinição costuma seguir um comprometimento da conta; tokens de
+	// acesso pessoal criados por um invasor também deixam de valer
+	if err := s.patRepo.RevokeAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.sendEmail(ctx, user.Email, "password_changed", user.Locale, map[string]interface{}{
+		"Name":      user.FullName(),
+		"ChangedAt": time.Now().UTC().Format("2006-01-02 15:04 UTC"),
+	}); err != nil {
+		// A senha já foi trocada; falha no aviso não deve desfazer a operação
+		log.Printf("Failed to send password changed notice to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
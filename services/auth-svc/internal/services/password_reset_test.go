@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var resetTokenPattern = regexp.MustCompile(`token=([^\s"&<]+)`)
+
+// requestReset envia o link de redefinição e extrai o token do email
+func requestReset(t *testing.T, env *testEnv, email string) string {
+	t.Helper()
+
+	require.NoError(t, env.auth.sendPasswordReset(context.Background(), email, "en"))
+
+	message, ok := env.mail.last(email)
+	require.True(t, ok, "no email sent to %s", email)
+	match := resetTokenPattern.FindStringSubmatch(message.Text)
+	require.NotNil(t, match, "no reset link in email")
+
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestPasswordResetTokenIsSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	token := requestReset(t, env, user.Email)
+	require.NoError(t, env.auth.ResetPassword(ctx, token, "a brand new passphrase"))
+
+	_, err := env.auth.Login(ctx, user.Email, "a brand new passphrase")
+	require.NoError(t, err)
+
+	err = env.auth.ResetPassword(ctx, token, "another new passphrase")
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestPasswordResetTokenExpires(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+
+	token := requestReset(t, env, user.Email)
+	env.resets.update(user.ID, func(reset *models.PasswordResetToken) {
+		reset.ExpiresAt = time.Now().Add(-time.Second)
+	})
+
+	err := env.auth.ResetPassword(context.Background(), token, "a brand new passphrase")
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestPasswordResetKeepsOnlyLatestLink(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	first := requestReset(t, env, user.Email)
+	second := requestReset(t, env, user.Email)
+
+	assert.ErrorIs(t, env.auth.ResetPassword(ctx, first, "a brand new passphrase"), ErrInvalidResetToken)
+	assert.NoError(t, env.auth.ResetPassword(ctx, second, "a brand new passphrase"))
+}
+
+func TestPasswordResetVerifiesEmail(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	// Cadastro prévio com o email ainda não verificado
+	user.EmailVerified = false
+	require.NoError(t, env.users.Update(ctx, user))
+	require.NoError(t, env.passkeys.Create(ctx, &models.WebAuthnCredential{ID: uuid.New(), UserID: user.ID}))
+
+	token := requestReset(t, env, user.Email)
+	require.NoError(t, env.auth.ResetPassword(ctx, token, "a brand new passphrase"))
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.EmailVerified)
+	require.NotNil(t, stored.PasswordHash)
+
+	passkeys, err := env.passkeys.ListByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, passkeys)
+}
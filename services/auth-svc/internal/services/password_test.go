@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/password"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "correct horse battery staple"
+
+// setPasswordHash grava o hash da senha direto no repositório
+func setPasswordHash(t *testing.T, env *testEnv, user *models.User, hash string) {
+	t.Helper()
+
+	user.PasswordHash = &hash
+	require.NoError(t, env.users.Update(context.Background(), user))
+}
+
+func TestLoginLocksAccountAfterFailedAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	hash, err := password.Hash(testPassword)
+	require.NoError(t, err)
+	setPasswordHash(t, env, user, hash)
+
+	// Um acerto zera o contador de falhas anteriores
+	_, err = env.auth.Login(ctx, user.Email, "wrong password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = env.auth.Login(ctx, user.Email, testPassword)
+	require.NoError(t, err)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.FailedLoginAttempts)
+
+	for i := 0; i < maxFailedLoginAttempts; i++ {
+		_, err = env.auth.Login(ctx, user.Email, "wrong password")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// Bloqueada, nem a senha certa é aceita
+	_, err = env.auth.Login(ctx, user.Email, testPassword)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	stored, err = env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsLocked())
+}
+
+func TestLoginRehashesLegacyPassword(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+	setPasswordHash(t, env, user, string(legacy))
+
+	_, err = env.auth.Login(ctx, user.Email, testPassword)
+	require.NoError(t, err)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.PasswordHash)
+	assert.True(t, strings.HasPrefix(*stored.PasswordHash, "$argon2id$"))
+
+	ok, needsRehash, err := password.Verify(testPassword, *stored.PasswordHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+}
+
+func TestChangePasswordCountsWrongCurrentPassword(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	hash, err := password.Hash(testPassword)
+	require.NoError(t, err)
+	setPasswordHash(t, env, user, hash)
+
+	for i := 0; i < maxFailedLoginAttempts; i++ {
+		err := env.auth.ChangePassword(ctx, user.ID, uuid.New(), "wrong password", "a brand new passphrase")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	err = env.auth.ChangePassword(ctx, user.ID, uuid.New(), testPassword, "a brand new passphrase")
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	_, err = env.auth.Login(ctx, user.Email, testPassword)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+}
+
+func TestChangePasswordSetsFirstPasswordAfterRecentLogin(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	auth, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	_, claims, err := env.auth.ValidateAccessToken(ctx, auth.AccessToken)
+	require.NoError(t, err)
+
+	// Sessões antigas não bastam para definir a senha sem a atual
+	env.sessions.update(claims.SessionID, func(s *models.Session) {
+		s.CreatedAt = s.CreatedAt.Add(-recentLoginWindow - time.Minute)
+	})
+	err = env.auth.ChangePassword(ctx, user.ID, claims.SessionID, "", "a brand new passphrase")
+	assert.ErrorIs(t, err, ErrRecentLoginRequired)
+
+	env.sessions.update(claims.SessionID, func(s *models.Session) {
+		s.CreatedAt = time.Now()
+	})
+	require.NoError(t, env.auth.ChangePassword(ctx, user.ID, claims.SessionID, "", "a brand new passphrase"))
+
+	_, err = env.auth.Login(ctx, user.Email, "a brand new passphrase")
+	assert.NoError(t, err)
+
+	// Com a senha definida, a atual volta a ser exigida
+	err = env.auth.ChangePassword(ctx, user.ID, claims.SessionID, "", "another new passphrase")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
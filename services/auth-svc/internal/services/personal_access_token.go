@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/pkg/authz"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PersonalAccessTokenPrefix identifica tokens de acesso pessoal, permitindo
+	// distingui-los de JWTs e detectá-los em scanners de segredos
+	PersonalAccessTokenPrefix = "pm_pat_"
+	// personalAccessTokenPrefixLen caracteres exibidos para identificar o token
+	personalAccessTokenPrefixLen = len(PersonalAccessTokenPrefix) + 8
+	maxPersonalAccessTokens      = 50
+	// patTouchInterval intervalo mínimo entre gravações de último uso
+	patTouchInterval = time.Minute
+)
+
+// personalAccessTokenScopes escopos que podem ser concedidos a um token
+var personalAccessTokenScopes = map[string]bool{
+	authz.ScopeBuildsRead:       true,
+	authz.ScopeBuildsWrite:      true,
+	authz.ScopeDeploymentsRead:  true,
+	authz.ScopeDeploymentsWrite: true,
+	authz.ScopeSitesRead:        true,
+}
+
+var (
+	// ErrInvalidScope escopo desconhecido ou lista de escopos vazia
+	ErrInvalidScope = errors.New("invalid token scope")
+	// ErrInvalidExpiry expiração no passado
+	ErrInvalidExpiry = errors.New("token expiry must be in the future")
+	// ErrTooManyTokens limite de tokens ativos atingido
+	ErrTooManyTokens = errors.New("too many active personal access tokens")
+)
+
+// CreatePersonalAccessToken cria um token com os escopos informados. O
+// segredo é retornado apenas aqui; depois só o hash fica armazenado.
+func (s *AuthService) CreatePersonalAccessToken(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (token *models.PersonalAccessToken, plain string, err error) {
+	defer func() {
+		var metadata map[string]interface{}
+		if token != nil {
+			metadata = map[string]interface{}{"token_id": token.ID.String(), "name": token.Name, "scopes": token.Scopes}
+		}
+		s.audit(ctx, models.AuditAccessTokenCreated, &userID, err, metadata)
+	}()
+
+	scopes, err = normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	count, err := s.patRepo.CountActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= maxPersonalAccessTokens {
+		return nil, "", ErrTooManyTokens
+	}
+
+	secret, err := s.generateSecureToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	plain = PersonalAccessTokenPrefix + secret
+
+	record := &models.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:personalAccessTokenPrefixLen],
+		TokenHash: hashToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.patRepo.Create(ctx, record); err != nil {
+		return nil, "", err
+	}
+
+	return record, plain, nil
+}
+
+// ListPersonalAccessTokens lista os tokens não revogados do usuário
+func (s *AuthService) ListPersonalAccessTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	return s.patRepo.ListByUserID(ctx, userID)
+}
+
+// RevokePersonalAccessToken revoga um token do usuário
+func (s *AuthService) RevokePersonalAccessToken(ctx context.Context, userID, tokenID uuid.UUID) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditAccessTokenRevoked, &userID, err, map[string]interface{}{"token_id": tokenID.String()})
+	}()
+
+	return s.patRepo.Revoke(ctx, userID, tokenID)
+}
+
+// validatePersonalAccessToken valida um token pm_pat_ e o representa como
+// claims do tipo "pat", com os escopos do token e sem sessão
+func (s *AuthService) validatePersonalAccessToken(ctx context.Context, plain string) (*models.User, *models.JWTClaims, error) {
+	token, err := s.patRepo.GetByHash(ctx, hashToken(plain))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: unknown personal access token", ErrInvalidAccessToken)
+		}
+		return nil, nil, err
+	}
+
+	if !token.IsActive() {
+		return nil, nil, fmt.Errorf("%w: personal access token revoked or expired", ErrInvalidAccessToken)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: user not found", ErrInvalidAccessToken)
+		}
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidAccessToken, ErrAccountSuspended)
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > patTouchInterval {
+		info := ClientInfoFromContext(ctx)
+		if err := s.patRepo.TouchLastUsed(ctx, token.ID, optionalString(info.IPAddress)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	claims := &models.JWTClaims{
+		ID:     token.ID.String(),
+		UserID: user.ID,
+		Email:  user.Email,
+		Iat:    token.CreatedAt.Unix(),
+		Type:   "pat",
+		Scopes: token.Scopes,
+	}
+	if token.ExpiresAt != nil {
+		claims.Exp = token.ExpiresAt.Unix()
+	}
+
+	return user, claims, nil
+}
+
+// normalizeScopes valida, remove duplicados e ordena os escopos
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !personalAccessTokenScopes[scope] {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+
+	if len(normalized) == 0 {
+		return nil, ErrInvalidScope
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/internal/sms"
+
+	"github.com/google/uuid"
+)
+
+const (
+	smsCodeDigits = 6
+	smsCodeTTL    = 10 * time.Minute
+	// smsMaxAttempts códigos errados tolerados antes de o código ser descartado
+	smsMaxAttempts = 5
+	// smsResendInterval intervalo mínimo entre dois envios para o mesmo usuário
+	smsResendInterval = time.Minute
+	// smsMaxSends envios permitidos enquanto o código anterior não expira
+	smsMaxSends = 5
+)
+
+var (
+	// ErrSMSUnavailable nenhum provedor de SMS configurado
+	ErrSMSUnavailable = errors.New("sms delivery is not configured")
+	// ErrInvalidSMSCode código SMS errado, expirado ou já usado
+	ErrInvalidSMSCode = errors.New("invalid or expired sms code")
+	// ErrMobileNotVerified o usuário não tem número de celular verificado
+	ErrMobileNotVerified = errors.New("mobile number not verified")
+	// ErrSMSMFAAlreadyEnabled SMS já está ativo como segundo fator
+	ErrSMSMFAAlreadyEnabled = errors.New("sms two-factor already enabled")
+	// ErrSMSMFANotEnabled SMS não está ativo como segundo fator
+	ErrSMSMFANotEnabled = errors.New("sms two-factor not enabled")
+)
+
+// smsCodeMessages texto da mensagem por idioma; o primeiro argumento é o código
+var smsCodeMessages = map[string]string{
+	"en": "%s is your Page Magic verification code. It expires in %d minutes.",
+	"pt": "%s é o seu código de verificação do Page Magic. Ele expira em %d minutos.",
+	"es": "%s es tu código de verificación de Page Magic. Caduca en %d minutos.",
+}
+
+// PhoneService verificação do número de celular por SMS e uso do SMS como
+// segundo fator de login opcional
+type PhoneService struct {
+	auth      *AuthService
+	repo      *repository.Repository
+	userRepo  repository.UserRepository
+	phoneRepo repository.PhoneVerificationRepository
+	sender    sms.SMSSender
+}
+
+func NewPhoneService(auth *AuthService, repo *repository.Repository, sender sms.SMSSender) *PhoneService {
+	return &PhoneService{
+		auth:      auth,
+		repo:      repo,
+		userRepo:  repo.User,
+		phoneRepo: repo.Phone,
+		sender:    sender,
+	}
+}
+
+// StartVerification envia um código para o número informado. O número só é
+// gravado no perfil depois que o código é confirmado.
+func (s *PhoneService) StartVerification(ctx context.Context, userID uuid.UUID, number string) (phone string, err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditMobileCodeSent, &userID, err, map[string]interface{}{"purpose": string(models.PhoneVerificationVerify)})
+	}()
+
+	phone, err = sms.NormalizeE164(number)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.sendCode(ctx, user, phone, models.PhoneVerificationVerify); err != nil {
+		return "", err
+	}
+
+	return phone, nil
+}
+
+// ConfirmVerification grava o número como verificado a partir do código
+// enviado por StartVerification
+func (s *PhoneService) ConfirmVerification(ctx context.Context, userID uuid.UUID, code string) (user *models.User, err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditMobileVerified, &userID, err, nil)
+	}()
+
+	verification, err := s.checkCode(ctx, userID, models.PhoneVerificationVerify, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	phone := verification.PhoneNumber
+	user.MobileNumber = &phone
+	user.MobileVerified = true
+
+	if err := s.saveUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RemoveMobile remove o número do perfil e desativa o SMS como segundo fator
+func (s *PhoneService) RemoveMobile(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditMobileRemoved, &userID, err, nil)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	user.MobileNumber = nil
+	user.MobileVerified = false
+	user.SMSMFAEnabled = false
+
+	if err := s.saveUser(ctx, user); err != nil {
+		return err
+	}
+
+	return s.phoneRepo.DeleteByUserID(ctx, userID)
+}
+
+// EnableSMSFactor ativa o SMS como segundo fator; exige número verificado
+func (s *PhoneService) EnableSMSFactor(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditSMSMFAEnabled, &userID, err, nil)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MobileVerified || user.MobileNumber == nil {
+		return ErrMobileNotVerified
+	}
+	if user.SMSMFAEnabled {
+		return ErrSMSMFAAlreadyEnabled
+	}
+
+	user.SMSMFAEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// DisableSMSFactor desativa o SMS como segundo fator, mantendo o número
+func (s *PhoneService) DisableSMSFactor(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditSMSMFADisabled, &userID, err, nil)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.SMSMFAEnabled {
+		return ErrSMSMFANotEnabled
+	}
+
+	user.SMSMFAEnabled = false
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// SendMFACode envia o código de segundo fator para o número verificado do
+// usuário do token mfa_pending
+func (s *PhoneService) SendMFACode(ctx context.Context, mfaToken string) (err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.auth.audit(ctx, models.AuditMobileCodeSent, userID, err, map[string]interface{}{"purpose": string(models.PhoneVerificationLogin)})
+	}()
+
+	challenge, err := s.auth.loadMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return err
+	}
+	user := challenge.user
+	userID = &user.ID
+
+	if !user.SMSMFAEnabled || !user.MobileVerified || user.MobileNumber == nil {
+		return ErrInvalidMFAToken
+	}
+
+	return s.sendCode(ctx, user, *user.MobileNumber, models.PhoneVerificationLogin)
+}
+
+// VerifyMFACode troca um token mfa_pending e o código recebido por SMS por
+// um par de tokens. Códigos errados contam para o bloqueio da conta.
+func (s *PhoneService) VerifyMFACode(ctx context.Context, mfaToken, code string) (auth *models.AuthResponse, err error) {
+	var userID *uuid.UUID
+	defer func() {
+		s.auth.auditAuth(ctx, models.AuditMFAVerified, userID, auth, err, map[string]interface{}{"method": "sms"})
+	}()
+
+	challenge, err := s.auth.loadMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	user := challenge.user
+	userID = &user.ID
+
+	if !user.SMSMFAEnabled || !user.MobileVerified || user.MobileNumber == nil {
+		return nil, ErrInvalidMFACode
+	}
+
+	verification, err := s.checkCode(ctx, user.ID, models.PhoneVerificationLogin, code)
+	if err == nil && verification.PhoneNumber != *user.MobileNumber {
+		// O número mudou depois do envio
+		err = ErrInvalidSMSCode
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidSMSCode) {
+			if err := s.auth.recordFailedLogin(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	return s.auth.completeMFA(ctx, challenge)
+}
+
+// sendCode gera um novo código para a finalidade e o envia por SMS. Reenvios
+// respeitam um intervalo mínimo e um limite por janela de validade, contados
+// por usuário independentemente do número, para que a conta não seja usada
+// para disparar mensagens.
+func (s *PhoneService) sendCode(ctx context.Context, user *models.User, phone string, purpose models.PhoneVerificationPurpose) error {
+	if s.sender == nil {
+		return ErrSMSUnavailable
+	}
+
+	now := time.Now()
+	sends := 1
+
+	previous, err := s.phoneRepo.Get(ctx, user.ID, purpose)
+	switch {
+	case err == nil && now.Before(previous.ExpiresAt):
+		if wait := previous.LastSentAt.Add(smsResendInterval).Sub(now); wait > 0 {
+			return &RateLimitError{RetryAfter: wait}
+		}
+		if previous.Sends >= smsMaxSends {
+			return &RateLimitError{RetryAfter: previous.ExpiresAt.Sub(now)}
+		}
+		sends = previous.Sends + 1
+	case err != nil && !errors.Is(err, repository.ErrNotFound):
+		return err
+	}
+
+	code, err := generateNumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("failed to generate sms code: %w", err)
+	}
+
+	verification := &models.PhoneVerification{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		PhoneNumber: phone,
+		Purpose:     purpose,
+		Sends:       sends,
+		ExpiresAt:   now.Add(smsCodeTTL),
+		LastSentAt:  now,
+		CreatedAt:   now,
+	}
+	verification.CodeHash = hashCode(verification.ID, code)
+
+	if err := s.phoneRepo.Upsert(ctx, verification); err != nil {
+		return err
+	}
+
+	message, ok := smsCodeMessages[user.Locale]
+	if !ok {
+		message = smsCodeMessages["en"]
+	}
+
+	if err := s.sender.Send(ctx, phone, fmt.Sprintf(message, code, int(smsCodeTTL.Minutes()))); err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+
+	return nil
+}
+
+// checkCode valida e consome o código da finalidade. Cada erro conta uma
+// tentativa; ao atingir o limite o código é descartado.
+func (s *PhoneService) checkCode(ctx context.Context, userID uuid.UUID, purpose models.PhoneVerificationPurpose, code string) (*models.PhoneVerification, error) {
+	verification, err := s.phoneRepo.Get(ctx, userID, purpose)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidSMSCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(verification.ExpiresAt) || verification.Attempts >= smsMaxAttempts {
+		return nil, fmt.Errorf("%w: code expired", ErrInvalidSMSCode)
+	}
+
+	expected := hashCode(verification.ID, strings.TrimSpace(code))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(verification.CodeHash)) != 1 {
+		attempts, err := s.phoneRepo.IncrementAttempts(ctx, verification.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		if attempts >= smsMaxAttempts {
+			if err := s.phoneRepo.Delete(ctx, verification.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidSMSCode
+	}
+
+	// Consumir o código impede que ele seja usado duas vezes
+	if err := s.phoneRepo.Delete(ctx, verification.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidSMSCode
+		}
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// saveUser grava o perfil e publica user.updated na mesma transação
+func (s *PhoneService) saveUser(ctx context.Context, user *models.User) error {
+	return s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.User.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		return enqueueEvent(ctx, tx, events.UserUpdated, events.NewUserData(user))
+	})
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/sms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPhone = "+5511912345678"
+
+func newPhoneTestService(t *testing.T) (*testEnv, *PhoneService, *sms.Fake) {
+	t.Helper()
+
+	env := newTestEnv(t)
+	sender := sms.NewFake(nil)
+	return env, NewPhoneService(env.auth, env.repo, sender), sender
+}
+
+// lastCode extrai o código da última mensagem enviada ao número
+func lastCode(t *testing.T, sender *sms.Fake, phone string) string {
+	t.Helper()
+
+	message, ok := sender.Last(phone)
+	require.True(t, ok, "no sms sent to %s", phone)
+	return strings.Fields(message.Body)[0]
+}
+
+// wrongCode retorna um código diferente do informado, com os mesmos dígitos
+func wrongCode(code string) string {
+	first := (code[0]-'0'+1)%10 + '0'
+	return string(first) + code[1:]
+}
+
+func TestPhoneVerification(t *testing.T) {
+	env, service, sender := newPhoneTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	phone, err := service.StartVerification(ctx, user.ID, "+55 (11) 91234-5678")
+	require.NoError(t, err)
+	assert.Equal(t, testPhone, phone)
+
+	code := lastCode(t, sender, testPhone)
+	assert.Len(t, code, smsCodeDigits)
+
+	// O número só vai para o perfil depois da confirmação
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.MobileNumber)
+
+	updated, err := service.ConfirmVerification(ctx, user.ID, " "+code+" ")
+	require.NoError(t, err)
+	require.NotNil(t, updated.MobileNumber)
+	assert.Equal(t, testPhone, *updated.MobileNumber)
+	assert.True(t, updated.MobileVerified)
+
+	// O código é de uso único
+	_, err = service.ConfirmVerification(ctx, user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidSMSCode)
+}
+
+func TestPhoneCodeAttemptLimit(t *testing.T) {
+	env, service, sender := newPhoneTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	_, err := service.StartVerification(ctx, user.ID, testPhone)
+	require.NoError(t, err)
+	code := lastCode(t, sender, testPhone)
+
+	for i := 0; i < smsMaxAttempts; i++ {
+		_, err := service.ConfirmVerification(ctx, user.ID, wrongCode(code))
+		require.ErrorIs(t, err, ErrInvalidSMSCode)
+	}
+
+	// Esgotadas as tentativas, nem o código certo é aceito
+	_, err = service.ConfirmVerification(ctx, user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidSMSCode)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.MobileVerified)
+}
+
+func TestPhoneCodeExpires(t *testing.T) {
+	env, service, sender := newPhoneTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	_, err := service.StartVerification(ctx, user.ID, testPhone)
+	require.NoError(t, err)
+	code := lastCode(t, sender, testPhone)
+
+	env.phones.update(user.ID, models.PhoneVerificationVerify, func(v *models.PhoneVerification) {
+		assert.WithinDuration(t, time.Now().Add(smsCodeTTL), v.ExpiresAt, time.Minute)
+		v.ExpiresAt = time.Now().Add(-time.Second)
+	})
+
+	_, err = service.ConfirmVerification(ctx, user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidSMSCode)
+}
+
+func TestPhoneResendLimits(t *testing.T) {
+	env, service, sender := newPhoneTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	// moveBack simula a passagem do intervalo entre reenvios
+	moveBack := func(d time.Duration) {
+		env.phones.update(user.ID, models.PhoneVerificationVerify, func(v *models.PhoneVerification) {
+			v.LastSentAt = v.LastSentAt.Add(-d)
+			v.ExpiresAt = v.ExpiresAt.Add(-d)
+		})
+	}
+
+	_, err := service.StartVerification(ctx, user.ID, testPhone)
+	require.NoError(t, err)
+
+	var limited *RateLimitError
+	_, err = service.StartVerification(ctx, user.ID, testPhone)
+	require.ErrorAs(t, err, &limited)
+	assert.LessOrEqual(t, limited.RetryAfter, smsResendInterval)
+
+	// O intervalo vale por usuário, mesmo para outro número
+	_, err = service.StartVerification(ctx, user.ID, "+5511987654321")
+	require.ErrorAs(t, err, &limited)
+
+	for i := 1; i < smsMaxSends; i++ {
+		moveBack(smsResendInterval)
+		_, err = service.StartVerification(ctx, user.ID, testPhone)
+		require.NoError(t, err)
+	}
+	assert.Len(t, sender.Messages(), smsMaxSends)
+
+	// Esgotados os envios, só depois que o código expirar
+	moveBack(smsResendInterval)
+	_, err = service.StartVerification(ctx, user.ID, testPhone)
+	require.ErrorAs(t, err, &limited)
+	assert.Greater(t, limited.RetryAfter, smsResendInterval)
+
+	moveBack(smsCodeTTL)
+	_, err = service.StartVerification(ctx, user.ID, testPhone)
+	assert.NoError(t, err)
+}
+
+func TestSMSSecondFactor(t *testing.T) {
+	env, service, sender := newPhoneTestService(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	phone := testPhone
+	user.MobileNumber = &phone
+	user.MobileVerified = true
+	user.SMSMFAEnabled = true
+	require.NoError(t, env.users.Update(ctx, user))
+
+	pending, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	require.NotEmpty(t, pending.MFAToken)
+	assert.Contains(t, pending.MFAMethods, "sms")
+
+	require.NoError(t, service.SendMFACode(ctx, pending.MFAToken))
+	code := lastCode(t, sender, testPhone)
+
+	// Códigos errados contam para o bloqueio da conta
+	_, err = service.VerifyMFACode(ctx, pending.MFAToken, wrongCode(code))
+	assert.ErrorIs(t, err, ErrInvalidSMSCode)
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.FailedLoginAttempts)
+
+	auth, err := service.VerifyMFACode(ctx, pending.MFAToken, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, auth.AccessToken)
+
+	stored, err = env.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.FailedLoginAttempts)
+}
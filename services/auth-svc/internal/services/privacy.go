@@ -0,0 +1,415 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	privacyInterval     = 30 * time.Second
+	deletionBatchSize   = 50
+	exportFailureReason = "export generation failed"
+)
+
+var (
+	// ErrExportInProgress já existe uma exportação pendente ou pronta para o usuário
+	ErrExportInProgress = errors.New("data export already in progress")
+	// ErrExportNotReady a exportação ainda não foi gerada, falhou ou expirou
+	ErrExportNotReady = errors.New("data export not ready")
+	// ErrDeletionAlreadyScheduled a exclusão da conta já foi solicitada
+	ErrDeletionAlreadyScheduled = errors.New("account deletion already scheduled")
+	// ErrDeletionNotScheduled não há exclusão pendente para cancelar
+	ErrDeletionNotScheduled = errors.New("account deletion not scheduled")
+	// ErrSoleOrganizationOwner o usuário é o único proprietário de uma organização com outros membros
+	ErrSoleOrganizationOwner = errors.New("user is the sole owner of an organization with other members")
+)
+
+// PrivacyService exportação dos dados da conta e exclusão com período de
+// carência (LGPD/GDPR). Os jobs rodam em segundo plano em Run.
+type PrivacyService struct {
+	auth   *AuthService
+	repo   *repository.Repository
+	config config.PrivacyConfig
+}
+
+func NewPrivacyService(auth *AuthService, repo *repository.Repository, cfg config.PrivacyConfig) *PrivacyService {
+	return &PrivacyService{
+		auth:   auth,
+		repo:   repo,
+		config: cfg,
+	}
+}
+
+// RequestExport enfileira a geração do arquivo com os dados do usuário
+func (s *PrivacyService) RequestExport(ctx context.Context, userID uuid.UUID) (export *models.DataExport, err error) {
+	defer func() {
+		var metadata map[string]interface{}
+		if export != nil {
+			metadata = map[string]interface{}{"export_id": export.ID.String()}
+		}
+		s.auth.audit(ctx, models.AuditDataExportRequested, &userID, err, metadata)
+	}()
+
+	_, err = s.repo.DataExport.GetActiveByUserID(ctx, userID)
+	if err == nil {
+		return nil, ErrExportInProgress
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	export = &models.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.DataExport.Create(ctx, export); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// GetExport retorna o estado de uma exportação do usuário
+func (s *PrivacyService) GetExport(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error) {
+	return s.repo.DataExport.GetByID(ctx, userID, exportID)
+}
+
+// DownloadExport retorna o arquivo zip de uma exportação pronta
+func (s *PrivacyService) DownloadExport(ctx context.Context, userID, exportID uuid.UUID) (archive []byte, err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditDataExportDownloaded, &userID, err, map[string]interface{}{"export_id": exportID.String()})
+	}()
+
+	if _, err := s.repo.DataExport.GetByID(ctx, userID, exportID); err != nil {
+		return nil, err
+	}
+
+	archive, err = s.repo.DataExport.GetArchive(ctx, userID, exportID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrExportNotReady
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// ScheduleDeletion agenda a exclusão da conta para o fim do período de
+// carência. Até lá o usuário continua acessando a conta e pode cancelar.
+func (s *PrivacyService) ScheduleDeletion(ctx context.Context, userID uuid.UUID) (deletion *models.AccountDeletion, err error) {
+	defer func() {
+		var metadata map[string]interface{}
+		if deletion != nil {
+			metadata = map[string]interface{}{"scheduled_for": deletion.ScheduledFor.UTC().Format(time.RFC3339)}
+		}
+		s.auth.audit(ctx, models.AuditDeletionScheduled, &userID, err, metadata)
+	}()
+
+	user, err := s.repo.User.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repo.Deletion.GetByUserID(ctx, userID)
+	if err == nil {
+		return nil, ErrDeletionAlreadyScheduled
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if err := checkOrganizationOwnership(ctx, s.repo.Organization, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deletion = &models.AccountDeletion{
+		UserID:       userID,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(s.config.DeletionGracePeriod),
+	}
+	if err := s.repo.Deletion.Schedule(ctx, deletion); err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.sendEmail(ctx, user.Email, "account_deletion_scheduled", user.Locale, map[string]interface{}{
+		"Name":         user.FullName(),
+		"ScheduledFor": deletion.ScheduledFor.UTC().Format("2006-01-02 15:04 UTC"),
+		"Link":         s.auth.config.Server.AppURL + "/settings/privacy",
+	}); err != nil {
+		// O pedido já foi registrado; o aviso não deve desfazê-lo
+		log.Printf("Failed to send account deletion notice to user %s: %v", userID, err)
+	}
+
+	return deletion, nil
+}
+
+// GetDeletion retorna a exclusão agendada para o usuário
+func (s *PrivacyService) GetDeletion(ctx context.Context, userID uuid.UUID) (*models.AccountDeletion, error) {
+	deletion, err := s.repo.Deletion.GetByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeletionNotScheduled
+	}
+	return deletion, err
+}
+
+// CancelDeletion cancela a exclusão agendada
+func (s *PrivacyService) CancelDeletion(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() {
+		s.auth.audit(ctx, models.AuditDeletionCanceled, &userID, err, nil)
+	}()
+
+	err = s.repo.Deletion.Cancel(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeletionNotScheduled
+	}
+	return err
+}
+
+// checkOrganizationOwnership impede que a exclusão deixe sem proprietário
+// uma organização que tem outros membros; organizações em que o usuário é
+// o único membro são apagadas junto com a conta. Roda no agendamento e de
+// novo na exclusão, porque no período de carência o usuário pode convidar
+// membros.
+func checkOrganizationOwnership(ctx context.Context, orgs repository.OrganizationRepository, userID uuid.UUID) error {
+	memberships, err := orgs.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, membership := range memberships {
+		if membership.Role != models.OrgRoleOwner {
+			continue
+		}
+
+		owners, err := orgs.CountOwners(ctx, membership.ID)
+		if err != nil {
+			return err
+		}
+		if owners > 1 {
+			continue
+		}
+
+		members, err := orgs.ListMembers(ctx, membership.ID)
+		if err != nil {
+			return err
+		}
+		if len(members) > 1 {
+			return ErrSoleOrganizationOwner
+		}
+	}
+
+	return nil
+}
+
+// Run processa exportações pendentes e exclusões vencidas até o contexto
+// ser cancelado. Pode rodar em várias instâncias ao mesmo tempo.
+func (s *PrivacyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(privacyInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processExports(ctx)
+		s.processDeletions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processExports gera os arquivos de todas as exportações pendentes
+func (s *PrivacyService) processExports(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := s.repo.DataExport.ClaimPending(ctx)
+		if errors.Is(err, repository.ErrNotFound) {
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to claim data export: %v", err)
+			return
+		}
+
+		if err := s.generateExport(ctx, export); err != nil {
+			log.Printf("Failed to generate data export %s: %v", export.ID, err)
+			if err := s.repo.DataExport.Fail(ctx, export.ID, exportFailureReason); err != nil {
+				log.Printf("Failed to mark data export %s as failed: %v", export.ID, err)
+			}
+		}
+	}
+}
+
+// generateExport monta o zip com perfil, identidades vinculadas, sessões e
+// log de auditoria do usuário e avisa por email que ele está disponível
+func (s *PrivacyService) generateExport(ctx context.Context, export *models.DataExport) error {
+	user, err := s.repo.User.GetByID(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	roles, err := s.repo.Role.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	organizations, err := s.repo.Organization.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	providers, err := s.repo.AuthProvider.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	sessions, err := s.repo.Session.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", map[string]interface{}{
+			"user":          user,
+			"roles":         roles,
+			"organizations": organizations,
+		}},
+		{"providers.json", providers},
+		{"sessions.json", sessions},
+	}
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	w, err := archive.Create("audit_log.ndjson")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	err = s.repo.Audit.Stream(ctx, models.AuditLogFilter{UserID: &user.ID}, func(entry *models.AuditLog) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.config.ExportTTL)
+	if err := s.repo.DataExport.Complete(ctx, export.ID, buf.Bytes(), expiresAt); err != nil {
+		return err
+	}
+
+	if err := s.auth.sendEmail(ctx, user.Email, "data_export_ready", user.Locale, map[string]interface{}{
+		"Name":      user.FullName(),
+		"Link":      s.auth.config.Server.AppURL + "/settings/privacy",
+		"ExpiresAt": expiresAt.UTC().Format("2006-01-02 15:04 UTC"),
+	}); err != nil {
+		log.Printf("Failed to send data export notice to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// processDeletions apaga as contas cujo período de carência terminou
+func (s *PrivacyService) processDeletions(ctx context.Context) {
+	due, err := s.repo.Deletion.ListDue(ctx, time.Now(), deletionBatchSize)
+	if err != nil {
+		log.Printf("Failed to list due account deletions: %v", err)
+		return
+	}
+
+	for _, deletion := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.eraseAccount(ctx, deletion.UserID); err != nil {
+			log.Printf("Failed to erase account %s: %v", deletion.UserID, err)
+		}
+	}
+}
+
+// eraseAccount apaga os dados da conta, invalida os access tokens ainda
+// válidos e publica user.deleted para que os demais serviços removam os seus
+func (s *PrivacyService) eraseAccount(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.repo.User.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	err = s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		// Remover o pedido primeiro serializa instâncias concorrentes: a
+		// segunda espera o bloqueio da linha e não encontra mais o pedido
+		if err := tx.Deletion.Cancel(ctx, userID); err != nil {
+			return err
+		}
+		if err := checkOrganizationOwnership(ctx, tx.Organization, userID); err != nil {
+			return err
+		}
+		if err := tx.Deletion.Erase(ctx, userID, user.Email); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, tx, events.UserDeleted, events.UserDeletedData{
+			UserID:    userID,
+			DeletedAt: deletedAt,
+		})
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		// Cancelado ou já processado por outra instância
+		return nil
+	}
+	if errors.Is(err, ErrSoleOrganizationOwner) {
+		// A conta passou a ser a única proprietária de uma organização com
+		// outros membros: o pedido é cancelado em vez de ficar pendente, e o
+		// motivo fica no histórico do usuário
+		err = s.repo.Deletion.Cancel(ctx, userID)
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			s.auth.audit(ctx, models.AuditDeletionCanceled, &userID, ErrSoleOrganizationOwner, nil)
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// Sessões e refresh tokens já foram apagados; resta recusar os access
+	// tokens emitidos antes da exclusão
+	if err := s.auth.denylist.RevokeUser(ctx, userID, deletedAt, s.auth.config.JWT.AccessTokenTTL); err != nil {
+		log.Printf("Failed to revoke access tokens of erased user %s: %v", userID, err)
+	}
+
+	s.auth.audit(ctx, models.AuditAccountErased, &userID, nil, nil)
+	return nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addOrganization cria uma organização tendo o usuário como proprietário
+func addOrganization(t *testing.T, env *testEnv, owner *models.User) *models.Organization {
+	t.Helper()
+
+	org := &models.Organization{ID: uuid.New(), Name: "Acme", CreatedBy: owner.ID}
+	require.NoError(t, env.orgs.Create(context.Background(), org, &models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         owner.ID,
+		Role:           models.OrgRoleOwner,
+	}))
+	return org
+}
+
+// scheduleDueDeletion registra um pedido de exclusão já vencido
+func scheduleDueDeletion(t *testing.T, env *testEnv, userID uuid.UUID) {
+	t.Helper()
+
+	require.NoError(t, env.deletions.Schedule(context.Background(), &models.AccountDeletion{
+		UserID:       userID,
+		RequestedAt:  time.Now().Add(-time.Hour),
+		ScheduledFor: time.Now().Add(-time.Minute),
+	}))
+}
+
+func TestEraseAccountRechecksOrganizationOwnership(t *testing.T) {
+	env := newTestEnv(t)
+	service := NewPrivacyService(env.auth, env.repo, config.PrivacyConfig{})
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	org := addOrganization(t, env, user)
+	scheduleDueDeletion(t, env, user.ID)
+
+	// Durante o período de carência a organização ganhou outro membro
+	member := env.addUser("bia@example.com")
+	require.NoError(t, env.orgs.AddMember(ctx, &models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         member.ID,
+		Role:           models.OrgRoleEditor,
+	}))
+
+	require.NoError(t, service.eraseAccount(ctx, user.ID))
+	assert.Empty(t, env.deletions.erased)
+
+	_, err := service.GetDeletion(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrDeletionNotScheduled)
+
+	require.NotEmpty(t, env.audit.entries)
+	entry := env.audit.entries[len(env.audit.entries)-1]
+	assert.Equal(t, models.AuditDeletionCanceled, entry.Action)
+	assert.Equal(t, models.AuditResultFailure, entry.Result)
+	assert.Equal(t, ErrSoleOrganizationOwner.Error(), entry.Metadata["reason"])
+}
+
+func TestEraseAccountWithSoleMemberOrganization(t *testing.T) {
+	env := newTestEnv(t)
+	service := NewPrivacyService(env.auth, env.repo, config.PrivacyConfig{})
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	addOrganization(t, env, user)
+	scheduleDueDeletion(t, env, user.ID)
+
+	require.NoError(t, service.eraseAccount(ctx, user.ID))
+	assert.Equal(t, []uuid.UUID{user.ID}, env.deletions.erased)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidAvatarURL URL do avatar não é http(s) absoluta
+	ErrInvalidAvatarURL = errors.New("invalid avatar url")
+	// ErrInvalidTimezone fuso horário fora da base IANA
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+// UpdateProfile altera os dados de perfil informados (campos nil são
+// mantidos; strings vazias limpam nome e avatar). O número de celular não é
+// alterado aqui: ele só muda após a verificação por SMS (phone.go).
+func (s *AuthService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateUserRequest) (user *models.User, err error) {
+	var changed []string
+	defer func() {
+		s.audit(ctx, models.AuditProfileUpdated, &userID, err, map[string]interface{}{"fields": changed})
+	}()
+
+	user, err = s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if req.FirstName != nil {
+		user.FirstName = optionalString(strings.TrimSpace(*req.FirstName))
+		changed = append(changed, "first_name")
+	}
+	if req.LastName != nil {
+		user.LastName = optionalString(strings.TrimSpace(*req.LastName))
+		changed = append(changed, "last_name")
+	}
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if avatarURL != "" && !isHTTPURL(avatarURL) {
+			return nil, ErrInvalidAvatarURL
+		}
+		user.AvatarURL = optionalString(avatarURL)
+		changed = append(changed, "avatar_url")
+	}
+	if req.Locale != nil && *req.Locale != "" {
+		user.Locale = *req.Locale
+		changed = append(changed, "locale")
+	}
+	if req.Timezone != nil && *req.Timezone != "" {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+		user.Timezone = *req.Timezone
+		changed = append(changed, "timezone")
+	}
+	if req.PushNotificationsEnabled != nil {
+		user.PushNotificationsEnabled = *req.PushNotificationsEnabled
+		changed = append(changed, "push_notifications_enabled")
+	}
+
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	err = s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.User.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update profile: %w", err)
+		}
+		return enqueueEvent(ctx, tx, events.UserUpdated, events.NewUserData(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
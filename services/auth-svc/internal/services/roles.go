@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+	"pagemagic/auth-svc/pkg/authz"
+
+	"github.com/google/uuid"
+)
+
+// ErrRoleNotFound papel inexistente ou não atribuído ao usuário
+var ErrRoleNotFound = errors.New("role not found")
+
+// defaultRole papel atribuído a toda conta nova
+const defaultRole = authz.RoleUser
+
+var defaultPermissions = []models.Permission{
+	{Name: authz.PermissionSitesCreate, Resource: "sites", Action: "create", Description: "Create new sites"},
+	{Name: authz.PermissionSitesRead, Resource: "sites", Action: "read", Description: "View sites"},
+	{Name: authz.PermissionSitesUpdate, Resource: "sites", Action: "update", Description: "Edit sites"},
+	{Name: authz.PermissionSitesDelete, Resource: "sites", Action: "delete", Description: "Delete sites"},
+	{Name: authz.PermissionBillingRead, Resource: "billing", Action: "read", Description: "View billing information"},
+	{Name: authz.PermissionBillingManage, Resource: "billing", Action: "manage", Description: "Manage billing and subscriptions"},
+	{Name: authz.PermissionAdminUsers, Resource: "admin", Action: "users", Description: "Manage users"},
+	{Name: authz.PermissionAdminAudit, Resource: "admin", Action: "audit", Description: "Search and export the audit log"},
+	{Name: authz.PermissionAdminSystem, Resource: "admin", Action: "system", Description: "System administration"},
+}
+
+var sitePermissions = []string{
+	authz.PermissionSitesCreate, authz.PermissionSitesRead, authz.PermissionSitesUpdate, authz.PermissionSitesDelete,
+}
+
+var defaultRoles = []models.Role{
+	{
+		Name:        authz.RoleUser,
+		Description: "Regular user with basic permissions",
+		Permissions: append(append([]string{}, sitePermissions...), authz.PermissionBillingRead),
+	},
+	{
+		Name:        authz.RolePro,
+		Description: "Pro user with enhanced features",
+		Permissions: append(append([]string{}, sitePermissions...), authz.PermissionBillingRead, authz.PermissionBillingManage),
+	},
+	{
+		Name:        authz.RoleAdmin,
+		Description: "Administrator with full access",
+		Permissions: append(append([]string{}, sitePermissions...),
+			authz.PermissionBillingRead, authz.PermissionBillingManage,
+			authz.PermissionAdminUsers, authz.PermissionAdminAudit, authz.PermissionAdminSystem,
+		),
+	},
+}
+
+// EnsureDefaultRoles cria ou atualiza os papéis e permissões padrão
+func (s *AuthService) EnsureDefaultRoles(ctx context.Context) error {
+	if err := s.roleRepo.EnsureDefaults(ctx, defaultPermissions, defaultRoles); err != nil {
+		return fmt.Errorf("failed to ensure default roles: %w", err)
+	}
+	return nil
+}
+
+// ListRoles lista os papéis disponíveis
+func (s *AuthService) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+// ListUserRoles lista os papéis atribuídos ao usuário
+func (s *AuthService) ListUserRoles(ctx context.Context, userID uuid.UUID) ([]*models.Role, error) {
+	return s.roleRepo.ListByUserID(ctx, userID)
+}
+
+// AssignRole atribui um papel ao usuário. Tokens já emitidos passam a
+// refletir o papel na próxima renovação.
+func (s *AuthService) AssignRole(ctx context.Context, userID uuid.UUID, roleName string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditRoleAssigned, &userID, err, map[string]interface{}{"role": roleName})
+	}()
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := s.roleRepo.AssignToUser(ctx, userID, roleName); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RemoveRole remove um papel do usuário e encerra suas sessões, para que
+// nenhum access token continue carregando as permissões retiradas
+func (s *AuthService) RemoveRole(ctx context.Context, userID uuid.UUID, roleName string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditRoleRemoved, &userID, err, map[string]interface{}{"role": roleName})
+	}()
+
+	if err := s.roleRepo.RemoveFromUser(ctx, userID, roleName); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+
+	return s.LogoutAll(ctx, userID)
+}
+
+// createUser persiste uma conta nova com o papel padrão e publica
+// user.created
+func (s *AuthService) createUser(ctx context.Context, user *models.User) error {
+	return s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.User.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		if err := tx.Role.AssignToUser(ctx, user.ID, defaultRole); err != nil {
+			return fmt.Errorf("failed to assign default role: %w", err)
+		}
+
+		return enqueueEvent(ctx, tx, events.UserCreated, events.NewUserData(user))
+	})
+}
+
+// userAuthorization retorna os papéis do usuário e a união das permissões
+// concedidas, embutidos no access token
+func (s *AuthService) userAuthorization(ctx context.Context, userID uuid.UUID) ([]string, []string, error) {
+	roles, err := s.roleRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	scopes := []string{}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+		for _, permission := range role.Permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				scopes = append(scopes, permission)
+			}
+		}
+	}
+
+	return roleNames, scopes, nil
+}
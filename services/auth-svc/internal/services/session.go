@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pagemagic/auth-svc/internal/events"
+	"pagemagic/auth-svc/internal/models"
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionRevoked a sessão do refresh token foi encerrada
+var ErrSessionRevoked = errors.New("session revoked")
+
+// issueSession registra a sessão (e o dispositivo) de um novo login e emite
+// o primeiro par de tokens da sessão
+func (s *AuthService) issueSession(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	// Ponto comum a todos os métodos de login
+	if !user.IsActive() {
+		return nil, ErrAccountSuspended
+	}
+
+	client := ClientInfoFromContext(ctx)
+	now := time.Now()
+
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		IPAddress:  optionalString(client.IPAddress),
+		UserAgent:  optionalString(client.UserAgent),
+		ExpiresAt:  now.Add(s.config.JWT.RefreshTokenTTL),
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+
+	if fingerprint := deviceFingerprint(client); fingerprint != "" {
+		deviceType, platform, name := describeUserAgent(client.UserAgent)
+		device, err := s.deviceRepo.Upsert(ctx, &models.Device{
+			ID:          uuid.New(),
+			UserID:      user.ID,
+			Name:        name,
+			Type:        deviceType,
+			Platform:    platform,
+			Fingerprint: fingerprint,
+			LastSeenAt:  now,
+			LastSeenIP:  optionalString(client.IPAddress),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		session.DeviceID = &device.ID
+	}
+
+	err := s.repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.Session.Create(ctx, session); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, tx, events.UserLogin, events.UserLoginData{
+			UserID:    user.ID,
+			SessionID: session.ID,
+			DeviceID:  session.DeviceID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// ListSessions lista as sessões ativas do usuário
+func (s *AuthService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	return s.sessionRepo.ListActiveByUserID(ctx, userID)
+}
+
+// RevokeSession encerra uma sessão do usuário. O refresh token da sessão
+// deixa de funcionar e seus access tokens são recusados imediatamente.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuditSessionRevoked, &userID, err, map[string]interface{}{"session_id": sessionID.String()})
+	}()
+
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	return s.revokeSessionTokens(ctx, sessionID)
+}
+
+// ListDevices lista os dispositivos do usuário
+func (s *AuthService) ListDevices(ctx context.Context, userID uuid.UUID) ([]*models.Device, error) {
+	return s.deviceRepo.ListByUserID(ctx, userID)
+}
+
+// UpdateDevice renomeia e/ou marca o dispositivo como confiável
+func (s *AuthService) UpdateDevice(ctx context.Context, userID, deviceID uuid.UUID, name *string, trusted *bool) (*models.Device, error) {
+	device, err := s.deviceRepo.GetByID(ctx, userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		device.Name = strings.TrimSpace(*name)
+	}
+	if trusted != nil {
+		device.IsTrusted = *trusted
+	}
+
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// revokeSessionTokens revoga a família de refresh tokens da sessão e
+// recusa os access tokens já emitidos com o mesmo sid
+func (s *AuthService) revokeSessionTokens(ctx context.Context, sessionID uuid.UUID) error {
+	if err := s.refreshRepo.RevokeFamily(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session refresh tokens: %w", err)
+	}
+
+	return s.denylist.RevokeSession(ctx, sessionID, s.config.JWT.AccessTokenTTL)
+}
+
+// touchSession valida a sessão no refresh e registra a atividade
+func (s *AuthService) touchSession(ctx context.Context, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+
+	if !session.IsActive() {
+		return ErrSessionRevoked
+	}
+
+	client := ClientInfoFromContext(ctx)
+	return s.sessionRepo.Touch(ctx, sessionID, optionalString(client.IPAddress), time.Now().Add(s.config.JWT.RefreshTokenTTL))
+}
+
+// deviceFingerprint identifica o dispositivo pelo ID enviado pelo app ou,
+// na falta dele, pelo user agent
+func deviceFingerprint(client ClientInfo) string {
+	if client.DeviceID != "" {
+		return hashToken("device:" + client.DeviceID)
+	}
+	if client.UserAgent != "" {
+		return hashToken("ua:" + client.UserAgent)
+	}
+	return ""
+}
+
+// describeUserAgent extrai tipo, plataforma e um nome legível do user agent
+func describeUserAgent(userAgent string) (deviceType, platform, name string) {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "ios"
+	case strings.Contains(ua, "android"):
+		platform = "android"
+	case strings.Contains(ua, "windows"):
+		platform = "windows"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		platform = "macos"
+	case strings.Contains(ua, "linux"):
+		platform = "linux"
+	default:
+		platform = "unknown"
+	}
+
+	switch {
+	case strings.Contains(ua, "mobile"), platform == "ios", platform == "android":
+		deviceType = "mobile"
+	case strings.Contains(ua, "electron"):
+		deviceType = "desktop"
+	default:
+		deviceType = "web"
+	}
+
+	var browser string
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	}
+
+	platformNames := map[string]string{
+		"ios": "iOS", "android": "Android", "windows": "Windows", "macos": "macOS", "linux": "Linux",
+	}
+	switch {
+	case browser != "" && platformNames[platform] != "":
+		name = browser + " on " + platformNames[platform]
+	case browser != "":
+		name = browser
+	case platformNames[platform] != "":
+		name = platformNames[platform]
+	default:
+		name = "Unknown device"
+	}
+
+	return deviceType, platform, name
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"pagemagic/auth-svc/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	first, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+
+	second, err := env.auth.RefreshToken(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	third, err := env.auth.RefreshToken(ctx, second.RefreshToken)
+	require.NoError(t, err)
+
+	_, claims, err := env.auth.ValidateAccessToken(ctx, third.AccessToken)
+	require.NoError(t, err)
+	session, err := env.sessions.GetByID(ctx, claims.SessionID)
+	require.NoError(t, err)
+	assert.True(t, session.IsActive())
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	first, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	second, err := env.auth.RefreshToken(ctx, first.RefreshToken)
+	require.NoError(t, err)
+
+	// Reapresentar um token já rotacionado indica roubo
+	_, err = env.auth.RefreshToken(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// O token legítimo mais recente da família também deixa de valer
+	_, err = env.auth.RefreshToken(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// E os access tokens da sessão são recusados imediatamente
+	_, _, err = env.auth.ValidateAccessToken(ctx, second.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	// Outras sessões do usuário não são afetadas
+	other, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	_, err = env.auth.RefreshToken(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestRefreshTokenRejectsAccessToken(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	auth, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+
+	_, err = env.auth.RefreshToken(ctx, auth.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRevokeSession(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("ana@example.com")
+	ctx := context.Background()
+
+	revoked, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+	kept, err := env.auth.startSession(ctx, user)
+	require.NoError(t, err)
+
+	_, claims, err := env.auth.ValidateAccessToken(ctx, revoked.AccessToken)
+	require.NoError(t, err)
+
+	// Um usuário não encerra sessões de outro
+	other := env.addUser("bia@example.com")
+	assert.ErrorIs(t, env.auth.RevokeSession(ctx, other.ID, claims.SessionID), repository.ErrNotFound)
+
+	require.NoError(t, env.auth.RevokeSession(ctx, user.ID, claims.SessionID))
+
+	// O access token é recusado imediatamente, sem esperar expirar
+	_, _, err = env.auth.ValidateAccessToken(ctx, revoked.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	_, err = env.auth.RefreshToken(ctx, revoked.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, _, err = env.auth.ValidateAccessToken(ctx, kept.AccessToken)
+	assert.NoError(t, err)
+	_, err = env.auth.RefreshToken(ctx, kept.RefreshToken)
+	assert.NoError(t, err)
+}
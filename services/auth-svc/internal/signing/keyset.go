@@ -0,0 +1,180 @@
+// Package signing gerencia as chaves assimétricas usadas para assinar os
+// access tokens e publica suas chaves públicas em formato JWKS.
+//
+// Cada arquivo PEM (PKCS#8) no diretório de chaves é uma chave; o nome do
+// arquivo sem extensão é o kid. Todas as chaves carregadas são publicadas e
+// aceitas na validação, mas apenas a chave ativa assina. Rotação sem
+// downtime:
+//
+//  1. adicionar a nova chave ao diretório e reiniciar (passa a ser publicada);
+//  2. após os consumidores renovarem o cache do JWKS, apontar JWT_ACTIVE_KID
+//     para a nova chave;
+//  3. depois de JWT_ACCESS_TTL, remover a chave antiga.
+//
+// Gerar uma chave: openssl genpkey -algorithm ed25519 -out <kid>.pem
+// (ou -algorithm RSA -pkeyopt rsa_keygen_bits:2048 para RS256).
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey o token referencia um kid que não está no conjunto
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key chave de assinatura com seu identificador
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private chave privada (*rsa.PrivateKey ou ed25519.PrivateKey)
+	Private crypto.Signer
+}
+
+// Public retorna a chave pública correspondente
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// KeySet conjunto de chaves publicadas, com uma chave ativa para assinatura
+type KeySet struct {
+	keys   map[string]*Key
+	active *Key
+}
+
+// LoadDir carrega as chaves *.pem do diretório; activeKID indica a chave
+// que assina os novos tokens
+func LoadDir(dir, activeKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+
+	set := &KeySet{keys: make(map[string]*Key)}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key %s: %w", path, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := ParsePrivateKey(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		set.keys[kid] = key
+	}
+
+	if activeKID == "" && len(set.keys) == 1 {
+		for kid := range set.keys {
+			activeKID = kid
+		}
+	}
+
+	active, ok := set.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found in %s", activeKID, dir)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+// NewEphemeral gera uma chave Ed25519 em memória. Destinado a
+// desenvolvimento: os tokens deixam de ser válidos a cada reinício.
+func NewEphemeral() (*KeySet, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	kid := "dev-" + fmt.Sprintf("%x", private.Public().(ed25519.PublicKey)[:4])
+	key := &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Private: private}
+
+	return &KeySet{keys: map[string]*Key{kid: key}, active: key}, nil
+}
+
+// ParsePrivateKey lê uma chave privada PEM (PKCS#8, ou PKCS#1 para RSA)
+func ParsePrivateKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s: invalid PEM", kid)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", kid, err)
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("signing key %s: RSA keys must have at least 2048 bits", kid)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Private: private}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Private: private}, nil
+	default:
+		return nil, fmt.Errorf("signing key %s: unsupported key type %T", kid, parsed)
+	}
+}
+
+// Active retorna a chave que assina os novos tokens
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Keys retorna todas as chaves publicadas, ordenadas pelo kid
+func (s *KeySet) Keys() []*Key {
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// Sign assina as claims com a chave ativa, incluindo o kid no header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.Private)
+}
+
+// Keyfunc resolve a chave pública pelo kid do header para jwt.Parse,
+// recusando tokens cujo algoritmo não corresponda ao da chave
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Public(), nil
+}
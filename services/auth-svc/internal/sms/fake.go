@@ -0,0 +1,63 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Message SMS registrado pelo Fake
+type Message struct {
+	To     string
+	Body   string
+	SentAt time.Time
+}
+
+// Fake guarda as mensagens em memória, para testes e desenvolvimento. Com
+// out definido, cada mensagem também é escrita nele.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	out      io.Writer
+}
+
+// NewFake cria uma nova instância do Fake; out pode ser nil
+func NewFake(out io.Writer) *Fake {
+	return &Fake{out: out}
+}
+
+// Send registra a mensagem
+func (f *Fake) Send(ctx context.Context, to, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, Message{To: to, Body: body, SentAt: time.Now()})
+	if f.out != nil {
+		fmt.Fprintf(f.out, "SMS to %s: %s\n", to, body)
+	}
+
+	return nil
+}
+
+// Messages retorna uma cópia das mensagens enviadas
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.messages...)
+}
+
+// Last retorna a última mensagem enviada para o número
+func (f *Fake) Last(to string) (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].To == to {
+			return f.messages[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,72 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"pagemagic/auth-svc/internal/config"
+)
+
+// ErrInvalidPhoneNumber número que não pode ser normalizado para E.164
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// SMSSender interface para os backends de envio de SMS
+type SMSSender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// NormalizeE164 normaliza um número internacional para E.164 (+ seguido de
+// 8 a 15 dígitos, sem zero no código do país). Espaços, hífens, pontos e
+// parênteses são ignorados e o prefixo internacional 00 equivale a +.
+// Números sem código do país são recusados, pois a região é desconhecida.
+func NormalizeE164(number string) (string, error) {
+	number = strings.TrimSpace(number)
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	if !strings.HasPrefix(number, "+") {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	var digits strings.Builder
+	for _, r := range number[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	normalized := digits.String()
+	if len(normalized) < 8 || len(normalized) > 15 || normalized[0] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	return "+" + normalized, nil
+}
+
+// New cria o SMSSender configurado em SMSConfig.Provider; sem provedor
+// retorna nil e o envio de SMS fica desativado
+func New(cfg config.SMSConfig) (SMSSender, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "twilio":
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" {
+			return nil, fmt.Errorf("TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN are required for the twilio sms provider")
+		}
+		if cfg.From == "" && cfg.TwilioMessagingServiceSID == "" {
+			return nil, fmt.Errorf("SMS_FROM or TWILIO_MESSAGING_SERVICE_SID is required for the twilio sms provider")
+		}
+		return NewTwilioSender(cfg), nil
+	case "fake":
+		return NewFake(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %s", cfg.Provider)
+	}
+}
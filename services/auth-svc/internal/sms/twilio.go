@@ -0,0 +1,54 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+
+	"pagemagic/auth-svc/internal/config"
+
+	"github.com/twilio/twilio-go"
+	twilioapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// TwilioSender envia SMS pela API de mensagens da Twilio
+type TwilioSender struct {
+	client              *twilio.RestClient
+	from                string
+	messagingServiceSID string
+}
+
+// NewTwilioSender cria uma nova instância do TwilioSender. Com um
+// Messaging Service configurado, ele escolhe o remetente.
+func NewTwilioSender(cfg config.SMSConfig) *TwilioSender {
+	return &TwilioSender{
+		client: twilio.NewRestClientWithParams(twilio.ClientParams{
+			Username: cfg.TwilioAccountSID,
+			Password: cfg.TwilioAuthToken,
+		}),
+		from:                cfg.From,
+		messagingServiceSID: cfg.TwilioMessagingServiceSID,
+	}
+}
+
+// Send envia a mensagem. O cliente da Twilio não aceita contexto; o
+// cancelamento só é verificado antes da chamada.
+func (s *TwilioSender) Send(ctx context.Context, to, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	params := &twilioapi.CreateMessageParams{}
+	params.SetTo(to)
+	params.SetBody(body)
+	if s.messagingServiceSID != "" {
+		params.SetMessagingServiceSid(s.messagingServiceSID)
+	} else {
+		params.SetFrom(s.from)
+	}
+
+	if _, err := s.client.Api.CreateMessage(params); err != nil {
+		return fmt.Errorf("failed to send sms via twilio: %w", err)
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
+//go:build !enterprise
+
 package main
 
 import (
 	"log"
+	"os"
 
 	"pagemagic/auth-svc/internal/app"
 	"pagemagic/auth-svc/internal/config"
@@ -21,6 +24,14 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Subcomando de migrações: auth-svc migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	// Inicializar e executar a aplicação
 	application := app.New(cfg)
 	if err := application.Run(); err != nil {
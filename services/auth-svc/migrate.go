@@ -0,0 +1,70 @@
+//go:build !enterprise
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"pagemagic/auth-svc/internal/config"
+	"pagemagic/auth-svc/internal/migrations"
+)
+
+const migrateUsage = "usage: auth-svc migrate up | down [N] | status"
+
+// runMigrate executa o subcomando migrate com os argumentos restantes
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+	databaseURL := cfg.DatabaseURL()
+
+	switch args[0] {
+	case "up":
+		if err := migrations.Up(ctx, databaseURL); err != nil {
+			return err
+		}
+		fmt.Println("Migrations applied")
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		if err := migrations.Down(ctx, databaseURL, steps); err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", steps)
+
+	case "status":
+		status, err := migrations.GetStatus(ctx, databaseURL)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Version: %d", status.Version)
+		if status.Dirty {
+			fmt.Print(" (dirty)")
+		}
+		fmt.Println()
+		for _, m := range status.Migrations {
+			mark := " "
+			if m.Applied {
+				mark = "x"
+			}
+			fmt.Printf("[%s] %06d %s\n", mark, m.Version, m.Identifier)
+		}
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package authz aplica papéis e permissões dos access tokens do auth-svc em
+// outros serviços. Os middlewares seguem a assinatura
+// func(http.Handler) http.Handler, aceita por gorilla/mux (Router.Use) e
+// net/http; serviços gin usam o adaptador do pacote ginauthz. A validação
+// das assinaturas fica a cargo de um Verifier (ver pacote verifier).
+package authz
+
+import (
+	"context"
+	"strings"
+)
+
+// Permissões conhecidas, no formato recurso.ação
+const (
+	PermissionSitesCreate   = "sites.create"
+	PermissionSitesRead     = "sites.read"
+	PermissionSitesUpdate   = "sites.update"
+	PermissionSitesDelete   = "sites.delete"
+	PermissionBillingRead   = "billing.read"
+	PermissionBillingManage = "billing.manage"
+	PermissionAdminUsers    = "admin.users"
+	PermissionAdminAudit    = "admin.audit"
+	PermissionAdminSystem   = "admin.system"
+)
+
+// Escopos concedidos a tokens de acesso pessoal (CI e scripts)
+const (
+	ScopeBuildsRead       = "builds:read"
+	ScopeBuildsWrite      = "builds:write"
+	ScopeDeploymentsRead  = "deployments:read"
+	ScopeDeploymentsWrite = "deployments:write"
+	ScopeSitesRead        = "sites:read"
+)
+
+// Papéis padrão
+const (
+	RoleUser  = "user"
+	RolePro   = "pro"
+	RoleAdmin = "admin"
+)
+
+// Papéis dentro de uma organização, do maior para o menor privilégio
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+var orgRoleRank = map[string]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleAdmin:  3,
+	OrgRoleOwner:  4,
+}
+
+// Claims dados do access token relevantes para autorização
+type Claims struct {
+	TokenID   string
+	UserID    string
+	SessionID string
+	Email     string
+	Roles     []string
+	// Scopes permissões concedidas ao token (claim "scope", separado por espaços)
+	Scopes []string
+	// OrgID e OrgRole organização ativa da sessão e papel do usuário nela;
+	// vazios quando nenhuma organização está ativa
+	OrgID   string
+	OrgRole string
+}
+
+// HasRole verifica se o token carrega o papel
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission verifica se o token concede a permissão. Um escopo
+// "recurso.*" concede todas as ações do recurso.
+func (c *Claims) HasPermission(permission string) bool {
+	resource, _, _ := strings.Cut(permission, ".")
+	for _, scope := range c.Scopes {
+		if scope == permission || scope == resource+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOrgRole verifica se o token tem uma organização ativa na qual o
+// usuário tenha pelo menos o papel informado
+func (c *Claims) HasOrgRole(min string) bool {
+	return c.OrgID != "" && orgRoleRank[c.OrgRole] >= orgRoleRank[min] && orgRoleRank[min] > 0
+}
+
+type claimsKey struct{}
+
+// WithClaims associa as claims ao contexto
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext retorna as claims autenticadas do contexto
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
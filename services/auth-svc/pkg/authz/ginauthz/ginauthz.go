@@ -0,0 +1,50 @@
+// Package ginauthz adapta os middlewares de authz para gin.
+//
+//	router.Use(ginauthz.Authenticate(verifier))
+//	router.PUT("/sites/:id", ginauthz.RequirePermission("sites.update"), handler)
+package ginauthz
+
+import (
+	"net/http"
+
+	"pagemagic/auth-svc/pkg/authz"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware converte um middleware net/http em um gin.HandlerFunc. Se o
+// middleware não chamar o próximo handler, a cadeia gin é interrompida.
+func Middleware(mw func(http.Handler) http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		called := false
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			c.Request = r
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+
+		if !called {
+			c.Abort()
+		}
+	}
+}
+
+// Authenticate valida o bearer token e guarda as claims no contexto
+func Authenticate(verifier authz.Verifier) gin.HandlerFunc {
+	return Middleware(authz.Authenticate(verifier))
+}
+
+// RequirePermission exige a permissão no token autenticado
+func RequirePermission(permission string) gin.HandlerFunc {
+	return Middleware(authz.RequirePermission(permission))
+}
+
+// RequireRole exige o papel no token autenticado
+func RequireRole(role string) gin.HandlerFunc {
+	return Middleware(authz.RequireRole(role))
+}
+
+// RequireOrgRole exige uma organização ativa com pelo menos o papel informado
+func RequireOrgRole(role string) gin.HandlerFunc {
+	return Middleware(authz.RequireOrgRole(role))
+}
@@ -0,0 +1,88 @@
+package authz
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Authenticate valida o header Authorization: Bearer e disponibiliza as
+// claims no contexto da requisição (ClaimsFromContext)
+func Authenticate(verifier Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "Authorization header required")
+				return
+			}
+
+			claims, err := verifier.Verify(r.Context(), token)
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// RequirePermission recusa requisições cujo token não conceda a permissão.
+// Deve ser montado depois de Authenticate.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return require(func(claims *Claims) bool {
+		return claims.HasPermission(permission)
+	})
+}
+
+// RequireRole recusa requisições cujo token não carregue o papel.
+// Deve ser montado depois de Authenticate.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return require(func(claims *Claims) bool {
+		return claims.HasRole(role)
+	})
+}
+
+// RequireOrgRole recusa requisições sem organização ativa ou cujo papel na
+// organização seja inferior ao informado. Deve ser montado depois de
+// Authenticate.
+func RequireOrgRole(role string) func(http.Handler) http.Handler {
+	return require(func(claims *Claims) bool {
+		return claims.HasOrgRole(role)
+	})
+}
+
+func require(allowed func(*Claims) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+
+			if !allowed(claims) {
+				writeError(w, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
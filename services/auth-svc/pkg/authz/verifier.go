@@ -0,0 +1,52 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken token ausente, malformado, expirado ou com assinatura inválida
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Verifier valida um access token e extrai suas claims. A implementação
+// para outros serviços é verifier.JWKSVerifier, que valida offline com as
+// chaves públicas do auth-svc.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// ParseClaims converte as claims de um access token do auth-svc
+func ParseClaims(mapClaims jwt.MapClaims) (*Claims, error) {
+	if tokenType, _ := mapClaims["type"].(string); tokenType != "access" {
+		return nil, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+	}
+
+	claims := &Claims{}
+	claims.TokenID, _ = mapClaims["jti"].(string)
+	claims.UserID, _ = mapClaims["user_id"].(string)
+	claims.SessionID, _ = mapClaims["sid"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.OrgID, _ = mapClaims["org_id"].(string)
+	claims.OrgRole, _ = mapClaims["org_role"].(string)
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("%w: missing user ID", ErrInvalidToken)
+	}
+
+	if roles, ok := mapClaims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				claims.Roles = append(claims.Roles, name)
+			}
+		}
+	}
+
+	if scope, ok := mapClaims["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	}
+
+	return claims, nil
+}
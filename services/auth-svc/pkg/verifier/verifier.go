@@ -0,0 +1,273 @@
+// Package verifier valida offline os access tokens do auth-svc com as chaves
+// públicas publicadas em /.well-known/jwks.json. Implementa authz.Verifier:
+//
+//	v := verifier.New("http://auth-svc:8080/.well-known/jwks.json")
+//	router.Use(authz.Authenticate(v))
+package verifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"pagemagic/auth-svc/pkg/authz"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultCacheTTL = 10 * time.Minute
+	// minRefreshInterval limita as buscas do JWKS, inclusive com o cache
+	// vencido: com o auth-svc fora do ar, no máximo uma a cada intervalo
+	minRefreshInterval = 30 * time.Second
+	// fetchTimeout limita a busca, que roda fora da requisição que a disparou
+	fetchTimeout = 5 * time.Second
+)
+
+var errUnknownKey = errors.New("unknown signing key")
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// JWKSVerifier valida tokens RS256/EdDSA buscando as chaves no endpoint JWKS.
+// As chaves ficam em cache por CacheTTL; um kid desconhecido (rotação)
+// força uma nova busca. Se o endpoint estiver indisponível, as chaves já
+// conhecidas continuam sendo usadas. Só uma busca roda por vez, fora do
+// mutex, e as requisições concorrentes aguardam o mesmo resultado.
+type JWKSVerifier struct {
+	url      string
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]publicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+	// refreshing é fechado ao fim da busca em andamento; nil se nenhuma
+	refreshing chan struct{}
+}
+
+// Option configura o JWKSVerifier
+type Option func(*JWKSVerifier)
+
+// WithHTTPClient define o cliente HTTP usado para buscar o JWKS
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *JWKSVerifier) {
+		v.client = client
+	}
+}
+
+// WithCacheTTL define por quanto tempo as chaves são mantidas em cache
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(v *JWKSVerifier) {
+		v.cacheTTL = ttl
+	}
+}
+
+// New cria um verificador para o endpoint JWKS informado
+func New(jwksURL string, opts ...Option) *JWKSVerifier {
+	v := &JWKSVerifier{
+		url:      jwksURL,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		cacheTTL: defaultCacheTTL,
+		keys:     make(map[string]publicKey),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify valida assinatura e expiração do access token e retorna suas claims.
+// Não consulta revogações (logout); para isso use a introspecção do auth-svc.
+func (v *JWKSVerifier) Verify(ctx context.Context, tokenString string) (*authz.Claims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.alg {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", authz.ErrInvalidToken, err)
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, authz.ErrInvalidToken
+	}
+
+	return authz.ParseClaims(mapClaims)
+}
+
+// key retorna a chave do kid, buscando o JWKS se o cache estiver vencido ou
+// não conhecer o kid. Com o cache vencido a chave conhecida é usada sem
+// esperar a busca, que roda em segundo plano.
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (publicKey, error) {
+	v.mu.Lock()
+	key, known := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > v.cacheTTL
+	if known && !stale {
+		v.mu.Unlock()
+		return key, nil
+	}
+	done := v.startRefresh()
+	v.mu.Unlock()
+
+	if known {
+		return key, nil
+	}
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return publicKey{}, ctx.Err()
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, known = v.keys[kid]
+	if !known {
+		if len(v.keys) == 0 && v.lastErr != nil {
+			return publicKey{}, v.lastErr
+		}
+		return publicKey{}, errUnknownKey
+	}
+	return key, nil
+}
+
+// startRefresh inicia a busca do JWKS, a menos que uma já esteja em
+// andamento ou que a última tentativa tenha sido há menos de
+// minRefreshInterval. Retorna o canal da busca em andamento, ou nil se
+// nenhuma foi iniciada. Chamado com v.mu travado.
+func (v *JWKSVerifier) startRefresh() chan struct{} {
+	if v.refreshing != nil {
+		return v.refreshing
+	}
+	if time.Since(v.lastAttempt) < minRefreshInterval {
+		return nil
+	}
+
+	done := make(chan struct{})
+	v.refreshing = done
+	v.lastAttempt = time.Now()
+
+	go func() {
+		defer close(done)
+
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		keys, err := v.fetch(ctx)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		v.refreshing = nil
+		v.lastErr = err
+		if err == nil {
+			v.keys = keys
+			v.fetchedAt = time.Now()
+		}
+	}()
+
+	return done
+}
+
+// fetch busca e decodifica o JWKS; roda sem v.mu travado
+func (v *JWKSVerifier) fetch(ctx context.Context) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Tipos de chave desconhecidos são ignorados
+			continue
+		}
+		keys[k.KeyID] = key
+	}
+
+	return keys, nil
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+}
+
+func (k jwk) publicKey() (publicKey, error) {
+	switch {
+	case k.KeyType == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return publicKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return publicKey{}, err
+		}
+		return publicKey{
+			alg: jwt.SigningMethodRS256.Alg(),
+			key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+		}, nil
+	case k.KeyType == "OKP" && k.Curve == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return publicKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return publicKey{}, fmt.Errorf("invalid Ed25519 key size")
+		}
+		return publicKey{alg: jwt.SigningMethodEdDSA.Alg(), key: ed25519.PublicKey(x)}, nil
+	default:
+		return publicKey{}, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
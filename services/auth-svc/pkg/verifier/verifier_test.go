@@ -0,0 +1,121 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pagemagic/auth-svc/internal/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer publica o JWKS do conjunto e conta as buscas; down simula o
+// auth-svc fora do ar
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	down    atomic.Bool
+}
+
+func newJWKSServer(t *testing.T, keys *signing.KeySet) *jwksServer {
+	t.Helper()
+
+	server := &jwksServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.fetches.Add(1)
+		if server.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(keys.JWKS())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func signAccessToken(t *testing.T, keys *signing.KeySet) string {
+	t.Helper()
+
+	token, err := keys.Sign(jwt.MapClaims{
+		"type":    "access",
+		"user_id": "user-1",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	return token
+}
+
+// age simula a passagem do tempo desde a última busca
+func age(v *JWKSVerifier, d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.fetchedAt = v.fetchedAt.Add(-d)
+	v.lastAttempt = v.lastAttempt.Add(-d)
+}
+
+func TestVerifierServesCachedKeysWhileJWKSIsDown(t *testing.T) {
+	keys, err := signing.NewEphemeral()
+	require.NoError(t, err)
+	server := newJWKSServer(t, keys)
+	token := signAccessToken(t, keys)
+	ctx := context.Background()
+
+	v := New(server.URL)
+	_, err = v.Verify(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.fetches.Load())
+
+	server.down.Store(true)
+	age(v, defaultCacheTTL+time.Second)
+
+	// Com o cache vencido as requisições seguem com a chave conhecida e
+	// disparam uma única busca
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.Verify(ctx, token)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		return v.refreshing == nil
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), server.fetches.Load())
+
+	// A busca que falhou não é repetida antes de minRefreshInterval
+	for i := 0; i < 5; i++ {
+		_, err := v.Verify(ctx, token)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
+
+func TestVerifierReturnsFetchErrorWithoutKeys(t *testing.T) {
+	keys, err := signing.NewEphemeral()
+	require.NoError(t, err)
+	server := newJWKSServer(t, keys)
+	server.down.Store(true)
+	token := signAccessToken(t, keys)
+
+	v := New(server.URL)
+	for i := 0; i < 3; i++ {
+		_, err = v.Verify(context.Background(), token)
+		assert.ErrorContains(t, err, "status 503")
+	}
+	assert.Equal(t, int32(1), server.fetches.Load())
+}